@@ -0,0 +1,97 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// rpcBackend is a Backend that exposes its underlying RPC client, as
+// *ethclient.Client and the client's HTTP backend do
+type rpcBackend interface {
+	Client() *rpc.Client
+}
+
+// AccessListFor asks the node for the EIP-2930 access list of a call with
+// eth_createAccessList, for use with WithAccessList. A zero call.From is
+// replaced by the client's address.
+func (c *Client) AccessListFor(ctx context.Context, call ethereum.CallMsg) (types.AccessList, error) {
+	backend, ok := c.client.(rpcBackend)
+	if !ok {
+		return nil, fmt.Errorf("backend does not support eth_createAccessList")
+	}
+
+	if call.From == (common.Address{}) {
+		call.From = c.address
+	}
+
+	ctx, cancel := c.withTimeout(ctx, timeoutRead)
+	defer cancel()
+
+	accessList, _, vmErr, err := gethclient.New(backend.Client()).CreateAccessList(ctx, call)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create access list: %w", err)
+	}
+	if vmErr != "" {
+		return nil, fmt.Errorf("failed to create access list: call failed: %s", vmErr)
+	}
+
+	return *accessList, nil
+}
+
+// transactWithAccessList signs and sends a contract call with an access list,
+// as an EIP-1559 transaction when auth has no gas price and as an EIP-2930
+// transaction otherwise, which bind.BoundContract cannot build
+func (c *Client) transactWithAccessList(auth *bind.TransactOpts, contract, method string, args []interface{}, accessList types.AccessList) (*types.Transaction, error) {
+	address, err := c.contractAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := c.contractABI(contract).Pack(method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+
+	var unsigned types.TxData = &types.AccessListTx{
+		ChainID:    c.chainID,
+		Nonce:      auth.Nonce.Uint64(),
+		GasPrice:   auth.GasPrice,
+		Gas:        auth.GasLimit,
+		To:         &address,
+		Value:      new(big.Int),
+		Data:       data,
+		AccessList: accessList,
+	}
+	if auth.GasPrice == nil {
+		unsigned = &types.DynamicFeeTx{
+			ChainID:    c.chainID,
+			Nonce:      auth.Nonce.Uint64(),
+			GasTipCap:  auth.GasTipCap,
+			GasFeeCap:  auth.GasFeeCap,
+			Gas:        auth.GasLimit,
+			To:         &address,
+			Value:      new(big.Int),
+			Data:       data,
+			AccessList: accessList,
+		}
+	}
+
+	tx, err := auth.Signer(auth.From, types.NewTx(unsigned))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.client.SendTransaction(auth.Context, tx); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
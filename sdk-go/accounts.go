@@ -0,0 +1,95 @@
+package synapse
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// account is a signing account of the client. Each account has its own
+// nonces, so transactions from different accounts never wait on each other.
+type account struct {
+	address common.Address
+	signer  Signer
+	nonces  nonceManager
+}
+
+// newAccounts returns the account table of a client, holding the default
+// signer, if any, and extra
+func newAccounts(signer Signer, extra []Signer) map[common.Address]*account {
+	accounts := make(map[common.Address]*account, len(extra)+1)
+	for _, s := range extra {
+		accounts[s.Address()] = &account{address: s.Address(), signer: s}
+	}
+	if signer != nil {
+		accounts[signer.Address()] = &account{address: signer.Address(), signer: signer}
+	}
+	return accounts
+}
+
+// AddAccount registers an additional account that write methods can send
+// from with WithFrom, e.g. one of several agent identities derived with
+// DeriveMnemonicSigners. An address can only be registered once.
+func (c *Client) AddAccount(signer Signer) error {
+	c.accountsMu.Lock()
+	defer c.accountsMu.Unlock()
+
+	address := signer.Address()
+	if _, ok := c.accounts[address]; ok {
+		return fmt.Errorf("account %s is already registered", address.Hex())
+	}
+	c.accounts[address] = &account{address: address, signer: signer}
+	return nil
+}
+
+// Accounts returns the addresses the client can send from: the default
+// account first, followed by those added with AddAccount or Config.Accounts
+// in address order
+func (c *Client) Accounts() []common.Address {
+	c.accountsMu.Lock()
+	defer c.accountsMu.Unlock()
+
+	var addresses []common.Address
+	for address := range c.accounts {
+		if address != c.address || c.signer == nil {
+			addresses = append(addresses, address)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return addresses[i].Cmp(addresses[j]) < 0
+	})
+
+	if c.signer != nil {
+		addresses = append([]common.Address{c.address}, addresses...)
+	}
+	return addresses
+}
+
+// sender returns the account to send from: from, or the default account if
+// from is zero
+func (c *Client) sender(from common.Address) (*account, error) {
+	if from == (common.Address{}) {
+		if err := c.requireSigner(); err != nil {
+			return nil, err
+		}
+		from = c.address
+	}
+
+	c.accountsMu.Lock()
+	defer c.accountsMu.Unlock()
+
+	acct, ok := c.accounts[from]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAccount, from.Hex())
+	}
+	return acct, nil
+}
+
+// senderAddress returns the address a call with o is sent from
+func (c *Client) senderAddress(o *txOptions) common.Address {
+	if o.from != (common.Address{}) {
+		return o.from
+	}
+	return c.address
+}
@@ -0,0 +1,244 @@
+package synapse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// AgentPick identifies one side of an agent comparison
+type AgentPick int8
+
+const (
+	PickTie AgentPick = iota
+	PickA
+	PickB
+)
+
+// AgentComparison reports which of two agents wins on each criterion and the
+// recommended counterparty
+type AgentComparison struct {
+	HigherTier        AgentPick
+	HigherSuccessRate AgentPick
+	MoreExperienced   AgentPick
+	Recommended       AgentPick
+}
+
+// CompareAgents compares two agents by tier, weighted success rate and
+// TotalTransactions. The agent winning more criteria is recommended; if they
+// split evenly, the higher weighted success rate decides.
+func CompareAgents(a, b *AgentInfo) AgentComparison {
+	cmp := AgentComparison{
+		HigherTier:        pickGreater(float64(a.Tier), float64(b.Tier)),
+		HigherSuccessRate: pickGreater(a.WeightedSuccessRate(), b.WeightedSuccessRate()),
+		MoreExperienced:   pickGreater(float64(a.TotalTransactions), float64(b.TotalTransactions)),
+	}
+
+	var score int
+	for _, pick := range []AgentPick{cmp.HigherTier, cmp.HigherSuccessRate, cmp.MoreExperienced} {
+		switch pick {
+		case PickA:
+			score++
+		case PickB:
+			score--
+		}
+	}
+
+	switch {
+	case score > 0:
+		cmp.Recommended = PickA
+	case score < 0:
+		cmp.Recommended = PickB
+	default:
+		cmp.Recommended = cmp.HigherSuccessRate
+	}
+
+	return cmp
+}
+
+// pickGreater returns the side with the greater value
+func pickGreater(a, b float64) AgentPick {
+	switch {
+	case a > b:
+		return PickA
+	case b > a:
+		return PickB
+	}
+	return PickTie
+}
+
+// Onboard registers the client as an agent, first approving the Reputation
+// contract for the stake plus registration fee if the current allowance is
+// short. The approval is waited on before registering. It returns the
+// registration hash and the approval hash, which is zero if no approval was
+// needed.
+func (c *Client) Onboard(ctx context.Context, params RegisterAgentParams) (registerTx, approveTx common.Hash, err error) {
+	reputation, err := c.contractAddress(ContractReputation)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+
+	out, err := c.callContract(ctx, ContractReputation, "registrationFee")
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+	required := new(big.Int).Add(params.Stake, out[0].(*big.Int))
+
+	allowance, err := c.GetAllowance(ctx, c.address, reputation)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
+
+	if allowance.Cmp(required) < 0 {
+		_, approveTx, err = c.Approve(ctx, reputation, required)
+		if err != nil {
+			return common.Hash{}, common.Hash{}, err
+		}
+
+		if _, err := c.waitForHash(ctx, approveTx); err != nil {
+			return common.Hash{}, approveTx, err
+		}
+	}
+
+	registerTx, err = c.RegisterAgent(ctx, params)
+	if err != nil {
+		return common.Hash{}, approveTx, err
+	}
+
+	return registerTx, approveTx, nil
+}
+
+// AgentProfile is an agent's self-published off-chain profile
+type AgentProfile struct {
+	Address      common.Address `json:"address"`
+	Name         string         `json:"name"`
+	Endpoints    []string       `json:"endpoints,omitempty"`
+	Capabilities []string       `json:"capabilities,omitempty"`
+	MetadataURI  string         `json:"metadataURI,omitempty"`
+}
+
+// BuildAgentProfile encodes a profile as canonical JSON: fields in declaration
+// order with no insignificant whitespace, so equal profiles sign identically
+func BuildAgentProfile(profile AgentProfile) ([]byte, error) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode agent profile: %w", err)
+	}
+	return data, nil
+}
+
+// SignAgentProfile encodes a profile for the client's address and signs it as
+// an EIP-191 personal message
+func (c *Client) SignAgentProfile(profile AgentProfile) (data, signature []byte, err error) {
+	if err := c.requireSigner(); err != nil {
+		return nil, nil, err
+	}
+
+	profile.Address = c.address
+	data, err = BuildAgentProfile(profile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	signature, err = c.signMessage(data)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to sign agent profile: %w", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return data, signature, nil
+}
+
+// ProveAddressOwnership signs a verifier's challenge as an EIP-191 personal
+// message, proving the client controls its address. Verifiers check the proof
+// with VerifyAddressOwnership or ecrecover.
+func (c *Client) ProveAddressOwnership(challenge []byte) ([]byte, error) {
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+
+	signature, err := c.signMessage(challenge)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign challenge: %w", err)
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
+
+// VerifyAddressOwnership returns the address that produced a
+// ProveAddressOwnership proof for a challenge
+func VerifyAddressOwnership(challenge, proof []byte) (common.Address, error) {
+	return recoverSigner(accounts.TextHash(challenge), proof)
+}
+
+// VerifyAgentProfile recovers the signer of a profile and checks it matches
+// the profile's address. Callers should compare the result with the on-chain
+// agent they expect.
+func VerifyAgentProfile(data, signature []byte) (common.Address, error) {
+	signer, err := recoverSigner(accounts.TextHash(data), signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	var profile AgentProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return common.Address{}, fmt.Errorf("failed to decode agent profile: %w", err)
+	}
+	if profile.Address != signer {
+		return common.Address{}, fmt.Errorf("agent profile for %s signed by %s", profile.Address.Hex(), signer.Hex())
+	}
+
+	return signer, nil
+}
+
+// StakeStatus describes how much of an agent's stake can be withdrawn
+type StakeStatus struct {
+	Total        *big.Int
+	Locked       *big.Int
+	Withdrawable *big.Int
+	// UnlockTime is when locked stake becomes withdrawable, or zero if the
+	// lock has no expiry
+	UnlockTime uint64
+}
+
+// GetStakeStatus returns an agent's stake and the part WithdrawStake would
+// accept. The ReputationRegistry has no time-based lock: the minimum stake of
+// the agent's current tier stays locked while the agent holds that tier, and
+// nothing can be withdrawn unless the agent is active, so UnlockTime is
+// always zero.
+func (c *Client) GetStakeStatus(ctx context.Context, agent common.Address) (*StakeStatus, error) {
+	out, err := c.callContract(ctx, ContractReputation, "getAgent", agent)
+	if err != nil {
+		return nil, err
+	}
+	data := *abi.ConvertType(out[0], new(agentData)).(*agentData)
+
+	status := &StakeStatus{
+		Total:        data.StakedAmount,
+		Locked:       new(big.Int).Set(data.StakedAmount),
+		Withdrawable: new(big.Int),
+	}
+	if data.Status != agentStatusActive {
+		return status, nil
+	}
+
+	out, err = c.callContract(ctx, ContractReputation, "getTierRequirements", data.Tier)
+	if err != nil {
+		return nil, err
+	}
+	requirements := *abi.ConvertType(out[0], new(tierRequirementsData)).(*tierRequirementsData)
+
+	if requirements.MinStake.Cmp(data.StakedAmount) < 0 {
+		status.Locked.Set(requirements.MinStake)
+		status.Withdrawable.Sub(data.StakedAmount, requirements.MinStake)
+	}
+
+	return status, nil
+}
@@ -0,0 +1,219 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestCompareAgents(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b AgentInfo
+		want AgentComparison
+	}{
+		{
+			name: "identical agents tie",
+			a:    AgentInfo{Tier: TierSilver, TotalTransactions: 10, SuccessfulTransactions: 9},
+			b:    AgentInfo{Tier: TierSilver, TotalTransactions: 10, SuccessfulTransactions: 9},
+			want: AgentComparison{},
+		},
+		{
+			name: "a wins every criterion",
+			a:    AgentInfo{Tier: TierGold, TotalTransactions: 100, SuccessfulTransactions: 99},
+			b:    AgentInfo{Tier: TierBronze, TotalTransactions: 10, SuccessfulTransactions: 5},
+			want: AgentComparison{HigherTier: PickA, HigherSuccessRate: PickA, MoreExperienced: PickA, Recommended: PickA},
+		},
+		{
+			name: "b wins two of three",
+			a:    AgentInfo{Tier: TierGold, TotalTransactions: 10, SuccessfulTransactions: 5},
+			b:    AgentInfo{Tier: TierBronze, TotalTransactions: 100, SuccessfulTransactions: 99},
+			want: AgentComparison{HigherTier: PickA, HigherSuccessRate: PickB, MoreExperienced: PickB, Recommended: PickB},
+		},
+		{
+			// One perfect transaction does not beat a long, nearly perfect record
+			name: "success rate is weighted by volume",
+			a:    AgentInfo{Tier: TierSilver, TotalTransactions: 1, SuccessfulTransactions: 1},
+			b:    AgentInfo{Tier: TierSilver, TotalTransactions: 200, SuccessfulTransactions: 190},
+			want: AgentComparison{HigherSuccessRate: PickB, MoreExperienced: PickB, Recommended: PickB},
+		},
+		{
+			name: "even split falls back to success rate",
+			a:    AgentInfo{Tier: TierGold, TotalTransactions: 50, SuccessfulTransactions: 25},
+			b:    AgentInfo{Tier: TierBronze, TotalTransactions: 50, SuccessfulTransactions: 45},
+			want: AgentComparison{HigherTier: PickA, HigherSuccessRate: PickB, Recommended: PickB},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareAgents(&tt.a, &tt.b); got != tt.want {
+				t.Errorf("CompareAgents = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testAgent returns a registered Reputation agent with a transaction record
+func testAgent(owner common.Address, total, successful int64) agentData {
+	return agentData{
+		Owner:                  owner,
+		RegistrationTime:       new(big.Int),
+		StakedAmount:           new(big.Int),
+		ReputationScore:        new(big.Int),
+		TotalTransactions:      big.NewInt(total),
+		SuccessfulTransactions: big.NewInt(successful),
+		FailedTransactions:     big.NewInt(total - successful),
+		TotalVolume:            new(big.Int),
+		DisputesRaised:         new(big.Int),
+		DisputesLost:           new(big.Int),
+		Status:                 agentStatusActive,
+	}
+}
+
+// withAgents makes the Reputation mock serve agents by owner, and an
+// unregistered agent for anyone else
+func withAgents(backend *mockBackend, agents ...agentData) {
+	byOwner := make(map[common.Address]agentData)
+	for _, agent := range agents {
+		byOwner[agent.Owner] = agent
+	}
+
+	backend.handle(testContracts.Reputation, reputationABI, "getAgent", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		agent, ok := byOwner[args[0].(common.Address)]
+		if !ok {
+			agent = testAgent(args[0].(common.Address), 0, 0)
+			agent.Status = agentStatusUnregistered
+		}
+		return []interface{}{agent}, nil
+	})
+}
+
+func TestOnboard(t *testing.T) {
+	params := RegisterAgentParams{Name: "agent", MetadataURI: "ipfs://agent", Stake: big.NewInt(1000)}
+
+	tests := []struct {
+		name        string
+		allowance   int64
+		wantApprove bool
+	}{
+		{"zero allowance", 0, true},
+		{"allowance short of the fee", 1000, true},
+		{"allowance covers stake and fee", 1050, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			backend.returns(testContracts.Reputation, reputationABI, "registrationFee", big.NewInt(50))
+			backend.returns(testContracts.Token, tokenABI, "allowance", big.NewInt(tt.allowance))
+			backend.returns(testContracts.Token, tokenABI, "approve", true)
+			backend.returns(testContracts.Reputation, reputationABI, "registerAgent")
+
+			registerTx, approveTx, err := c.Onboard(context.Background(), params)
+			if err != nil {
+				t.Fatalf("Onboard: %v", err)
+			}
+
+			sent := backend.sentTxs()
+			wantApprove, _ := tokenABI.Pack("approve", testContracts.Reputation, big.NewInt(1050))
+			wantRegister, _ := reputationABI.Pack("registerAgent", params.MetadataURI, params.Stake)
+			if tt.wantApprove {
+				if len(sent) != 2 || sent[0].Hash() != approveTx || !bytes.Equal(sent[0].Data(), wantApprove) {
+					t.Fatalf("want an approval of stake plus fee first, sent %d transactions", len(sent))
+				}
+				sent = sent[1:]
+			} else if approveTx != (common.Hash{}) {
+				t.Errorf("approveTx = %s, want none", approveTx.Hex())
+			}
+			if len(sent) != 1 || sent[0].Hash() != registerTx || !bytes.Equal(sent[0].Data(), wantRegister) {
+				t.Errorf("registration not sent as returned")
+			}
+		})
+	}
+}
+
+func TestGetStakeStatus(t *testing.T) {
+	tests := []struct {
+		name                 string
+		stake                int64
+		tier                 Tier
+		status               uint8
+		locked, withdrawable int64
+	}{
+		{"stake above the tier minimum", 5000, TierSilver, agentStatusActive, 2000, 3000},
+		{"stake at the tier minimum", 2000, TierSilver, agentStatusActive, 2000, 0},
+		// Suspended agents cannot withdraw
+		{"inactive agent", 5000, TierSilver, 2, 5000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			agent := testAgent(testAddress(1), 0, 0)
+			agent.StakedAmount = big.NewInt(tt.stake)
+			agent.Tier = uint8(tt.tier)
+			agent.Status = tt.status
+			withAgents(backend, agent)
+			backend.handle(testContracts.Reputation, reputationABI, "getTierRequirements", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				return []interface{}{tierRequirementsData{
+					MinTransactions: new(big.Int),
+					MinSuccessRate:  new(big.Int),
+					MinStake:        big.NewInt(int64(args[0].(uint8)) * 1000),
+					FeeDiscount:     new(big.Int),
+				}}, nil
+			})
+
+			status, err := c.GetStakeStatus(context.Background(), agent.Owner)
+			if err != nil {
+				t.Fatalf("GetStakeStatus: %v", err)
+			}
+			if status.Total.Int64() != tt.stake || status.Locked.Int64() != tt.locked || status.Withdrawable.Int64() != tt.withdrawable {
+				t.Errorf("total, locked, withdrawable = %s, %s, %s, want %d, %d, %d",
+					status.Total, status.Locked, status.Withdrawable, tt.stake, tt.locked, tt.withdrawable)
+			}
+			// The registry locks stake by tier, not until a time
+			if status.UnlockTime != 0 {
+				t.Errorf("UnlockTime = %d, want 0", status.UnlockTime)
+			}
+		})
+	}
+}
+
+func TestProveAddressOwnership(t *testing.T) {
+	c := newTestClient(t, newMockBackend(), Config{})
+	challenge := []byte("synapse-handshake:42")
+
+	if key := c.PublicKey(); key == nil || crypto.PubkeyToAddress(*key) != c.Address() {
+		t.Fatalf("PublicKey = %v, want the key of %s", key, c.Address().Hex())
+	}
+
+	proof, err := c.ProveAddressOwnership(challenge)
+	if err != nil {
+		t.Fatalf("ProveAddressOwnership: %v", err)
+	}
+	signer, err := VerifyAddressOwnership(challenge, proof)
+	if err != nil {
+		t.Fatalf("VerifyAddressOwnership: %v", err)
+	}
+	if signer != c.Address() {
+		t.Errorf("proof recovers to %s, want %s", signer.Hex(), c.Address().Hex())
+	}
+
+	// ecrecover over the EIP-191 hash agrees
+	sig := bytes.Clone(proof)
+	sig[crypto.RecoveryIDOffset] -= 27
+	key, err := crypto.SigToPub(accounts.TextHash(challenge), sig)
+	if err != nil || crypto.PubkeyToAddress(*key) != c.Address() {
+		t.Errorf("ecrecover = %v, %v, want %s", key, err, c.Address().Hex())
+	}
+
+	if signer, err := VerifyAddressOwnership([]byte("another challenge"), proof); err == nil && signer == c.Address() {
+		t.Error("proof verified for another challenge")
+	}
+}
@@ -0,0 +1,568 @@
+package synapse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Contract addresses the test clients are configured with
+var testContracts = ContractAddresses{
+	Token:           common.HexToAddress("0x1000000000000000000000000000000000000001"),
+	PaymentRouter:   common.HexToAddress("0x1000000000000000000000000000000000000002"),
+	Reputation:      common.HexToAddress("0x1000000000000000000000000000000000000003"),
+	ServiceRegistry: common.HexToAddress("0x1000000000000000000000000000000000000004"),
+	PaymentChannel:  common.HexToAddress("0x1000000000000000000000000000000000000005"),
+}
+
+// testKey returns a deterministic private key for test account n
+func testKey(n int) *ecdsa.PrivateKey {
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(fmt.Sprintf("synapse test key %d", n))))
+	if err != nil {
+		panic(err)
+	}
+	return key
+}
+
+// testAddress returns the address of testKey(n)
+func testAddress(n int) common.Address {
+	return crypto.PubkeyToAddress(testKey(n).PublicKey)
+}
+
+// callHandler answers a contract call with the method's outputs
+type callHandler func(from common.Address, args []interface{}) ([]interface{}, error)
+
+type handlerKey struct {
+	to       common.Address
+	selector [4]byte
+}
+
+// methodHandler is a registered handler with the method it answers
+type methodHandler struct {
+	method abi.Method
+	fn     callHandler
+}
+
+// mockBackend is an in-memory Backend. Contract calls are answered by
+// handlers registered per contract method, and sent transactions are mined
+// immediately into a block of their own unless autoMine is off.
+type mockBackend struct {
+	mu sync.Mutex
+
+	chainID  *big.Int
+	baseFee  *big.Int
+	gasPrice *big.Int
+	tip      *big.Int
+	gasLimit uint64
+	autoMine bool
+
+	headers  []*types.Header
+	nonces   map[common.Address]uint64
+	balances map[common.Address]*big.Int
+	txs      map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	pool     []*types.Transaction
+	sent     []*types.Transaction
+	logs     []types.Log
+	handlers map[handlerKey]methodHandler
+
+	// receiptLogs, if set, returns the logs of tx mined in the block of
+	// header. It is called with mu held.
+	receiptLogs func(tx *types.Transaction, header *types.Header) []*types.Log
+	// estimateGas, if set, replaces the fixed gas estimate
+	estimateGas func(msg ethereum.CallMsg) (uint64, error)
+	// err, if set, is returned by every call before it is handled
+	err func(method string) error
+}
+
+// newMockBackend returns a backend for chain 1337 with an EIP-1559 genesis
+// block
+func newMockBackend() *mockBackend {
+	b := &mockBackend{
+		chainID:  big.NewInt(1337),
+		baseFee:  big.NewInt(1e9),
+		gasPrice: big.NewInt(2e9),
+		tip:      big.NewInt(1e9),
+		gasLimit: 30_000_000,
+		autoMine: true,
+		nonces:   make(map[common.Address]uint64),
+		balances: make(map[common.Address]*big.Int),
+		txs:      make(map[common.Hash]*types.Transaction),
+		receipts: make(map[common.Hash]*types.Receipt),
+		handlers: make(map[handlerKey]methodHandler),
+	}
+	b.headers = []*types.Header{{Number: new(big.Int), Time: 1_700_000_000, GasLimit: b.gasLimit, BaseFee: b.baseFee}}
+	return b
+}
+
+// newTestClient returns a client for testKey(0) on backend
+func newTestClient(t *testing.T, backend *mockBackend, config Config) *Client {
+	t.Helper()
+
+	if config.Signer == nil && config.PrivateKey == "" {
+		config.Signer = NewLocalSigner(testKey(0))
+	}
+	if config.Contracts == (ContractAddresses{}) {
+		config.Contracts = testContracts
+	}
+
+	c, err := NewClientWithBackend(backend, config)
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	return c
+}
+
+// handle registers fn to answer calls of method on the contract at to
+func (b *mockBackend) handle(to common.Address, contractABI abi.ABI, method string, fn callHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var selector [4]byte
+	copy(selector[:], contractABI.Methods[method].ID)
+	b.handlers[handlerKey{to, selector}] = methodHandler{contractABI.Methods[method], fn}
+}
+
+// returns registers a handler for method that always returns outputs
+func (b *mockBackend) returns(to common.Address, contractABI abi.ABI, method string, outputs ...interface{}) {
+	b.handle(to, contractABI, method, func(common.Address, []interface{}) ([]interface{}, error) {
+		return outputs, nil
+	})
+}
+
+// call runs the handler for a call message
+func (b *mockBackend) call(msg ethereum.CallMsg) ([]byte, error) {
+	if msg.To == nil || len(msg.Data) < 4 {
+		return nil, nil
+	}
+
+	var selector [4]byte
+	copy(selector[:], msg.Data)
+
+	b.mu.Lock()
+	handler, ok := b.handlers[handlerKey{*msg.To, selector}]
+	b.mu.Unlock()
+	if !ok {
+		return nil, &mockRevertError{}
+	}
+
+	args, err := handler.method.Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+
+	outputs, err := handler.fn(msg.From, args)
+	if err != nil {
+		return nil, err
+	}
+	return handler.method.Outputs.Pack(outputs...)
+}
+
+// mockRevertError is a revert as returned by a node, carrying revert data
+type mockRevertError struct {
+	data []byte
+}
+
+func (e *mockRevertError) Error() string          { return "execution reverted" }
+func (e *mockRevertError) ErrorCode() int         { return 3 }
+func (e *mockRevertError) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+// revertWith returns a revert of a custom error of contractABI
+func revertWith(contractABI abi.ABI, name string) error {
+	id := contractABI.Errors[name].ID
+	return &mockRevertError{data: id[:4]}
+}
+
+// fail returns the configured error for a backend method, if any
+func (b *mockBackend) fail(method string) error {
+	if b.err == nil {
+		return nil
+	}
+	return b.err(method)
+}
+
+// head returns the latest header
+func (b *mockBackend) head() *types.Header {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.headers[len(b.headers)-1]
+}
+
+// mine includes the pooled transactions in a new block
+func (b *mockBackend) mine() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mineLocked()
+}
+
+// mineLocked is mine with mu held
+func (b *mockBackend) mineLocked() {
+	parent := b.headers[len(b.headers)-1]
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number, big.NewInt(1)),
+		Time:       parent.Time + 12,
+		GasLimit:   b.gasLimit,
+		BaseFee:    b.baseFee,
+	}
+	b.headers = append(b.headers, header)
+
+	for i, tx := range b.pool {
+		from, _ := types.Sender(types.LatestSignerForChainID(b.chainID), tx)
+		b.nonces[from] = tx.Nonce() + 1
+
+		receipt := &types.Receipt{
+			Status:            types.ReceiptStatusSuccessful,
+			TxHash:            tx.Hash(),
+			GasUsed:           tx.Gas(),
+			EffectiveGasPrice: tx.GasPrice(),
+			BlockHash:         header.Hash(),
+			BlockNumber:       header.Number,
+			TransactionIndex:  uint(i),
+		}
+		if b.receiptLogs != nil {
+			for _, log := range b.receiptLogs(tx, header) {
+				log.TxHash = tx.Hash()
+				log.BlockHash = header.Hash()
+				log.BlockNumber = header.Number.Uint64()
+				receipt.Logs = append(receipt.Logs, log)
+				b.logs = append(b.logs, *log)
+			}
+		}
+		b.receipts[tx.Hash()] = receipt
+	}
+	b.pool = nil
+}
+
+// addLog appends a log to the chain in a new block
+func (b *mockBackend) addLog(log types.Log) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.mineLocked()
+	head := b.headers[len(b.headers)-1]
+	log.BlockNumber = head.Number.Uint64()
+	log.BlockHash = head.Hash()
+	b.logs = append(b.logs, log)
+}
+
+// sentTxs returns the transactions sent so far
+func (b *mockBackend) sentTxs() []*types.Transaction {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]*types.Transaction(nil), b.sent...)
+}
+
+func (b *mockBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	if err := b.fail("BlockNumber"); err != nil {
+		return 0, err
+	}
+	return b.head().Number.Uint64(), nil
+}
+
+func (b *mockBackend) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return nil, ethereum.NotFound
+}
+
+func (b *mockBackend) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	header, err := b.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return types.NewBlockWithHeader(header), nil
+}
+
+func (b *mockBackend) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, header := range b.headers {
+		if header.Hash() == hash {
+			return header, nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (b *mockBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := b.fail("HeaderByNumber"); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if number == nil || number.Sign() < 0 {
+		return b.headers[len(b.headers)-1], nil
+	}
+	if number.Uint64() >= uint64(len(b.headers)) {
+		return nil, ethereum.NotFound
+	}
+	return b.headers[number.Uint64()], nil
+}
+
+func (b *mockBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (uint, error) {
+	return 0, nil
+}
+
+func (b *mockBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (*types.Transaction, error) {
+	return nil, ethereum.NotFound
+}
+
+func (b *mockBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, errors.New("notifications not supported")
+}
+
+func (b *mockBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if balance, ok := b.balances[account]; ok {
+		return new(big.Int).Set(balance), nil
+	}
+	return new(big.Int).Lsh(big.NewInt(1), 100), nil
+}
+
+func (b *mockBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return make([]byte, 32), nil
+}
+
+func (b *mockBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return []byte{0x00}, nil
+}
+
+func (b *mockBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nonces[account], nil
+}
+
+func (b *mockBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if err := b.fail("CallContract"); err != nil {
+		return nil, err
+	}
+	return b.call(msg)
+}
+
+func (b *mockBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if err := b.fail("EstimateGas"); err != nil {
+		return 0, err
+	}
+	if b.estimateGas != nil {
+		return b.estimateGas(msg)
+	}
+	return 100_000, nil
+}
+
+func (b *mockBackend) SuggestGasPrice(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.gasPrice), nil
+}
+
+func (b *mockBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return new(big.Int).Set(b.tip), nil
+}
+
+func (b *mockBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (*ethereum.FeeHistory, error) {
+	return &ethereum.FeeHistory{OldestBlock: b.head().Number, GasUsedRatio: []float64{0.5}}, nil
+}
+
+func (b *mockBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) ([]types.Log, error) {
+	if err := b.fail("FilterLogs"); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var found []types.Log
+	for _, log := range b.logs {
+		if query.FromBlock != nil && log.BlockNumber < query.FromBlock.Uint64() {
+			continue
+		}
+		if query.ToBlock != nil && log.BlockNumber > query.ToBlock.Uint64() {
+			continue
+		}
+		if len(query.Addresses) > 0 && !containsAddress(query.Addresses, log.Address) {
+			continue
+		}
+		if matchesTopics(query.Topics, log.Topics) {
+			found = append(found, log)
+		}
+	}
+	return found, nil
+}
+
+// containsAddress reports whether address is in addresses
+func containsAddress(addresses []common.Address, address common.Address) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesTopics applies a FilterQuery topic filter
+func matchesTopics(filter [][]common.Hash, topics []common.Hash) bool {
+	for i, alternatives := range filter {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(topics) {
+			return false
+		}
+		match := false
+		for _, topic := range alternatives {
+			match = match || topic == topics[i]
+		}
+		if !match {
+			return false
+		}
+	}
+	return true
+}
+
+func (b *mockBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("notifications not supported")
+}
+
+func (b *mockBackend) PendingBalanceAt(ctx context.Context, account common.Address) (*big.Int, error) {
+	return b.BalanceAt(ctx, account, nil)
+}
+
+func (b *mockBackend) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) ([]byte, error) {
+	return b.StorageAt(ctx, account, key, nil)
+}
+
+func (b *mockBackend) PendingCodeAt(ctx context.Context, account common.Address) ([]byte, error) {
+	return b.CodeAt(ctx, account, nil)
+}
+
+func (b *mockBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if err := b.fail("PendingNonceAt"); err != nil {
+		return 0, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	nonce := b.nonces[account]
+	for _, tx := range b.pool {
+		from, _ := types.Sender(types.LatestSignerForChainID(b.chainID), tx)
+		if from == account && tx.Nonce() >= nonce {
+			nonce = tx.Nonce() + 1
+		}
+	}
+	return nonce, nil
+}
+
+func (b *mockBackend) PendingTransactionCount(ctx context.Context) (uint, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return uint(len(b.pool)), nil
+}
+
+func (b *mockBackend) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	return b.CallContract(ctx, msg, nil)
+}
+
+func (b *mockBackend) TransactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	tx, ok := b.txs[hash]
+	if !ok {
+		return nil, false, ethereum.NotFound
+	}
+	_, mined := b.receipts[hash]
+	return tx, !mined, nil
+}
+
+func (b *mockBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	receipt, ok := b.receipts[hash]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (b *mockBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.fail("SendTransaction"); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	from, err := types.Sender(types.LatestSignerForChainID(b.chainID), tx)
+	if err != nil {
+		return err
+	}
+
+	// Replace a pooled transaction with the same nonce if the tip and fee
+	// cap are at least 10% higher, as a node would
+	pool := make([]*types.Transaction, 0, len(b.pool)+1)
+	for _, pooled := range b.pool {
+		pooledFrom, _ := types.Sender(types.LatestSignerForChainID(b.chainID), pooled)
+		if pooledFrom == from && pooled.Nonce() == tx.Nonce() {
+			if !bumped(tx.GasTipCap(), pooled.GasTipCap()) || !bumped(tx.GasFeeCap(), pooled.GasFeeCap()) {
+				return errors.New("replacement transaction underpriced")
+			}
+			continue
+		}
+		pool = append(pool, pooled)
+	}
+	b.pool = append(pool, tx)
+
+	b.txs[tx.Hash()] = tx
+	b.sent = append(b.sent, tx)
+	if b.autoMine {
+		b.mineLocked()
+	}
+	return nil
+}
+
+// bumped reports whether fee is at least 10% above old
+func bumped(fee, old *big.Int) bool {
+	least := new(big.Int).Mul(old, big.NewInt(110))
+	return new(big.Int).Mul(fee, big.NewInt(100)).Cmp(least) >= 0
+}
+
+func (b *mockBackend) ChainID(ctx context.Context) (*big.Int, error) {
+	if err := b.fail("ChainID"); err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return new(big.Int).Set(b.chainID), nil
+}
+
+// eventLog builds a log of event name of contractABI emitted by address,
+// with the indexed fields as topics and the rest packed as data
+func eventLog(address common.Address, contractABI abi.ABI, name string, indexed []common.Hash, data ...interface{}) *types.Log {
+	event := contractABI.Events[name]
+	packed, err := event.Inputs.NonIndexed().Pack(data...)
+	if err != nil {
+		panic(err)
+	}
+	return &types.Log{
+		Address: address,
+		Topics:  append([]common.Hash{event.ID}, indexed...),
+		Data:    packed,
+	}
+}
@@ -0,0 +1,102 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// maxBatchGasFraction is the share of the block gas limit MaxBatchSize fills,
+// leaving room for other transactions and for payments that cost more gas
+// than the sampled ones, e.g. to first-time recipients
+const maxBatchGasFraction = 0.5
+
+// MaxBatchSize returns how many of payments fit in one BatchPay, bounded by
+// the router's MAX_BATCH_SIZE and by half the latest block's gas limit. The
+// gas of a payment is estimated with batchPay of the first one and two
+// payments, so the client must already hold the balance and allowance for
+// them.
+func (c *Client) MaxBatchSize(ctx context.Context, payments []BatchPayment) (int, error) {
+	if len(payments) == 0 {
+		return 0, fmt.Errorf("no payments")
+	}
+
+	out, err := c.callContract(ctx, ContractPaymentRouter, "MAX_BATCH_SIZE")
+	if err != nil {
+		return 0, err
+	}
+	maxSize := out[0].(*big.Int).Uint64()
+
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get latest header: %w", err)
+	}
+
+	one, err := c.estimateBatchPayGas(ctx, payments[:1])
+	if err != nil {
+		return 0, err
+	}
+	baseGas, paymentGas := uint64(0), one
+	if len(payments) > 1 {
+		two, err := c.estimateBatchPayGas(ctx, payments[:2])
+		if err != nil {
+			return 0, err
+		}
+		if two > one {
+			baseGas, paymentGas = 2*one-two, two-one
+		}
+		if baseGas > one {
+			baseGas = 0
+		}
+	}
+
+	budget := uint64(float64(head.GasLimit) * maxBatchGasFraction)
+	var fit uint64
+	if budget > baseGas {
+		fit = (budget - baseGas) / paymentGas
+	}
+	if fit < maxSize {
+		maxSize = fit
+	}
+	if n := uint64(len(payments)); n < maxSize {
+		maxSize = n
+	}
+	if maxSize == 0 {
+		return 0, fmt.Errorf("block gas limit %d cannot fit a batch payment of %d gas", head.GasLimit, one)
+	}
+
+	return int(maxSize), nil
+}
+
+// estimateBatchPayGas estimates the gas of batchPay for payments
+func (c *Client) estimateBatchPayGas(ctx context.Context, payments []BatchPayment) (uint64, error) {
+	recipients := make([]common.Address, len(payments))
+	amounts := make([]*big.Int, len(payments))
+	for i, payment := range payments {
+		recipients[i] = payment.Recipient
+		amounts[i] = payment.Amount
+	}
+
+	return c.estimateContractGasFrom(ctx, c.address, ContractPaymentRouter, "batchPay", recipients, amounts, make([][32]byte, len(payments)))
+}
+
+// SplitBatch splits payments into consecutive chunks of at most size
+// payments, e.g. the result of MaxBatchSize
+func SplitBatch(payments []BatchPayment, size int) [][]BatchPayment {
+	if size <= 0 {
+		return nil
+	}
+
+	chunks := make([][]BatchPayment, 0, (len(payments)+size-1)/size)
+	for start := 0; start < len(payments); start += size {
+		end := start + size
+		if end > len(payments) {
+			end = len(payments)
+		}
+		chunks = append(chunks, payments[start:end:end])
+	}
+
+	return chunks
+}
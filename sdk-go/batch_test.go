@@ -0,0 +1,87 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestMaxBatchSizeSplitsTenThousandPayments(t *testing.T) {
+	const baseGas, paymentGas = 50_000, 30_000
+	batchPay := paymentRouterABI.Methods["batchPay"]
+
+	tests := []struct {
+		name     string
+		gasLimit uint64
+		maxBatch int64
+		want     int
+	}{
+		// (15,000,000 - 50,000) / 30,000
+		{"block gas limit", 30_000_000, 1000, 498},
+		{"router maximum", 30_000_000, 200, 200},
+		// (5,000,000 - 50,000) / 30,000
+		{"small block", 10_000_000, 1000, 165},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.gasLimit = tt.gasLimit
+			backend.headers[0].GasLimit = tt.gasLimit
+			backend.returns(testContracts.PaymentRouter, paymentRouterABI, "MAX_BATCH_SIZE", big.NewInt(tt.maxBatch))
+			backend.estimateGas = func(msg ethereum.CallMsg) (uint64, error) {
+				args, err := batchPay.Inputs.Unpack(msg.Data[4:])
+				if err != nil {
+					return 0, err
+				}
+				return baseGas + uint64(len(args[0].([]common.Address)))*paymentGas, nil
+			}
+			c := newTestClient(t, backend, Config{})
+
+			payments := make([]BatchPayment, 10_000)
+			for i := range payments {
+				payments[i] = BatchPayment{Recipient: testAddress(1), Amount: big.NewInt(int64(i + 1))}
+			}
+
+			size, err := c.MaxBatchSize(context.Background(), payments)
+			if err != nil {
+				t.Fatalf("MaxBatchSize: %v", err)
+			}
+			if size != tt.want {
+				t.Fatalf("MaxBatchSize = %d, want %d", size, tt.want)
+			}
+
+			chunks := SplitBatch(payments, size)
+			if want := (len(payments) + size - 1) / size; len(chunks) != want {
+				t.Fatalf("got %d chunks, want %d", len(chunks), want)
+			}
+			next := int64(1)
+			for i, chunk := range chunks {
+				if len(chunk) > size || (i < len(chunks)-1 && len(chunk) != size) {
+					t.Fatalf("chunk %d has %d payments, size %d", i, len(chunk), size)
+				}
+				if gas := uint64(baseGas + len(chunk)*paymentGas); gas > tt.gasLimit/2 {
+					t.Fatalf("chunk %d needs %d gas, over half the %d block gas limit", i, gas, tt.gasLimit)
+				}
+				for _, payment := range chunk {
+					if payment.Amount.Int64() != next {
+						t.Fatalf("payment %d out of order", next)
+					}
+					next++
+				}
+			}
+			if next != int64(len(payments))+1 {
+				t.Fatalf("chunks hold %d payments, want %d", next-1, len(payments))
+			}
+		})
+	}
+}
+
+func TestMaxBatchSizeRejectsEmptyBatch(t *testing.T) {
+	c := newTestClient(t, newMockBackend(), Config{})
+	if _, err := c.MaxBatchSize(context.Background(), nil); err == nil {
+		t.Fatal("MaxBatchSize accepted an empty batch")
+	}
+}
@@ -0,0 +1,187 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// cancelFeeBumpPercent is the share of a pending transaction's tip and fee
+// cap its replacement must at least pay. Nodes only replace a pending
+// transaction when both are at least 10% higher.
+const cancelFeeBumpPercent = 110
+
+// cancelUnknownFeeMultiplier scales the fees of cancellations for pending
+// transactions whose fees are unknown, which were usually priced below the
+// current suggestion
+const cancelUnknownFeeMultiplier = 2
+
+// CancelAllPending replaces every transaction from the sending account that
+// is pending in the mempool with a zero-value self-transfer at the same
+// nonce. It returns the hashes of the cancellations sent, including those
+// sent before an error.
+//
+// Each cancellation is priced by Config.FeeStrategy, or WithFeeStrategy, but
+// pays at least 10% more tip and fee cap than the transaction it replaces.
+// Pending transactions are looked up in Config.Journal and with the node's
+// txpool_contentFrom; one neither knows is replaced at twice the strategy's
+// fees. Chains without a base fee get legacy transactions priced the same
+// way. WithFrom selects the account.
+func (c *Client) CancelAllPending(ctx context.Context, opts ...TxOption) ([]common.Hash, error) {
+	o := applyTxOptions(opts)
+	if err := requireMaxFeeChecked("CancelAllPending", o); err != nil {
+		return nil, err
+	}
+	acct, err := c.sender(o.from)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkChainID(ctx); err != nil {
+		return nil, err
+	}
+
+	// Hold the nonce manager so no nonce is reserved meanwhile, and resync it
+	// afterwards
+	acct.nonces.mu.Lock()
+	defer acct.nonces.resyncNonces()
+
+	confirmed, err := c.client.NonceAt(ctx, acct.address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	pending, err := c.client.PendingNonceAt(ctx, acct.address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending nonce: %w", err)
+	}
+	if pending <= confirmed {
+		return nil, nil
+	}
+
+	feeCap, tip, dynamic, err := c.suggestDynamicFees(ctx, o.feeStrategy)
+	if err != nil {
+		return nil, err
+	}
+	var gasPrice *big.Int
+	if !dynamic {
+		if gasPrice, err = c.client.SuggestGasPrice(ctx); err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %w", err)
+		}
+	}
+
+	stuck := c.pendingTransactions(ctx, acct.address, confirmed, pending)
+
+	var hashes []common.Hash
+	for nonce := confirmed; nonce < pending; nonce++ {
+		var unsigned types.TxData
+		if dynamic {
+			var oldTip, oldFeeCap *big.Int
+			if old := stuck[nonce]; old != nil {
+				oldTip, oldFeeCap = old.GasTipCap(), old.GasFeeCap()
+			}
+			tx := &types.DynamicFeeTx{
+				ChainID:   c.chainID,
+				Nonce:     nonce,
+				GasTipCap: replacementFee(tip, oldTip),
+				GasFeeCap: replacementFee(feeCap, oldFeeCap),
+				Gas:       params.TxGas,
+				To:        &acct.address,
+				Value:     new(big.Int),
+			}
+			if tx.GasFeeCap.Cmp(tx.GasTipCap) < 0 {
+				tx.GasFeeCap = tx.GasTipCap
+			}
+			unsigned = tx
+		} else {
+			var oldGasPrice *big.Int
+			if old := stuck[nonce]; old != nil {
+				oldGasPrice = old.GasPrice()
+			}
+			unsigned = &types.LegacyTx{
+				Nonce:    nonce,
+				To:       &acct.address,
+				Value:    new(big.Int),
+				Gas:      params.TxGas,
+				GasPrice: replacementFee(gasPrice, oldGasPrice),
+			}
+		}
+
+		tx, err := c.signTx(ctx, acct.signer, types.NewTx(unsigned))
+		if err != nil {
+			return hashes, fmt.Errorf("failed to sign cancellation for nonce %d: %w", nonce, err)
+		}
+
+		if err := c.client.SendTransaction(ctx, tx); err != nil {
+			return hashes, fmt.Errorf("failed to send cancellation for nonce %d: %w", nonce, err)
+		}
+		hashes = append(hashes, tx.Hash())
+	}
+
+	return hashes, nil
+}
+
+// replacementFee returns fee, raised to what a replacement of a pending
+// transaction paying old must pay. A nil old is unknown and doubles fee.
+func replacementFee(fee, old *big.Int) *big.Int {
+	if old == nil {
+		return new(big.Int).Mul(fee, big.NewInt(cancelUnknownFeeMultiplier))
+	}
+
+	// Round up, as nodes compare against the exact percentage
+	least := new(big.Int).Mul(old, big.NewInt(cancelFeeBumpPercent))
+	least.Add(least, big.NewInt(99)).Div(least, big.NewInt(100))
+	if least.Cmp(fee) > 0 {
+		return least
+	}
+	return new(big.Int).Set(fee)
+}
+
+// pendingTransactions returns the pending transactions of address with
+// nonces in [from, to) that Config.Journal or the node's txpool_contentFrom
+// know about. Lookup failures leave the transaction out.
+func (c *Client) pendingTransactions(ctx context.Context, address common.Address, from, to uint64) map[uint64]*types.Transaction {
+	txs := make(map[uint64]*types.Transaction)
+
+	if c.config.Journal != nil {
+		entries, _ := c.config.Journal.Entries()
+		for _, entry := range entries {
+			if entry.From != address || entry.Nonce < from || entry.Nonce >= to {
+				continue
+			}
+			if tx, isPending, err := c.client.TransactionByHash(ctx, entry.TxHash); err == nil && isPending {
+				txs[entry.Nonce] = tx
+			}
+		}
+	}
+	if uint64(len(txs)) == to-from {
+		return txs
+	}
+
+	// txpool_contentFrom is a geth extension many providers do not serve
+	rpcBackend, ok := c.client.(interface{ Client() *rpc.Client })
+	if !ok {
+		return txs
+	}
+	var content struct {
+		Pending map[string]*types.Transaction `json:"pending"`
+	}
+	if err := rpcBackend.Client().CallContext(ctx, &content, "txpool_contentFrom", address); err != nil {
+		return txs
+	}
+	for key, tx := range content.Pending {
+		nonce, err := strconv.ParseUint(key, 10, 64)
+		if err != nil || nonce < from || nonce >= to || txs[nonce] != nil {
+			continue
+		}
+		txs[nonce] = tx
+	}
+
+	return txs
+}
@@ -0,0 +1,88 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestCancelAllPendingClearsStuckNonces(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseFee *big.Int
+		txType  uint8
+		opts    []TxOption
+	}{
+		{"EIP-1559", big.NewInt(1e9), types.DynamicFeeTxType, []TxOption{WithFeeStrategy(FeeFast)}},
+		{"legacy", nil, types.LegacyTxType, []TxOption{WithGasPrice(big.NewInt(5e9))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.autoMine = false
+			backend.baseFee = tt.baseFee
+			backend.headers[0].BaseFee = tt.baseFee
+			c := newTestClient(t, backend, Config{Journal: NewMemoryJournal()})
+			ctx := context.Background()
+
+			// Three stuck transfers priced above the current suggestion, and
+			// one the journal does not know about
+			for i := 0; i < 3; i++ {
+				if _, err := c.Transfer(ctx, testAddress(1), big.NewInt(1), tt.opts...); err != nil {
+					t.Fatalf("Transfer: %v", err)
+				}
+			}
+			unknown, err := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    3,
+				To:       &testContracts.Token,
+				Gas:      params.TxGas,
+				GasPrice: big.NewInt(1e9),
+			}), types.LatestSignerForChainID(c.ChainID()), testKey(0))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := backend.SendTransaction(ctx, unknown); err != nil {
+				t.Fatal(err)
+			}
+			stuck := backend.sentTxs()
+
+			hashes, err := c.CancelAllPending(ctx)
+			if err != nil {
+				t.Fatalf("CancelAllPending: %v", err)
+			}
+			if len(hashes) != len(stuck) {
+				t.Fatalf("sent %d cancellations, want %d", len(hashes), len(stuck))
+			}
+
+			cancellations := backend.sentTxs()[len(stuck):]
+			for i, tx := range cancellations {
+				if tx.Hash() != hashes[i] || tx.Nonce() != uint64(i) {
+					t.Fatalf("cancellation %d has nonce %d", i, tx.Nonce())
+				}
+				if tx.Type() != tt.txType {
+					t.Errorf("cancellation %d has type %d, want %d", i, tx.Type(), tt.txType)
+				}
+				if *tx.To() != c.Address() || tx.Value().Sign() != 0 {
+					t.Errorf("cancellation %d is not a zero-value self-transfer", i)
+				}
+				if !bumped(tx.GasTipCap(), stuck[i].GasTipCap()) || !bumped(tx.GasFeeCap(), stuck[i].GasFeeCap()) {
+					t.Errorf("cancellation %d pays %s/%s, less than 10%% over %s/%s", i,
+						tx.GasTipCap(), tx.GasFeeCap(), stuck[i].GasTipCap(), stuck[i].GasFeeCap())
+				}
+			}
+
+			backend.mine()
+			if nonce, _ := backend.NonceAt(ctx, c.Address(), nil); nonce != uint64(len(stuck)) {
+				t.Errorf("nonce after mining = %d, want %d", nonce, len(stuck))
+			}
+			for i, tx := range stuck {
+				if _, err := backend.TransactionReceipt(ctx, tx.Hash()); err == nil {
+					t.Errorf("stuck transaction %d was mined instead of its cancellation", i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,669 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// SignedChannelState is an off-chain channel state signed by the client
+type SignedChannelState struct {
+	ChannelID [32]byte
+	Balance1  *big.Int
+	Balance2  *big.Int
+	Nonce     uint64
+	Signature []byte
+}
+
+// GetChannelByID returns channel information for a channel ID
+func (c *Client) GetChannelByID(ctx context.Context, channelID [32]byte) (*ChannelInfo, error) {
+	out, err := c.callContract(ctx, ContractPaymentChannel, "getChannel", channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	return channelInfoFromData(*abi.ConvertType(out[0], new(channelData)).(*channelData))
+}
+
+// channelInfoFromData converts a PaymentChannel.Channel struct
+func channelInfoFromData(data channelData) (*ChannelInfo, error) {
+	if !data.Nonce.IsUint64() {
+		return nil, fmt.Errorf("%w: channel nonce %s", ErrChannelNonceOverflow, data.Nonce)
+	}
+
+	return &ChannelInfo{
+		ChannelID:    data.ChannelId,
+		Participant1: data.PartyA,
+		Participant2: data.PartyB,
+		Deposit1:     data.DepositA,
+		Deposit2:     data.DepositB,
+		Balance1:     data.BalanceA,
+		Balance2:     data.BalanceB,
+		Nonce:        data.Nonce.Uint64(),
+		Status:       ChannelStatus(data.Status),
+		ChallengeEnd: data.ChallengeEnd.Uint64(),
+	}, nil
+}
+
+// GetOpenChannels returns the client's open channels with a counterparty
+func (c *Client) GetOpenChannels(ctx context.Context, counterparty common.Address) ([]*ChannelInfo, error) {
+	return c.channelsWith(ctx, c.address, counterparty, ChannelOpen)
+}
+
+// channelsWith returns the channels of owner with counterparty in status
+func (c *Client) channelsWith(ctx context.Context, owner, counterparty common.Address, status ChannelStatus) ([]*ChannelInfo, error) {
+	all, err := c.allChannelsWith(ctx, owner, counterparty)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []*ChannelInfo
+	for _, channel := range all {
+		if channel.Status == status {
+			channels = append(channels, channel)
+		}
+	}
+
+	return channels, nil
+}
+
+// allChannelsWith returns the channels of owner with counterparty in any
+// status, oldest first
+func (c *Client) allChannelsWith(ctx context.Context, owner, counterparty common.Address) ([]*ChannelInfo, error) {
+	out, err := c.callContract(ctx, ContractPaymentChannel, "getUserChannels", owner)
+	if err != nil {
+		return nil, err
+	}
+
+	var channels []*ChannelInfo
+	for _, channelID := range out[0].([][32]byte) {
+		channel, err := c.GetChannelByID(ctx, channelID)
+		if err != nil {
+			return nil, err
+		}
+
+		if channel.Participant1 == counterparty || channel.Participant2 == counterparty {
+			channels = append(channels, channel)
+		}
+	}
+
+	return channels, nil
+}
+
+// channelWith returns the first channel of owner with counterparty in
+// status, or ErrChannelNotOpen or ErrChannelNotClosing if there is none
+func (c *Client) channelWith(ctx context.Context, owner, counterparty common.Address, status ChannelStatus) (*ChannelInfo, error) {
+	channels, err := c.channelsWith(ctx, owner, counterparty, status)
+	if err != nil {
+		return nil, err
+	}
+	if len(channels) == 0 {
+		notFound := ErrChannelNotOpen
+		if status == ChannelClosing {
+			notFound = ErrChannelNotClosing
+		}
+		return nil, fmt.Errorf("%w: no channel with %s", notFound, counterparty.Hex())
+	}
+
+	return channels[0], nil
+}
+
+// OpenOrGetChannel returns the ID of an open channel with counterparty if one
+// exists, with created false, and otherwise opens one with OpenChannel
+func (c *Client) OpenOrGetChannel(ctx context.Context, counterparty common.Address, myDeposit, theirDeposit *big.Int, opts ...TxOption) ([32]byte, bool, error) {
+	channels, err := c.GetOpenChannels(ctx, counterparty)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+	if len(channels) > 0 {
+		return channels[0].ChannelID, false, nil
+	}
+
+	channelID, err := c.OpenChannel(ctx, counterparty, myDeposit, theirDeposit, opts...)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+
+	return channelID, true, nil
+}
+
+// OpenChannelAndPay opens a channel with OpenChannel and signs the first
+// off-chain state, moving firstPayment from the client's deposit to the
+// counterparty. The client opens the channel, so it is participant 1 and the
+// state has nonce 1. The state is signed by the account that opened the
+// channel, see WithFrom, and saved to Config.ChannelStates, if set.
+// firstPayment must not exceed myDeposit.
+func (c *Client) OpenChannelAndPay(ctx context.Context, counterparty common.Address, myDeposit, theirDeposit, firstPayment *big.Int, opts ...TxOption) (channelID [32]byte, firstState *SignedChannelState, err error) {
+	if myDeposit == nil {
+		myDeposit = new(big.Int)
+	}
+	if theirDeposit == nil {
+		theirDeposit = new(big.Int)
+	}
+
+	var v validator
+	v.check(requireAmount("firstPayment", firstPayment))
+	if firstPayment != nil && firstPayment.Cmp(myDeposit) > 0 {
+		v.check(fmt.Errorf("%w: firstPayment %s exceeds myDeposit %s", ErrInsufficientChannelCapacity, firstPayment, myDeposit))
+	}
+	if err := v.err(); err != nil {
+		return [32]byte{}, nil, err
+	}
+
+	acct, err := c.sender(applyTxOptions(opts).from)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+
+	channelID, err = c.OpenChannel(ctx, counterparty, myDeposit, theirDeposit, opts...)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+
+	nonce, err := NextChannelNonce(0)
+	if err != nil {
+		return channelID, nil, err
+	}
+
+	balance1 := new(big.Int).Sub(myDeposit, firstPayment)
+	balance2 := new(big.Int).Add(theirDeposit, firstPayment)
+	signature, err := c.signChannelState(acct.signer, channelID, balance1, balance2, nonce)
+	if err != nil {
+		return channelID, nil, err
+	}
+
+	firstState = &SignedChannelState{
+		ChannelID: channelID,
+		Balance1:  balance1,
+		Balance2:  balance2,
+		Nonce:     nonce,
+		Signature: signature,
+	}
+	if c.config.ChannelStates != nil {
+		if err := c.config.ChannelStates.Save(*firstState); err != nil {
+			return channelID, firstState, fmt.Errorf("failed to save channel state: %w", err)
+		}
+	}
+
+	return channelID, firstState, nil
+}
+
+// PreviewCooperativeClose maps proposed final balances of the client's open
+// channel with counterparty to each side. balance1 and balance2 are in the
+// channel's participant order, as passed to CooperativeClose. The balances
+// must add up to the channel's deposits, as the contract requires.
+func (c *Client) PreviewCooperativeClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int) (myPayout, theirPayout *big.Int, err error) {
+	channels, err := c.GetOpenChannels(ctx, counterparty)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(channels) == 0 {
+		return nil, nil, fmt.Errorf("%w: no open channel with %s", ErrChannelNotOpen, counterparty.Hex())
+	}
+	channel := channels[0]
+
+	if err := checkStateSum(channel, balance1, balance2); err != nil {
+		return nil, nil, err
+	}
+
+	if channel.Participant1 == c.address {
+		return balance1, balance2, nil
+	}
+	return balance2, balance1, nil
+}
+
+// ValidateStateAgainstChannel checks that an off-chain state's balances add up
+// to the channel's on-chain deposits, as the contract requires on close, and
+// returns ErrStateSumMismatch otherwise
+func (c *Client) ValidateStateAgainstChannel(ctx context.Context, channelID [32]byte, balance1, balance2 *big.Int) error {
+	channel, err := c.GetChannelByID(ctx, channelID)
+	if err != nil {
+		return err
+	}
+	if channel.Status == ChannelNone {
+		return fmt.Errorf("%w: %x", ErrChannelNotFound, channelID)
+	}
+
+	return checkStateSum(channel, balance1, balance2)
+}
+
+// checkStateSum returns ErrStateSumMismatch unless balance1 and balance2 add
+// up to the channel's deposits
+func checkStateSum(channel *ChannelInfo, balance1, balance2 *big.Int) error {
+	if balance1 == nil || balance2 == nil {
+		return fmt.Errorf("%w: missing balance", ErrStateSumMismatch)
+	}
+
+	total := new(big.Int).Add(balance1, balance2)
+	deposits := new(big.Int).Add(channel.Deposit1, channel.Deposit2)
+	if total.Cmp(deposits) != 0 {
+		return fmt.Errorf("%w: balances total %s, channel deposits are %s", ErrStateSumMismatch, total, deposits)
+	}
+
+	return nil
+}
+
+// RoutePayment splits a payment across the client's open channels with the
+// recipient, returning a signed state for each channel used. Channels with the
+// largest local balance are drained first so the payment touches as few
+// channels as possible.
+//
+// PaymentChannel only records balances on close, so each state builds on the
+// channel's latest state in Config.ChannelStates, see LatestChannelState:
+// its nonce is one higher and the payment is added to the balances already
+// paid. The signed states are saved there, so Config.ChannelStates is
+// required. The counterparty's states should be accepted into the same store
+// with AcceptChannelState.
+func (c *Client) RoutePayment(ctx context.Context, recipient common.Address, amount *big.Int) ([]SignedChannelState, error) {
+	if err := requireAmount("amount", amount); err != nil {
+		return nil, err
+	}
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+	if c.config.ChannelStates == nil {
+		return nil, ErrNoChannelStateStore
+	}
+
+	// Hold the lock from reading the latest states to saving the new ones,
+	// so concurrent payments do not sign the same nonce
+	c.channelMu.Lock()
+	defer c.channelMu.Unlock()
+
+	open, err := c.GetOpenChannels(ctx, recipient)
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]*ChannelInfo, len(open))
+	for i, channel := range open {
+		if channels[i], err = c.LatestChannelState(channel); err != nil {
+			return nil, err
+		}
+	}
+
+	// Sort by our side of the channel, largest first
+	sort.SliceStable(channels, func(i, j int) bool {
+		return c.localBalance(channels[i]).Cmp(c.localBalance(channels[j])) > 0
+	})
+
+	capacity := new(big.Int)
+	for _, channel := range channels {
+		capacity.Add(capacity, c.localBalance(channel))
+	}
+	if capacity.Cmp(amount) < 0 {
+		return nil, fmt.Errorf("%w: need %s, have %s", ErrInsufficientChannelCapacity, amount, capacity)
+	}
+
+	remaining := new(big.Int).Set(amount)
+	var states []SignedChannelState
+	for _, channel := range channels {
+		if remaining.Sign() == 0 {
+			break
+		}
+
+		part := new(big.Int).Set(c.localBalance(channel))
+		if part.Sign() == 0 {
+			continue
+		}
+		if part.Cmp(remaining) > 0 {
+			part.Set(remaining)
+		}
+		remaining.Sub(remaining, part)
+
+		balance1 := new(big.Int).Set(channel.Balance1)
+		balance2 := new(big.Int).Set(channel.Balance2)
+		if channel.Participant1 == c.address {
+			balance1.Sub(balance1, part)
+			balance2.Add(balance2, part)
+		} else {
+			balance2.Sub(balance2, part)
+			balance1.Add(balance1, part)
+		}
+
+		nonce, err := NextChannelNonce(channel.Nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		signature, err := c.SignChannelState(channel.ChannelID, balance1, balance2, nonce)
+		if err != nil {
+			return nil, err
+		}
+
+		states = append(states, SignedChannelState{
+			ChannelID: channel.ChannelID,
+			Balance1:  balance1,
+			Balance2:  balance2,
+			Nonce:     nonce,
+			Signature: signature,
+		})
+	}
+
+	for _, state := range states {
+		if err := c.config.ChannelStates.Save(state); err != nil {
+			return nil, fmt.Errorf("failed to save channel state: %w", err)
+		}
+	}
+
+	return states, nil
+}
+
+// LatestChannelState returns channel with the balances and nonce of its
+// latest state in Config.ChannelStates, if that is newer than the state
+// recorded on-chain. While a channel is open, PaymentChannel only holds its
+// deposits, so off-chain payments are only visible this way. Without a store
+// or a newer state, channel is returned as is.
+func (c *Client) LatestChannelState(channel *ChannelInfo) (*ChannelInfo, error) {
+	if c.config.ChannelStates == nil {
+		return channel, nil
+	}
+
+	latest, err := c.config.ChannelStates.Latest(channel.ChannelID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load channel state: %w", err)
+	}
+	if latest == nil || latest.Nonce <= channel.Nonce {
+		return channel, nil
+	}
+
+	updated := *channel
+	updated.Balance1, updated.Balance2, updated.Nonce = latest.Balance1, latest.Balance2, latest.Nonce
+	return &updated, nil
+}
+
+// GetChallengePeriod returns how long a unilateral close can be challenged
+// before FinalizeClose succeeds. The period is read once and cached.
+func (c *Client) GetChallengePeriod(ctx context.Context) (time.Duration, error) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.challengePeriod == 0 {
+		out, err := c.callContract(ctx, ContractPaymentChannel, "CHALLENGE_PERIOD")
+		if err != nil {
+			return 0, err
+		}
+		c.challengePeriod = time.Duration(out[0].(*big.Int).Int64()) * time.Second
+	}
+
+	return c.challengePeriod, nil
+}
+
+// NextChannelNonce returns the nonce following a channel state's nonce
+func NextChannelNonce(nonce uint64) (uint64, error) {
+	if nonce == math.MaxUint64 {
+		return 0, ErrChannelNonceOverflow
+	}
+	return nonce + 1, nil
+}
+
+// localBalance returns the client's side of a channel
+func (c *Client) localBalance(channel *ChannelInfo) *big.Int {
+	if channel.Participant1 == c.address {
+		return channel.Balance1
+	}
+	return channel.Balance2
+}
+
+// ChannelCapacity returns the total locked in a channel, the client's and the
+// counterparty's share of it, and the utilization: the fraction of the total
+// that has moved away from the client's initial deposit, in either direction.
+// Channels without deposit information are measured against an even split.
+// The shares are those of the channel's latest state in Config.ChannelStates,
+// see LatestChannelState, or the channel's own if the store cannot be read.
+func (c *Client) ChannelCapacity(channel *ChannelInfo) (total, mySide, theirSide *big.Int, utilization float64) {
+	if latest, err := c.LatestChannelState(channel); err == nil {
+		channel = latest
+	}
+
+	mySide, theirSide = channel.Balance2, channel.Balance1
+	myDeposit := channel.Deposit2
+	if channel.Participant1 == c.address {
+		mySide, theirSide = channel.Balance1, channel.Balance2
+		myDeposit = channel.Deposit1
+	}
+
+	total = new(big.Int).Add(mySide, theirSide)
+	if total.Sign() == 0 {
+		return total, mySide, theirSide, 0
+	}
+
+	if myDeposit == nil {
+		myDeposit = new(big.Int).Quo(total, big.NewInt(2))
+	}
+
+	shift := new(big.Int).Sub(mySide, myDeposit)
+	utilization, _ = new(big.Float).Quo(new(big.Float).SetInt(shift.Abs(shift)), new(big.Float).SetInt(total)).Float64()
+
+	return total, mySide, theirSide, utilization
+}
+
+// RebalanceKind is the kind of action RecommendRebalance suggests
+type RebalanceKind uint8
+
+const (
+	// RebalanceTopUp suggests depositing more on the client's side
+	RebalanceTopUp RebalanceKind = iota + 1
+	// RebalanceClose suggests closing the channel cooperatively and
+	// reopening it with fresh deposits, as the client's side is exhausted
+	RebalanceClose
+)
+
+// Thresholds of RecommendRebalance, as fractions of a channel's total
+const (
+	rebalanceTopUpThreshold     = 0.2
+	rebalanceExhaustedThreshold = 0.05
+)
+
+// RebalanceAction is a suggested action for one channel
+type RebalanceAction struct {
+	Channel *ChannelInfo
+	Kind    RebalanceKind
+	// LocalShare is the client's fraction of the channel's total
+	LocalShare float64
+}
+
+// RecommendRebalance suggests closing channels where myAddress holds less
+// than 5% of the total and topping up those where it holds less than 20%.
+// Channels that are not open are skipped. It returns an error if myAddress is
+// not a participant of one of the channels.
+//
+// The balances of an open channel read from the chain are its deposits. Pass
+// channels through Client.LatestChannelState first so that off-chain
+// payments count.
+func RecommendRebalance(channels []*ChannelInfo, myAddress common.Address) ([]RebalanceAction, error) {
+	var actions []RebalanceAction
+	for _, channel := range channels {
+		if channel == nil || channel.Status != ChannelOpen {
+			continue
+		}
+
+		var mine *big.Int
+		switch myAddress {
+		case channel.Participant1:
+			mine = channel.Balance1
+		case channel.Participant2:
+			mine = channel.Balance2
+		default:
+			return nil, fmt.Errorf("%s is not a participant of channel %x", myAddress.Hex(), channel.ChannelID)
+		}
+
+		total := new(big.Int).Add(channel.Balance1, channel.Balance2)
+		if total.Sign() == 0 {
+			continue
+		}
+		share, _ := new(big.Float).Quo(new(big.Float).SetInt(mine), new(big.Float).SetInt(total)).Float64()
+
+		switch {
+		case share < rebalanceExhaustedThreshold:
+			actions = append(actions, RebalanceAction{Channel: channel, Kind: RebalanceClose, LocalShare: share})
+		case share < rebalanceTopUpThreshold:
+			actions = append(actions, RebalanceAction{Channel: channel, Kind: RebalanceTopUp, LocalShare: share})
+		}
+	}
+
+	return actions, nil
+}
+
+// Approximate gas used by the PaymentChannel calls in a channel's lifetime,
+// for when it cannot be estimated. openChannel writes the 13-slot Channel
+// struct and two index entries and pulls one deposit; cooperativeClose
+// verifies two signatures and pays out.
+const (
+	defaultOpenChannelGas      = 400000
+	defaultCooperativeCloseGas = 100000
+)
+
+// ChannelBreakEven compares the cost of opening and cooperatively closing a
+// channel with counterparty against making expectedPayments direct payments
+// of paymentAmount. Off-chain channel payments cost nothing, so the channel is
+// worthwhile when its open and close gas is below the direct payments' gas
+// plus protocol fees.
+//
+// Config.GasLimits["openChannel"] and ["cooperativeClose"] override the
+// channel's gas. Otherwise openChannel is estimated for a deposit covering
+// the payments, falling back to 400000 if the estimate fails, e.g. because
+// the deposit is not yet approved. cooperativeClose cannot be estimated
+// before the channel exists and defaults to 100000.
+//
+// Unlike a bare payment count, counterparty and paymentAmount are required:
+// the pay gas is estimated against the actual recipient, the protocol fee is
+// a share of the amount, and the channel's deposit is their product.
+func (c *Client) ChannelBreakEven(ctx context.Context, counterparty common.Address, expectedPayments int, paymentAmount *big.Int) (channelCost, directCost *big.Int, worthwhile bool, err error) {
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get gas price: %w", err)
+	}
+
+	payGas, err := c.EstimatePayGas(ctx, counterparty, paymentAmount, nil)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	fee, err := c.EstimateFee(ctx, paymentAmount)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	deposit := new(big.Int).Mul(big.NewInt(int64(expectedPayments)), paymentAmount)
+	channelGas := c.openChannelGas(ctx, counterparty, deposit) + c.cooperativeCloseGas()
+	channelCost = new(big.Int).Mul(new(big.Int).SetUint64(channelGas), gasPrice)
+
+	perPayment := new(big.Int).Mul(new(big.Int).SetUint64(payGas), gasPrice)
+	perPayment.Add(perPayment, fee)
+	directCost = perPayment.Mul(perPayment, big.NewInt(int64(expectedPayments)))
+
+	return channelCost, directCost, channelCost.Cmp(directCost) < 0, nil
+}
+
+// openChannelGas returns the gas of opening a channel with counterparty and
+// deposit, as described at ChannelBreakEven
+func (c *Client) openChannelGas(ctx context.Context, counterparty common.Address, deposit *big.Int) uint64 {
+	if gas := c.config.GasLimits["openChannel"]; gas != 0 {
+		return gas
+	}
+
+	gas, err := c.estimateContractGas(ctx, ContractPaymentChannel, "openChannel", counterparty, deposit, new(big.Int))
+	if err != nil {
+		c.logDebug(ctx, "openChannel gas estimate failed, using default", slog.Any("error", err))
+		return defaultOpenChannelGas
+	}
+	return gas
+}
+
+// cooperativeCloseGas returns the gas of closing a channel cooperatively, as
+// described at ChannelBreakEven
+func (c *Client) cooperativeCloseGas() uint64 {
+	if gas := c.config.GasLimits["cooperativeClose"]; gas != 0 {
+		return gas
+	}
+	return defaultCooperativeCloseGas
+}
+
+// SuggestChannelDeposit sizes a channel deposit for expectedPayments
+// payments of avgPayment, plus a safety buffer as a fraction of the total
+// (0.2 for 20%), for use as myDeposit in OpenChannel. The result is rounded
+// up to the next token unit so the buffer never falls short. Negative inputs
+// and a non-finite buffer count as zero.
+func SuggestChannelDeposit(expectedPayments int, avgPayment *big.Int, buffer float64) *big.Int {
+	if expectedPayments <= 0 || avgPayment == nil || avgPayment.Sign() <= 0 {
+		return new(big.Int)
+	}
+	if buffer < 0 || math.IsNaN(buffer) || math.IsInf(buffer, 0) {
+		buffer = 0
+	}
+
+	total := new(big.Int).Mul(big.NewInt(int64(expectedPayments)), avgPayment)
+	if buffer == 0 {
+		return total
+	}
+
+	// Parse the buffer from its shortest decimal form so that e.g. 0.1 is
+	// exactly a tenth rather than its binary approximation
+	factor, _ := new(big.Rat).SetString(strconv.FormatFloat(buffer, 'g', -1, 64))
+	factor.Add(factor, big.NewRat(1, 1))
+	scaled := factor.Mul(factor, new(big.Rat).SetInt(total))
+
+	deposit, remainder := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if remainder.Sign() > 0 {
+		deposit.Add(deposit, big.NewInt(1))
+	}
+
+	return deposit
+}
+
+// GetChannels returns the client's open channel with each counterparty, in
+// input order, with nil where there is none. The channel reads are batched
+// through Multicall3 when Contracts.Multicall is configured.
+func (c *Client) GetChannels(ctx context.Context, counterparties []common.Address) ([]*ChannelInfo, error) {
+	out, err := c.callContract(ctx, ContractPaymentChannel, "getUserChannels", c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	channelIDs := out[0].([][32]byte)
+	args := make([][]interface{}, len(channelIDs))
+	for i, channelID := range channelIDs {
+		args[i] = []interface{}{channelID}
+	}
+
+	results, err := c.callContractBatch(ctx, ContractPaymentChannel, "getChannel", args)
+	if err != nil {
+		return nil, err
+	}
+
+	// The first open channel found with each counterparty wins, as in
+	// GetOpenChannels
+	open := make(map[common.Address]*ChannelInfo)
+	for _, result := range results {
+		channel, err := channelInfoFromData(*abi.ConvertType(result[0], new(channelData)).(*channelData))
+		if err != nil {
+			return nil, err
+		}
+		if channel.Status != ChannelOpen {
+			continue
+		}
+
+		counterparty := channel.Participant2
+		if channel.Participant1 != c.address {
+			counterparty = channel.Participant1
+		}
+		if _, ok := open[counterparty]; !ok {
+			open[counterparty] = channel
+		}
+	}
+
+	channels := make([]*ChannelInfo, len(counterparties))
+	for i, counterparty := range counterparties {
+		channels[i] = open[counterparty]
+	}
+
+	return channels, nil
+}
@@ -0,0 +1,678 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestOpenChannelAndPaySignsWithSender(t *testing.T) {
+	backend := newMockBackend()
+	agent := NewLocalSigner(testKey(2))
+	c := newTestClient(t, backend, Config{Accounts: []Signer{agent}})
+	counterparty := testAddress(1)
+	channelID := [32]byte{7}
+
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "openChannel", channelID)
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		return []*types.Log{eventLog(testContracts.PaymentChannel, paymentChannelABI, "ChannelOpened",
+			[]common.Hash{channelID, common.BytesToHash(agent.Address().Bytes()), common.BytesToHash(counterparty.Bytes())},
+			big.NewInt(1000), big.NewInt(0),
+		)}
+	}
+
+	_, state, err := c.OpenChannelAndPay(context.Background(), counterparty, big.NewInt(1000), nil, big.NewInt(100), WithFrom(agent.Address()))
+	if err != nil {
+		t.Fatalf("OpenChannelAndPay: %v", err)
+	}
+
+	digest := channelStateHash(c.ChainID(), testContracts.PaymentChannel, channelID, big.NewInt(900), big.NewInt(100), 1)
+	signer, err := recoverSigner(digest, state.Signature)
+	if err != nil {
+		t.Fatalf("recoverSigner: %v", err)
+	}
+	if signer != agent.Address() {
+		t.Errorf("first state signed by %s, want the opening account %s", signer.Hex(), agent.Address().Hex())
+	}
+}
+
+func TestChannelCapacity(t *testing.T) {
+	c := newTestClient(t, newMockBackend(), Config{})
+	me, them := c.Address(), testAddress(1)
+
+	tests := []struct {
+		name            string
+		channel         ChannelInfo
+		want            [3]int64 // total, mine, theirs
+		wantUtilization float64
+	}{
+		{
+			name:            "untouched channel",
+			channel:         ChannelInfo{Participant1: me, Participant2: them, Deposit1: big.NewInt(100), Deposit2: big.NewInt(100), Balance1: big.NewInt(100), Balance2: big.NewInt(100)},
+			want:            [3]int64{200, 100, 100},
+			wantUtilization: 0,
+		},
+		{
+			name:            "client is participant 2",
+			channel:         ChannelInfo{Participant1: them, Participant2: me, Deposit1: big.NewInt(100), Deposit2: big.NewInt(100), Balance1: big.NewInt(150), Balance2: big.NewInt(50)},
+			want:            [3]int64{200, 50, 150},
+			wantUtilization: 0.25,
+		},
+		{
+			name:            "received payments count as utilization",
+			channel:         ChannelInfo{Participant1: me, Participant2: them, Deposit1: big.NewInt(0), Deposit2: big.NewInt(200), Balance1: big.NewInt(50), Balance2: big.NewInt(150)},
+			want:            [3]int64{200, 50, 150},
+			wantUtilization: 0.25,
+		},
+		{
+			name:            "no deposits measured against an even split",
+			channel:         ChannelInfo{Participant1: me, Participant2: them, Balance1: big.NewInt(0), Balance2: big.NewInt(200)},
+			want:            [3]int64{200, 0, 200},
+			wantUtilization: 0.5,
+		},
+		{
+			name:            "empty channel",
+			channel:         ChannelInfo{Participant1: me, Participant2: them, Balance1: big.NewInt(0), Balance2: big.NewInt(0)},
+			want:            [3]int64{0, 0, 0},
+			wantUtilization: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			total, mine, theirs, utilization := c.ChannelCapacity(&tt.channel)
+			if got := [3]int64{total.Int64(), mine.Int64(), theirs.Int64()}; got != tt.want {
+				t.Errorf("ChannelCapacity = %v, want %v", got, tt.want)
+			}
+			if utilization != tt.wantUtilization {
+				t.Errorf("utilization = %v, want %v", utilization, tt.wantUtilization)
+			}
+		})
+	}
+}
+
+func TestRecommendRebalance(t *testing.T) {
+	me, them := testAddress(0), testAddress(1)
+	channel := func(mine, theirs int64, status ChannelStatus) *ChannelInfo {
+		return &ChannelInfo{Participant1: them, Participant2: me, Balance1: big.NewInt(theirs), Balance2: big.NewInt(mine), Status: status}
+	}
+
+	tests := []struct {
+		name    string
+		channel *ChannelInfo
+		want    RebalanceKind // zero for no action
+	}{
+		{name: "balanced", channel: channel(50, 50, ChannelOpen)},
+		{name: "exactly at top-up threshold", channel: channel(20, 80, ChannelOpen)},
+		{name: "below top-up threshold", channel: channel(19, 81, ChannelOpen), want: RebalanceTopUp},
+		{name: "exactly at exhaustion threshold", channel: channel(5, 95, ChannelOpen), want: RebalanceTopUp},
+		{name: "exhausted", channel: channel(4, 96, ChannelOpen), want: RebalanceClose},
+		{name: "empty channel", channel: channel(0, 0, ChannelOpen)},
+		{name: "closing channel skipped", channel: channel(0, 100, ChannelClosing)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, err := RecommendRebalance([]*ChannelInfo{tt.channel}, me)
+			if err != nil {
+				t.Fatalf("RecommendRebalance: %v", err)
+			}
+			var got RebalanceKind
+			if len(actions) > 0 {
+				got = actions[0].Kind
+			}
+			if got != tt.want || len(actions) > 1 {
+				t.Errorf("actions = %+v, want kind %d", actions, tt.want)
+			}
+		})
+	}
+
+	if _, err := RecommendRebalance([]*ChannelInfo{channel(50, 50, ChannelOpen)}, testAddress(2)); err == nil {
+		t.Error("RecommendRebalance accepted a channel the address is not part of")
+	}
+}
+
+func TestSuggestChannelDeposit(t *testing.T) {
+	tests := []struct {
+		name     string
+		payments int
+		avg      *big.Int
+		buffer   float64
+		want     string
+	}{
+		{name: "no buffer", payments: 10, avg: big.NewInt(100), buffer: 0, want: "1000"},
+		{name: "exact buffer", payments: 10, avg: big.NewInt(100), buffer: 0.2, want: "1200"},
+		{name: "tenth is exact", payments: 1, avg: big.NewInt(10), buffer: 0.1, want: "11"},
+		{name: "fraction rounds up", payments: 3, avg: big.NewInt(1), buffer: 0.1, want: "4"},
+		{name: "smallest fraction rounds up", payments: 1, avg: big.NewInt(1), buffer: 0.0001, want: "2"},
+		{name: "large amounts stay exact", payments: 1000, avg: new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil), buffer: 0.15, want: "1150000000000000000000"},
+		{name: "negative buffer counts as zero", payments: 2, avg: big.NewInt(5), buffer: -1, want: "10"},
+		{name: "NaN buffer counts as zero", payments: 2, avg: big.NewInt(5), buffer: math.NaN(), want: "10"},
+		{name: "no payments", payments: 0, avg: big.NewInt(5), buffer: 0.2, want: "0"},
+		{name: "nil average", payments: 2, avg: nil, buffer: 0.2, want: "0"},
+		{name: "negative average", payments: 2, avg: big.NewInt(-5), buffer: 0.2, want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SuggestChannelDeposit(tt.payments, tt.avg, tt.buffer).String(); got != tt.want {
+				t.Errorf("SuggestChannelDeposit = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCooperativeCloseUsesChannelWithCounterparty(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	counterparty := testAddress(1)
+	channelID := [32]byte{9}
+
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "getUserChannels", [][32]byte{channelID})
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "getChannel", channelData{
+		ChannelId:    channelID,
+		PartyA:       c.Address(),
+		PartyB:       counterparty,
+		DepositA:     big.NewInt(1000),
+		DepositB:     big.NewInt(0),
+		BalanceA:     big.NewInt(1000),
+		BalanceB:     big.NewInt(0),
+		Nonce:        new(big.Int),
+		OpenTime:     new(big.Int),
+		CloseTime:    new(big.Int),
+		ChallengeEnd: new(big.Int),
+		Status:       uint8(ChannelOpen),
+	})
+
+	sig1, _ := c.SignCooperativeClose(channelID, big.NewInt(600), big.NewInt(400), 3)
+	sig2 := make([]byte, 65)
+
+	if _, err := c.CooperativeClose(context.Background(), counterparty, big.NewInt(600), big.NewInt(500), 3, sig1, sig2); !errors.Is(err, ErrStateSumMismatch) {
+		t.Errorf("mismatched balances: err = %v, want ErrStateSumMismatch", err)
+	}
+
+	if _, err := c.CooperativeClose(context.Background(), counterparty, big.NewInt(600), big.NewInt(400), 3, sig1, sig2); err != nil {
+		t.Fatalf("CooperativeClose: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+	want, _ := paymentChannelABI.Pack("cooperativeClose", channelID, big.NewInt(600), big.NewInt(400), big.NewInt(3), sig1, sig2)
+	if !bytes.Equal(sent[0].Data(), want) {
+		t.Errorf("calldata = %x, want %x", sent[0].Data(), want)
+	}
+
+	if _, err := c.FinalizeClose(context.Background(), counterparty); !errors.Is(err, ErrChannelNotClosing) {
+		t.Errorf("FinalizeClose on an open channel: err = %v, want ErrChannelNotClosing", err)
+	}
+}
+
+func TestChannelBreakEvenGas(t *testing.T) {
+	openChannel := paymentChannelABI.Methods["openChannel"].ID
+
+	tests := []struct {
+		name        string
+		gasLimits   map[string]uint64
+		estimateErr error
+		wantGas     int64
+	}{
+		{"estimated", nil, nil, 250000 + defaultCooperativeCloseGas},
+		{"estimate fails", nil, errors.New("execution reverted"), defaultOpenChannelGas + defaultCooperativeCloseGas},
+		{"configured", map[string]uint64{"openChannel": 300000, "cooperativeClose": 80000}, nil, 380000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{GasLimits: tt.gasLimits})
+			backend.returns(testContracts.PaymentRouter, paymentRouterABI, "baseFee", big.NewInt(10))
+			backend.estimateGas = func(msg ethereum.CallMsg) (uint64, error) {
+				if bytes.HasPrefix(msg.Data, openChannel) {
+					return 250000, tt.estimateErr
+				}
+				return 60000, nil
+			}
+
+			channelCost, _, _, err := c.ChannelBreakEven(context.Background(), testAddress(1), 10, big.NewInt(1e18))
+			if err != nil {
+				t.Fatalf("ChannelBreakEven: %v", err)
+			}
+			want := new(big.Int).Mul(big.NewInt(tt.wantGas), backend.gasPrice)
+			if channelCost.Cmp(want) != 0 {
+				t.Errorf("channelCost = %s, want %s", channelCost, want)
+			}
+		})
+	}
+}
+
+func TestChannelBreakEven(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	counterparty, amount := testAddress(1), big.NewInt(1000)
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "baseFee", big.NewInt(10))
+	openChannel := paymentChannelABI.Methods["openChannel"].ID
+	pay := paymentRouterABI.Methods["pay"].ID
+	backend.estimateGas = func(msg ethereum.CallMsg) (uint64, error) {
+		switch {
+		case bytes.HasPrefix(msg.Data, openChannel):
+			return 250000, nil
+		case bytes.HasPrefix(msg.Data, pay) && bytes.Contains(msg.Data, counterparty.Bytes()):
+			return 60000, nil
+		}
+		return 0, errors.New("unexpected estimate")
+	}
+	fee, err := c.EstimateFee(context.Background(), amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The channel costs 350000 gas against 60000 gas plus the fee per
+	// payment, so it pays off from the sixth payment
+	tests := []struct {
+		payments       int
+		wantWorthwhile bool
+	}{
+		{1, false},
+		{5, false},
+		{6, true},
+		{100, true},
+	}
+	for _, tt := range tests {
+		channelCost, directCost, worthwhile, err := c.ChannelBreakEven(context.Background(), counterparty, tt.payments, amount)
+		if err != nil {
+			t.Fatalf("ChannelBreakEven(%d): %v", tt.payments, err)
+		}
+		wantChannel := new(big.Int).Mul(big.NewInt(250000+defaultCooperativeCloseGas), backend.gasPrice)
+		wantDirect := new(big.Int).Mul(big.NewInt(60000), backend.gasPrice)
+		wantDirect.Add(wantDirect, fee).Mul(wantDirect, big.NewInt(int64(tt.payments)))
+		if channelCost.Cmp(wantChannel) != 0 || directCost.Cmp(wantDirect) != 0 {
+			t.Errorf("ChannelBreakEven(%d) costs = %s, %s, want %s, %s", tt.payments, channelCost, directCost, wantChannel, wantDirect)
+		}
+		if worthwhile != tt.wantWorthwhile {
+			t.Errorf("ChannelBreakEven(%d) worthwhile = %v, want %v", tt.payments, worthwhile, tt.wantWorthwhile)
+		}
+	}
+}
+
+// openChannel is a channel registered by withOpenChannels, with the client's
+// deposit on its side. A zero status means ChannelOpen.
+type openChannel struct {
+	id        [32]byte
+	deposit   int64
+	clientIsB bool
+	status    ChannelStatus
+}
+
+// withOpenChannels makes the PaymentChannel mock report channels between
+// client and counterparty, with only the client's side funded
+func withOpenChannels(backend *mockBackend, client, counterparty common.Address, channels ...openChannel) {
+	byID := make(map[[32]byte]channelData)
+	var ids [][32]byte
+	for _, channel := range channels {
+		data := channelData{
+			ChannelId:    channel.id,
+			PartyA:       client,
+			PartyB:       counterparty,
+			DepositA:     big.NewInt(channel.deposit),
+			DepositB:     new(big.Int),
+			Nonce:        new(big.Int),
+			OpenTime:     new(big.Int),
+			CloseTime:    new(big.Int),
+			ChallengeEnd: new(big.Int),
+			Status:       uint8(ChannelOpen),
+		}
+		if channel.status != ChannelNone {
+			data.Status = uint8(channel.status)
+		}
+		if channel.clientIsB {
+			data.PartyA, data.PartyB = counterparty, client
+			data.DepositA, data.DepositB = data.DepositB, data.DepositA
+		}
+		data.BalanceA, data.BalanceB = data.DepositA, data.DepositB
+		byID[channel.id] = data
+		ids = append(ids, channel.id)
+	}
+
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "getUserChannels", ids)
+	backend.handle(testContracts.PaymentChannel, paymentChannelABI, "getChannel", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		return []interface{}{byID[args[0].([32]byte)]}, nil
+	})
+}
+
+func TestRoutePayment(t *testing.T) {
+	tests := []struct {
+		name     string
+		channels []openChannel
+		amount   int64
+		// want holds the client's and counterparty's balance after each
+		// state, in the order the states are returned
+		want    [][2]int64
+		wantErr error
+	}{
+		{
+			name:     "single channel",
+			channels: []openChannel{{id: [32]byte{1}, deposit: 1000}},
+			amount:   300,
+			want:     [][2]int64{{700, 300}},
+		},
+		{
+			name:     "split across channels, largest first",
+			channels: []openChannel{{id: [32]byte{1}, deposit: 300}, {id: [32]byte{2}, deposit: 500, clientIsB: true}},
+			amount:   700,
+			want:     [][2]int64{{0, 500}, {100, 200}},
+		},
+		{
+			name:     "insufficient capacity",
+			channels: []openChannel{{id: [32]byte{1}, deposit: 300}, {id: [32]byte{2}, deposit: 500}},
+			amount:   900,
+			wantErr:  ErrInsufficientChannelCapacity,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			store := NewMemoryChannelStateStore()
+			c := newTestClient(t, backend, Config{ChannelStates: store})
+			counterparty := testAddress(1)
+			withOpenChannels(backend, c.Address(), counterparty, tt.channels...)
+
+			states, err := c.RoutePayment(context.Background(), counterparty, big.NewInt(tt.amount))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RoutePayment error = %v, want %v", err, tt.wantErr)
+			}
+			if len(states) != len(tt.want) {
+				t.Fatalf("RoutePayment returned %d states, want %d", len(states), len(tt.want))
+			}
+
+			// The counterparty accepts every state into its own store
+			receiver := newTestClient(t, backend, Config{Signer: NewLocalSigner(testKey(1))})
+			for i, state := range states {
+				mine, theirs := state.Balance1, state.Balance2
+				if state.ChannelID == [32]byte{2} && tt.channels[1].clientIsB {
+					mine, theirs = theirs, mine
+				}
+				if got := [2]int64{mine.Int64(), theirs.Int64()}; got != tt.want[i] || state.Nonce != 1 {
+					t.Errorf("state %d = %v at nonce %d, want %v at nonce 1", i, got, state.Nonce, tt.want[i])
+				}
+				if err := receiver.AcceptChannelState(NewMemoryChannelStateStore(), c.Address(), state); err != nil {
+					t.Errorf("counterparty rejected state %d: %v", i, err)
+				}
+				if saved, _ := store.Latest(state.ChannelID); saved == nil || saved.Nonce != state.Nonce {
+					t.Errorf("state %d was not saved", i)
+				}
+			}
+		})
+	}
+}
+
+func TestRoutePaymentBuildsOnLatestState(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{ChannelStates: NewMemoryChannelStateStore()})
+	receiver := newTestClient(t, backend, Config{Signer: NewLocalSigner(testKey(1))})
+	channelID := [32]byte{1}
+	withOpenChannels(backend, c.Address(), receiver.Address(), openChannel{id: channelID, deposit: 1000})
+	ctx := context.Background()
+
+	// The counterparty only accepts states with increasing nonces, and the
+	// balances keep moving even though the chain still reports the deposits
+	received := NewMemoryChannelStateStore()
+	mine := int64(1000)
+	for i, amount := range []int64{300, 300, 250} {
+		states, err := c.RoutePayment(ctx, receiver.Address(), big.NewInt(amount))
+		if err != nil {
+			t.Fatalf("payment %d: %v", i, err)
+		}
+		mine -= amount
+		if states[0].Nonce != uint64(i+1) || states[0].Balance1.Int64() != mine || states[0].Balance2.Int64() != 1000-mine {
+			t.Errorf("payment %d signed %s/%s at nonce %d, want %d/%d at nonce %d", i,
+				states[0].Balance1, states[0].Balance2, states[0].Nonce, mine, 1000-mine, i+1)
+		}
+		if err := receiver.AcceptChannelState(received, c.Address(), states[0]); err != nil {
+			t.Fatalf("counterparty rejected payment %d: %v", i, err)
+		}
+	}
+	if _, err := c.RoutePayment(ctx, receiver.Address(), big.NewInt(200)); !errors.Is(err, ErrInsufficientChannelCapacity) {
+		t.Errorf("paying past the remaining 150: err = %v, want ErrInsufficientChannelCapacity", err)
+	}
+
+	// Capacity and rebalancing see the off-chain balances too
+	channel, err := c.GetChannelByID(ctx, channelID)
+	if err != nil {
+		t.Fatalf("GetChannelByID: %v", err)
+	}
+	if _, mySide, _, _ := c.ChannelCapacity(channel); mySide.Int64() != 150 {
+		t.Errorf("ChannelCapacity mySide = %s, want 150", mySide)
+	}
+	latest, err := c.LatestChannelState(channel)
+	if err != nil {
+		t.Fatalf("LatestChannelState: %v", err)
+	}
+	if actions, _ := RecommendRebalance([]*ChannelInfo{latest}, c.Address()); len(actions) != 1 || actions[0].Kind != RebalanceTopUp {
+		t.Errorf("RecommendRebalance = %+v, want a top-up at 15%%", actions)
+	}
+
+	noStore := newTestClient(t, backend, Config{})
+	if _, err := noStore.RoutePayment(ctx, receiver.Address(), big.NewInt(1)); !errors.Is(err, ErrNoChannelStateStore) {
+		t.Errorf("RoutePayment without a store: err = %v, want ErrNoChannelStateStore", err)
+	}
+}
+
+func TestGetChallengePeriod(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	calls := 0
+	backend.handle(testContracts.PaymentChannel, paymentChannelABI, "CHALLENGE_PERIOD", func(common.Address, []interface{}) ([]interface{}, error) {
+		calls++
+		return []interface{}{big.NewInt(86400)}, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		period, err := c.GetChallengePeriod(context.Background())
+		if err != nil {
+			t.Fatalf("GetChallengePeriod: %v", err)
+		}
+		if period != 24*time.Hour {
+			t.Errorf("period = %s, want 24h", period)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("CHALLENGE_PERIOD read %d times, want once", calls)
+	}
+}
+
+func TestOpenOrGetChannel(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	counterparty := testAddress(1)
+	channelID := [32]byte{7}
+
+	withOpenChannels(backend, c.Address(), counterparty)
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "openChannel", channelID)
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		return []*types.Log{eventLog(testContracts.PaymentChannel, paymentChannelABI, "ChannelOpened",
+			[]common.Hash{channelID, common.BytesToHash(c.Address().Bytes()), common.BytesToHash(counterparty.Bytes())},
+			big.NewInt(1000), big.NewInt(0),
+		)}
+	}
+
+	id, created, err := c.OpenOrGetChannel(context.Background(), counterparty, big.NewInt(1000), nil)
+	if err != nil {
+		t.Fatalf("OpenOrGetChannel: %v", err)
+	}
+	if id != channelID || !created {
+		t.Fatalf("first call = %x created %v, want %x created", id, created, channelID)
+	}
+	sent := len(backend.sentTxs())
+
+	// The channel is now open, so a retry returns it without a transaction
+	withOpenChannels(backend, c.Address(), counterparty, openChannel{id: channelID, deposit: 1000})
+	id, created, err = c.OpenOrGetChannel(context.Background(), counterparty, big.NewInt(1000), nil)
+	if err != nil {
+		t.Fatalf("OpenOrGetChannel: %v", err)
+	}
+	if id != channelID || created {
+		t.Errorf("second call = %x created %v, want the existing %x", id, created, channelID)
+	}
+	if n := len(backend.sentTxs()); n != sent {
+		t.Errorf("second call sent %d transactions", n-sent)
+	}
+}
+
+func TestPreviewCooperativeClose(t *testing.T) {
+	tests := []struct {
+		name                 string
+		channel              *openChannel
+		balance1, balance2   int64
+		wantMine, wantTheirs int64
+		wantErr              error
+	}{
+		{"client is participant 1", &openChannel{id: [32]byte{1}, deposit: 1000}, 700, 300, 700, 300, nil},
+		{"client is participant 2", &openChannel{id: [32]byte{1}, deposit: 1000, clientIsB: true}, 300, 700, 700, 300, nil},
+		{"balances exceed deposits", &openChannel{id: [32]byte{1}, deposit: 1000}, 700, 400, 0, 0, ErrStateSumMismatch},
+		{"no open channel", nil, 700, 300, 0, 0, ErrChannelNotOpen},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			counterparty := testAddress(1)
+			if tt.channel != nil {
+				withOpenChannels(backend, c.Address(), counterparty, *tt.channel)
+			} else {
+				withOpenChannels(backend, c.Address(), counterparty)
+			}
+
+			mine, theirs, err := c.PreviewCooperativeClose(context.Background(), counterparty, big.NewInt(tt.balance1), big.NewInt(tt.balance2))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PreviewCooperativeClose error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if mine.Int64() != tt.wantMine || theirs.Int64() != tt.wantTheirs {
+				t.Errorf("payouts = %s mine, %s theirs, want %d and %d", mine, theirs, tt.wantMine, tt.wantTheirs)
+			}
+		})
+	}
+}
+
+// callCountingBackend counts the calls made to each contract through the
+// client, not those a mock contract makes itself
+type callCountingBackend struct {
+	*mockBackend
+
+	mu    sync.Mutex
+	calls map[common.Address]int
+}
+
+func (b *callCountingBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	b.calls[*msg.To]++
+	b.mu.Unlock()
+	return b.mockBackend.CallContract(ctx, msg, block)
+}
+
+// withMulticall deploys a Multicall3 mock at address that runs each call
+// against backend, and returns the number of aggregate3 calls made
+func withMulticall(backend *mockBackend, address common.Address) *int {
+	aggregates := new(int)
+	backend.handle(address, multicallABI, "aggregate3", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		*aggregates++
+		calls := *abi.ConvertType(args[0], new([]multicallCall)).(*[]multicallCall)
+		results := make([]multicallResult, len(calls))
+		for i, call := range calls {
+			data, err := backend.CallContract(context.Background(), ethereum.CallMsg{To: &call.Target, Data: call.CallData}, nil)
+			results[i] = multicallResult{Success: err == nil, ReturnData: data}
+		}
+		return []interface{}{results}, nil
+	})
+	return aggregates
+}
+
+func TestGetChannels(t *testing.T) {
+	backend := &callCountingBackend{mockBackend: newMockBackend(), calls: make(map[common.Address]int)}
+	contracts := testContracts
+	contracts.Multicall = testAddress(9)
+	c, err := NewClientWithBackend(backend, Config{Signer: NewLocalSigner(testKey(0)), Contracts: contracts})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+
+	channel := func(id byte, partyA, partyB common.Address, status ChannelStatus) channelData {
+		return channelData{
+			ChannelId: [32]byte{id}, PartyA: partyA, PartyB: partyB,
+			DepositA: big.NewInt(100), DepositB: big.NewInt(100), BalanceA: big.NewInt(100), BalanceB: big.NewInt(100),
+			Nonce: new(big.Int), OpenTime: new(big.Int), CloseTime: new(big.Int), ChallengeEnd: new(big.Int),
+			Status: uint8(status),
+		}
+	}
+	me := c.Address()
+	channels := map[[32]byte]channelData{
+		{1}: channel(1, me, testAddress(1), ChannelOpen),
+		{2}: channel(2, testAddress(2), me, ChannelOpen),
+		{3}: channel(3, me, testAddress(3), ChannelClosed),
+	}
+	backend.returns(testContracts.PaymentChannel, paymentChannelABI, "getUserChannels", [][32]byte{{1}, {2}, {3}})
+	backend.handle(testContracts.PaymentChannel, paymentChannelABI, "getChannel", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		return []interface{}{channels[args[0].([32]byte)]}, nil
+	})
+	aggregates := withMulticall(backend.mockBackend, contracts.Multicall)
+
+	got, err := c.GetChannels(context.Background(), []common.Address{testAddress(3), testAddress(2), testAddress(1), testAddress(4)})
+	if err != nil {
+		t.Fatalf("GetChannels: %v", err)
+	}
+	want := [][32]byte{{}, {2}, {1}, {}}
+	if len(got) != len(want) {
+		t.Fatalf("GetChannels returned %d channels, want %d", len(got), len(want))
+	}
+	for i, id := range want {
+		switch {
+		case id == [32]byte{} && got[i] != nil:
+			t.Errorf("channel %d = %x, want none", i, got[i].ChannelID)
+		case id != [32]byte{} && (got[i] == nil || got[i].ChannelID != id):
+			t.Errorf("channel %d = %v, want %x", i, got[i], id)
+		}
+	}
+
+	// One getUserChannels call, then every getChannel in one aggregate3
+	if *aggregates != 1 || backend.calls[testContracts.PaymentChannel] != 1 {
+		t.Errorf("made %d aggregate3 and %d direct PaymentChannel calls, want 1 and 1", *aggregates, backend.calls[testContracts.PaymentChannel])
+	}
+}
+
+func TestValidateStateAgainstChannel(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	channelID := [32]byte{1}
+	withOpenChannels(backend, c.Address(), testAddress(1), openChannel{id: channelID, deposit: 1000})
+
+	tests := []struct {
+		name               string
+		balance1, balance2 *big.Int
+		wantErr            error
+	}{
+		{"balances match deposits", big.NewInt(600), big.NewInt(400), nil},
+		{"balances exceed deposits", big.NewInt(600), big.NewInt(500), ErrStateSumMismatch},
+		{"balances short of deposits", big.NewInt(600), big.NewInt(300), ErrStateSumMismatch},
+		{"missing balance", big.NewInt(1000), nil, ErrStateSumMismatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.ValidateStateAgainstChannel(context.Background(), channelID, tt.balance1, tt.balance2)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidateStateAgainstChannel error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
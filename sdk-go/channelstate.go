@@ -0,0 +1,150 @@
+package synapse
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ChannelStateStore persists the latest accepted state of each channel
+type ChannelStateStore interface {
+	// Latest returns the last accepted state of a channel, or nil if none
+	// has been accepted
+	Latest(channelID [32]byte) (*SignedChannelState, error)
+	Save(state SignedChannelState) error
+}
+
+// MemoryChannelStateStore is an in-memory ChannelStateStore
+type MemoryChannelStateStore struct {
+	mu     sync.Mutex
+	states map[[32]byte]SignedChannelState
+}
+
+// NewMemoryChannelStateStore creates an empty in-memory channel state store
+func NewMemoryChannelStateStore() *MemoryChannelStateStore {
+	return &MemoryChannelStateStore{states: make(map[[32]byte]SignedChannelState)}
+}
+
+// Latest returns the last saved state of a channel
+func (s *MemoryChannelStateStore) Latest(channelID [32]byte) (*SignedChannelState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[channelID]
+	if !ok {
+		return nil, nil
+	}
+	return &state, nil
+}
+
+// Save stores a channel's state, replacing any earlier one
+func (s *MemoryChannelStateStore) Save(state SignedChannelState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[state.ChannelID] = state
+	return nil
+}
+
+// States returns the saved state of every channel
+func (s *MemoryChannelStateStore) States() ([]SignedChannelState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states := make([]SignedChannelState, 0, len(s.states))
+	for _, state := range s.states {
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// channelStateHash returns the digest signed for a channel state: its
+// channelStateMessage with the EIP-191 prefix _verifySignature adds
+func channelStateHash(chainID *big.Int, channelContract common.Address, channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return accounts.TextHash(channelStateMessage(chainID, channelContract, channelID, balance1, balance2, nonce))
+}
+
+// channelStateMessage returns the personal message signed for a channel
+// state. It is the PaymentChannel contract's _hashState, which binds the
+// state to the chain and the channel contract.
+func channelStateMessage(chainID *big.Int, channelContract common.Address, channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return crypto.Keccak256(
+		channelID[:],
+		common.LeftPadBytes(balance1.Bytes(), 32),
+		common.LeftPadBytes(balance2.Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(nonce).Bytes(), 32),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		channelContract.Bytes(),
+	)
+}
+
+// channelStateHash returns the digest of a channel state on the client's
+// chain and PaymentChannel contract
+func (c *Client) channelStateHash(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return channelStateHash(c.chainID, c.config.Contracts.PaymentChannel, channelID, balance1, balance2, nonce)
+}
+
+// channelStateMessage returns the personal message of a channel state on the
+// client's chain and PaymentChannel contract
+func (c *Client) channelStateMessage(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return channelStateMessage(c.chainID, c.config.Contracts.PaymentChannel, channelID, balance1, balance2, nonce)
+}
+
+// cooperativeCloseHash returns the digest signed to close a channel
+// cooperatively: its cooperativeCloseMessage with the EIP-191 prefix
+func cooperativeCloseHash(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return accounts.TextHash(cooperativeCloseMessage(channelID, balance1, balance2, nonce))
+}
+
+// cooperativeCloseMessage returns the personal message signed to close a
+// channel cooperatively, the PaymentChannel contract's
+// createCooperativeCloseHash. Unlike channel states, it is not bound to the
+// chain or contract.
+func cooperativeCloseMessage(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) []byte {
+	return crypto.Keccak256(
+		channelID[:],
+		common.LeftPadBytes(balance1.Bytes(), 32),
+		common.LeftPadBytes(balance2.Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(nonce).Bytes(), 32),
+		[]byte("COOPERATIVE_CLOSE"),
+	)
+}
+
+// AcceptChannelState checks a state update received from counterparty and
+// saves it to store. The state must be signed by counterparty for the
+// client's chain and PaymentChannel contract, and its nonce must be above the
+// last accepted state of the channel, otherwise ErrInvalidChannelSignature or
+// ErrStaleChannelState is returned, so an older state, or one signed for
+// another chain or contract, cannot be replayed. Calls for the same channel must not run
+// concurrently.
+func (c *Client) AcceptChannelState(store ChannelStateStore, counterparty common.Address, state SignedChannelState) error {
+	if state.Balance1 == nil || state.Balance2 == nil || state.Balance1.Sign() < 0 || state.Balance2.Sign() < 0 {
+		return fmt.Errorf("invalid channel state balances %v and %v", state.Balance1, state.Balance2)
+	}
+
+	signer, err := recoverSigner(c.channelStateHash(state.ChannelID, state.Balance1, state.Balance2, state.Nonce), state.Signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidChannelSignature, err)
+	}
+	if signer != counterparty {
+		return fmt.Errorf("%w: signed by %s, expected %s", ErrInvalidChannelSignature, signer.Hex(), counterparty.Hex())
+	}
+
+	latest, err := store.Latest(state.ChannelID)
+	if err != nil {
+		return fmt.Errorf("failed to load channel state: %w", err)
+	}
+	if latest != nil && state.Nonce <= latest.Nonce {
+		return fmt.Errorf("%w: nonce %d, last accepted %d", ErrStaleChannelState, state.Nonce, latest.Nonce)
+	}
+
+	if err := store.Save(state); err != nil {
+		return fmt.Errorf("failed to save channel state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package synapse
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestChannelStateHash(t *testing.T) {
+	var channelID [32]byte
+	for i := range channelID {
+		channelID[i] = 0xab
+	}
+	channelContract := common.HexToAddress("0x3333333333333333333333333333333333333333")
+
+	// toEthSignedMessageHash(keccak256(abi.encodePacked(channelId, 600, 400,
+	// 5, block.chainid, address(this)))) on chain 1337
+	want := "0x5e395aecfdf5bdac4da3b2281e465e8c11b1de88ca316398475e2d0e41869a99"
+
+	got := common.BytesToHash(channelStateHash(big.NewInt(1337), channelContract, channelID, big.NewInt(600), big.NewInt(400), 5)).Hex()
+	if got != want {
+		t.Errorf("channelStateHash = %s, want %s", got, want)
+	}
+
+	if other := common.BytesToHash(channelStateHash(big.NewInt(1), channelContract, channelID, big.NewInt(600), big.NewInt(400), 5)).Hex(); other == want {
+		t.Error("channel state hash does not depend on the chain ID")
+	}
+}
+
+func TestSignChannelStateRecoversSigner(t *testing.T) {
+	c := newTestClient(t, newMockBackend(), Config{})
+	channelID := [32]byte{1}
+
+	signature, err := c.SignChannelState(channelID, big.NewInt(600), big.NewInt(400), 5)
+	if err != nil {
+		t.Fatalf("SignChannelState: %v", err)
+	}
+	if v := signature[crypto.RecoveryIDOffset]; v != 27 && v != 28 {
+		t.Errorf("v = %d, want 27 or 28", v)
+	}
+
+	digest := channelStateHash(big.NewInt(1337), testContracts.PaymentChannel, channelID, big.NewInt(600), big.NewInt(400), 5)
+	signer, err := recoverSigner(digest, signature)
+	if err != nil {
+		t.Fatalf("recoverSigner: %v", err)
+	}
+	if signer != c.Address() {
+		t.Errorf("signer = %s, want %s", signer.Hex(), c.Address().Hex())
+	}
+}
+
+func TestSignChannelStateNonceAboveMaxInt64(t *testing.T) {
+	c := newTestClient(t, newMockBackend(), Config{})
+	channelID := [32]byte{1}
+	nonce := uint64(math.MaxInt64) + 1
+
+	signature, err := c.SignChannelState(channelID, big.NewInt(600), big.NewInt(400), nonce)
+	if err != nil {
+		t.Fatalf("SignChannelState: %v", err)
+	}
+
+	// The nonce is the uint256 word 2^63, with no sign bit lost to an int64
+	var word [32]byte
+	word[24] = 0x80
+	message := crypto.Keccak256(
+		channelID[:],
+		common.LeftPadBytes(big.NewInt(600).Bytes(), 32),
+		common.LeftPadBytes(big.NewInt(400).Bytes(), 32),
+		word[:],
+		common.LeftPadBytes(big.NewInt(1337).Bytes(), 32),
+		testContracts.PaymentChannel.Bytes(),
+	)
+	signer, err := recoverSigner(accounts.TextHash(message), signature)
+	if err != nil {
+		t.Fatalf("recoverSigner: %v", err)
+	}
+	if signer != c.Address() {
+		t.Errorf("signature does not cover the nonce word %x", word)
+	}
+
+	if _, err := NextChannelNonce(math.MaxUint64); !errors.Is(err, ErrChannelNonceOverflow) {
+		t.Errorf("NextChannelNonce(MaxUint64): err = %v, want ErrChannelNonceOverflow", err)
+	}
+	if next, err := NextChannelNonce(nonce); err != nil || next != nonce+1 {
+		t.Errorf("NextChannelNonce(%d) = %d, %v", nonce, next, err)
+	}
+}
+
+func TestAcceptChannelStateRejectsReplay(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	counterparty := newTestClient(t, backend, Config{Signer: NewLocalSigner(testKey(1))})
+	channelID := [32]byte{1}
+
+	signed := func(signer *Client, nonce uint64) SignedChannelState {
+		t.Helper()
+		signature, err := signer.SignChannelState(channelID, big.NewInt(600), big.NewInt(400), nonce)
+		if err != nil {
+			t.Fatalf("SignChannelState: %v", err)
+		}
+		return SignedChannelState{ChannelID: channelID, Balance1: big.NewInt(600), Balance2: big.NewInt(400), Nonce: nonce, Signature: signature}
+	}
+
+	otherContracts := testContracts
+	otherContracts.PaymentChannel = testAddress(9)
+	otherChannel := newTestClient(t, backend, Config{Signer: NewLocalSigner(testKey(1)), Contracts: otherContracts})
+
+	store := NewMemoryChannelStateStore()
+	if err := c.AcceptChannelState(store, counterparty.Address(), signed(counterparty, 2)); err != nil {
+		t.Fatalf("AcceptChannelState: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		state SignedChannelState
+		want  error
+	}{
+		{name: "same nonce", state: signed(counterparty, 2), want: ErrStaleChannelState},
+		{name: "older nonce", state: signed(counterparty, 1), want: ErrStaleChannelState},
+		{name: "signed by us", state: signed(c, 3), want: ErrInvalidChannelSignature},
+		{name: "signed for another channel contract", state: signed(otherChannel, 3), want: ErrInvalidChannelSignature},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.AcceptChannelState(store, counterparty.Address(), tt.state)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("AcceptChannelState = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	latest, err := store.Latest(channelID)
+	if err != nil {
+		t.Fatalf("Latest: %v", err)
+	}
+	if latest.Nonce != 2 {
+		t.Errorf("latest nonce = %d, want 2", latest.Nonce)
+	}
+}
+
+func TestCooperativeCloseHash(t *testing.T) {
+	var channelID [32]byte
+	for i := range channelID {
+		channelID[i] = 0xab
+	}
+
+	// toEthSignedMessageHash(createCooperativeCloseHash(channelId, 600, 400, 5))
+	want := "0x123357257946587464a6a13a5cfb40ddfa381260c13db56b4c527c4a598a235b"
+
+	if got := common.BytesToHash(cooperativeCloseHash(channelID, big.NewInt(600), big.NewInt(400), 5)).Hex(); got != want {
+		t.Errorf("cooperativeCloseHash = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+package synapse
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Option configures a client created with Dial. Each option sets the Config
+// field of the same name, so Dial accepts everything NewClient does.
+type Option func(*Config)
+
+// Dial connects to rpcURL and creates a client configured by opts, as
+// NewClient does for a Config
+func Dial(rpcURL string, opts ...Option) (*Client, error) {
+	config := Config{RPCURL: rpcURL}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	return NewClient(config)
+}
+
+// WithConfig starts from an existing Config; later options override its
+// fields
+func WithConfig(config Config) Option {
+	return func(c *Config) {
+		rpcURL := c.RPCURL
+		*c = config
+		c.RPCURL = rpcURL
+	}
+}
+
+// WithPrivateKey sets the hex encoded key the client signs with
+func WithPrivateKey(privateKeyHex string) Option {
+	return func(c *Config) {
+		c.PrivateKey = privateKeyHex
+	}
+}
+
+// WithSigner sets the signer used instead of a private key, see
+// Config.Signer
+func WithSigner(signer Signer) Option {
+	return func(c *Config) {
+		c.Signer = signer
+	}
+}
+
+// WithAccounts adds signers write methods can send from with WithFrom, see
+// Config.Accounts
+func WithAccounts(signers ...Signer) Option {
+	return func(c *Config) {
+		c.Accounts = append(c.Accounts, signers...)
+	}
+}
+
+// WithAddress sets the account a read-only client queries as its own, see
+// Config.Address
+func WithAddress(address common.Address) Option {
+	return func(c *Config) {
+		c.Address = address
+	}
+}
+
+// WithContracts sets the protocol contract addresses
+func WithContracts(contracts ContractAddresses) Option {
+	return func(c *Config) {
+		c.Contracts = contracts
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for service endpoints
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Config) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithLogger sets the logger receiving debug logs, see Config.Logger
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *Config) {
+		c.Logger = logger
+	}
+}
+
+// WithGasStrategy sets the fee strategy of EIP-1559 transactions, see
+// Config.FeeStrategy
+func WithGasStrategy(strategy FeeStrategy) Option {
+	return func(c *Config) {
+		c.FeeStrategy = strategy
+	}
+}
+
+// WithConfirmations sets how many blocks deep write methods wait for their
+// transactions to be, see Config.Confirmations
+func WithConfirmations(confirmations uint64) Option {
+	return func(c *Config) {
+		c.Confirmations = confirmations
+	}
+}
@@ -0,0 +1,50 @@
+package synapse
+
+import (
+	"encoding/hex"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDialWithOptions(t *testing.T) {
+	_, server := newFlakyRPC(t)
+	logger, _ := captureLogs()
+	httpClient := &http.Client{Timeout: time.Second}
+
+	c, err := Dial(server.URL,
+		WithConfig(Config{RPCURL: "ws://ignored", Confirmations: 5, DefaultTimeout: time.Minute}),
+		WithPrivateKey(hex.EncodeToString(crypto.FromECDSA(testKey(0)))),
+		WithContracts(testContracts),
+		WithHTTPClient(httpClient),
+		WithLogger(logger),
+		WithGasStrategy(FeeFast),
+		WithConfirmations(2),
+	)
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer c.Close()
+
+	if c.Address() != testAddress(0) || c.ReadOnly() {
+		t.Errorf("client signs as %s, want %s", c.Address().Hex(), testAddress(0).Hex())
+	}
+	if c.ChainID().Int64() != 1337 {
+		t.Errorf("ChainID = %s, want the endpoint's 1337", c.ChainID())
+	}
+
+	config := c.config
+	if config.RPCURL != server.URL {
+		t.Errorf("RPCURL = %q, want the dialed %q", config.RPCURL, server.URL)
+	}
+	if config.Contracts != testContracts || config.HTTPClient != httpClient || config.Logger != logger || !reflect.DeepEqual(config.FeeStrategy, FeeFast) {
+		t.Error("options were not applied to the client's Config")
+	}
+	// Later options override WithConfig, whose other fields are kept
+	if config.Confirmations != 2 || config.DefaultTimeout != time.Minute {
+		t.Errorf("Confirmations %d DefaultTimeout %s, want 2 and 1m", config.Confirmations, config.DefaultTimeout)
+	}
+}
@@ -0,0 +1,808 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ABI definitions for the protocol contracts. Each covers only the subset of
+// the contract interface used by the SDK; see contracts/ for the full sources.
+
+const tokenABIJSON = `[
+	{"type":"function","name":"name","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"symbol","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"totalSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"circulatingSupply","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"allowance","stateMutability":"view","inputs":[{"name":"owner","type":"address"},{"name":"spender","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"approve","stateMutability":"nonpayable","inputs":[{"name":"spender","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]},
+	{"type":"error","name":"AddressBlocked","inputs":[]},
+	{"type":"error","name":"ZeroAddress","inputs":[]},
+	{"type":"error","name":"FeeTooHigh","inputs":[]},
+	{"type":"error","name":"InsufficientBalance","inputs":[]},
+	{"type":"error","name":"MaxSupplyExceeded","inputs":[]},
+	{"type":"error","name":"ERC20InsufficientBalance","inputs":[{"name":"sender","type":"address"},{"name":"balance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InsufficientAllowance","inputs":[{"name":"spender","type":"address"},{"name":"allowance","type":"uint256"},{"name":"needed","type":"uint256"}]},
+	{"type":"error","name":"ERC20InvalidSender","inputs":[{"name":"sender","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidReceiver","inputs":[{"name":"receiver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidApprover","inputs":[{"name":"approver","type":"address"}]},
+	{"type":"error","name":"ERC20InvalidSpender","inputs":[{"name":"spender","type":"address"}]}
+]`
+
+const paymentRouterABIJSON = `[
+	{"type":"function","name":"pay","stateMutability":"nonpayable","inputs":[
+		{"name":"recipient","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"serviceType","type":"bytes32"},
+		{"name":"metadata","type":"string"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"batchPay","stateMutability":"nonpayable","inputs":[
+		{"name":"recipients","type":"address[]"},
+		{"name":"amounts","type":"uint256[]"},
+		{"name":"serviceTypes","type":"bytes32[]"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"BatchPaymentExecuted","anonymous":false,"inputs":[
+		{"name":"batchId","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"totalAmount","type":"uint256","indexed":false},
+		{"name":"recipientCount","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"baseFee","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"tierDiscounts","stateMutability":"view","inputs":[{"name":"","type":"uint8"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"getPayment","stateMutability":"view","inputs":[{"name":"paymentId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"paymentId","type":"bytes32"},
+		{"name":"sender","type":"address"},
+		{"name":"recipient","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"fee","type":"uint256"},
+		{"name":"timestamp","type":"uint256"},
+		{"name":"status","type":"uint8"},
+		{"name":"serviceType","type":"bytes32"},
+		{"name":"metadata","type":"string"}
+	]}]},
+	{"type":"function","name":"getEscrow","stateMutability":"view","inputs":[{"name":"escrowId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"escrowId","type":"bytes32"},
+		{"name":"sender","type":"address"},
+		{"name":"recipient","type":"address"},
+		{"name":"arbiter","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"fee","type":"uint256"},
+		{"name":"deadline","type":"uint256"},
+		{"name":"status","type":"uint8"},
+		{"name":"conditionHash","type":"bytes32"}
+	]}]},
+	{"type":"event","name":"PaymentExecuted","anonymous":false,"inputs":[
+		{"name":"paymentId","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"recipient","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"fee","type":"uint256","indexed":false},
+		{"name":"serviceType","type":"bytes32","indexed":false}
+	]},
+	{"type":"event","name":"EscrowCreated","anonymous":false,"inputs":[
+		{"name":"escrowId","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"recipient","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"deadline","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"createEscrow","stateMutability":"nonpayable","inputs":[
+		{"name":"recipient","type":"address"},
+		{"name":"arbiter","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"deadline","type":"uint256"},
+		{"name":"conditionHash","type":"bytes32"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"releaseEscrow","stateMutability":"nonpayable","inputs":[{"name":"escrowId","type":"bytes32"},{"name":"conditionProof","type":"bytes"}],"outputs":[]},
+	{"type":"event","name":"EscrowReleased","anonymous":false,"inputs":[{"name":"escrowId","type":"bytes32","indexed":true}]},
+	{"type":"function","name":"getStream","stateMutability":"view","inputs":[{"name":"streamId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"streamId","type":"bytes32"},
+		{"name":"sender","type":"address"},
+		{"name":"recipient","type":"address"},
+		{"name":"totalAmount","type":"uint256"},
+		{"name":"withdrawn","type":"uint256"},
+		{"name":"startTime","type":"uint256"},
+		{"name":"endTime","type":"uint256"},
+		{"name":"active","type":"bool"}
+	]}]},
+	{"type":"function","name":"cancelStream","stateMutability":"nonpayable","inputs":[{"name":"streamId","type":"bytes32"}],"outputs":[]},
+	{"type":"function","name":"createStream","stateMutability":"nonpayable","inputs":[
+		{"name":"recipient","type":"address"},
+		{"name":"totalAmount","type":"uint256"},
+		{"name":"duration","type":"uint256"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"StreamCreated","anonymous":false,"inputs":[
+		{"name":"streamId","type":"bytes32","indexed":true},
+		{"name":"sender","type":"address","indexed":true},
+		{"name":"recipient","type":"address","indexed":true},
+		{"name":"totalAmount","type":"uint256","indexed":false},
+		{"name":"duration","type":"uint256","indexed":false}
+	]},
+	{"type":"event","name":"StreamCancelled","anonymous":false,"inputs":[
+		{"name":"streamId","type":"bytes32","indexed":true},
+		{"name":"refundAmount","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"payWithSignature","stateMutability":"nonpayable","inputs":[
+		{"name":"sender","type":"address"},
+		{"name":"recipient","type":"address"},
+		{"name":"amount","type":"uint256"},
+		{"name":"serviceType","type":"bytes32"},
+		{"name":"deadline","type":"uint256"},
+		{"name":"signature","type":"bytes"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"MAX_BATCH_SIZE","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"nonces","stateMutability":"view","inputs":[{"name":"","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"error","name":"InvalidAmount","inputs":[]},
+	{"type":"error","name":"InvalidRecipient","inputs":[]},
+	{"type":"error","name":"PaymentNotFound","inputs":[]},
+	{"type":"error","name":"EscrowNotFound","inputs":[]},
+	{"type":"error","name":"StreamNotFound","inputs":[]},
+	{"type":"error","name":"DeadlineExpired","inputs":[]},
+	{"type":"error","name":"DeadlineNotExpired","inputs":[]},
+	{"type":"error","name":"Unauthorized","inputs":[]},
+	{"type":"error","name":"AlreadyProcessed","inputs":[]},
+	{"type":"error","name":"InvalidSignature","inputs":[]},
+	{"type":"error","name":"BatchTooLarge","inputs":[]},
+	{"type":"error","name":"InsufficientStreamBalance","inputs":[]},
+	{"type":"error","name":"StreamNotActive","inputs":[]}
+]`
+
+const paymentChannelABIJSON = `[
+	{"type":"function","name":"openChannel","stateMutability":"nonpayable","inputs":[
+		{"name":"partyB","type":"address"},
+		{"name":"depositA","type":"uint256"},
+		{"name":"depositB","type":"uint256"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"ChannelOpened","anonymous":false,"inputs":[
+		{"name":"channelId","type":"bytes32","indexed":true},
+		{"name":"partyA","type":"address","indexed":true},
+		{"name":"partyB","type":"address","indexed":true},
+		{"name":"depositA","type":"uint256","indexed":false},
+		{"name":"depositB","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"cooperativeClose","stateMutability":"nonpayable","inputs":[
+		{"name":"channelId","type":"bytes32"},
+		{"name":"balanceA","type":"uint256"},
+		{"name":"balanceB","type":"uint256"},
+		{"name":"nonce","type":"uint256"},
+		{"name":"sigA","type":"bytes"},
+		{"name":"sigB","type":"bytes"}
+	],"outputs":[]},
+	{"type":"function","name":"initiateClose","stateMutability":"nonpayable","inputs":[
+		{"name":"channelId","type":"bytes32"},
+		{"name":"balanceA","type":"uint256"},
+		{"name":"balanceB","type":"uint256"},
+		{"name":"nonce","type":"uint256"},
+		{"name":"sigA","type":"bytes"},
+		{"name":"sigB","type":"bytes"}
+	],"outputs":[]},
+	{"type":"function","name":"challenge","stateMutability":"nonpayable","inputs":[
+		{"name":"channelId","type":"bytes32"},
+		{"name":"balanceA","type":"uint256"},
+		{"name":"balanceB","type":"uint256"},
+		{"name":"nonce","type":"uint256"},
+		{"name":"sigA","type":"bytes"},
+		{"name":"sigB","type":"bytes"}
+	],"outputs":[]},
+	{"type":"function","name":"finalizeClose","stateMutability":"nonpayable","inputs":[{"name":"channelId","type":"bytes32"}],"outputs":[]},
+	{"type":"event","name":"ChannelCloseInitiated","anonymous":false,"inputs":[
+		{"name":"channelId","type":"bytes32","indexed":true},
+		{"name":"initiator","type":"address","indexed":true},
+		{"name":"balanceA","type":"uint256","indexed":false},
+		{"name":"balanceB","type":"uint256","indexed":false},
+		{"name":"nonce","type":"uint256","indexed":false}
+	]},
+	{"type":"event","name":"ChannelChallenged","anonymous":false,"inputs":[
+		{"name":"channelId","type":"bytes32","indexed":true},
+		{"name":"challenger","type":"address","indexed":true},
+		{"name":"newNonce","type":"uint256","indexed":false}
+	]},
+	{"type":"event","name":"ChannelClosed","anonymous":false,"inputs":[
+		{"name":"channelId","type":"bytes32","indexed":true},
+		{"name":"finalBalanceA","type":"uint256","indexed":false},
+		{"name":"finalBalanceB","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"CHALLENGE_PERIOD","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"getUserChannels","stateMutability":"view","inputs":[{"name":"user","type":"address"}],"outputs":[{"name":"","type":"bytes32[]"}]},
+	{"type":"function","name":"getChannel","stateMutability":"view","inputs":[{"name":"channelId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"channelId","type":"bytes32"},
+		{"name":"partyA","type":"address"},
+		{"name":"partyB","type":"address"},
+		{"name":"depositA","type":"uint256"},
+		{"name":"depositB","type":"uint256"},
+		{"name":"balanceA","type":"uint256"},
+		{"name":"balanceB","type":"uint256"},
+		{"name":"nonce","type":"uint256"},
+		{"name":"openTime","type":"uint256"},
+		{"name":"closeTime","type":"uint256"},
+		{"name":"challengeEnd","type":"uint256"},
+		{"name":"status","type":"uint8"},
+		{"name":"latestStateHash","type":"bytes32"}
+	]}]},
+	{"type":"error","name":"ChannelNotFound","inputs":[]},
+	{"type":"error","name":"ChannelNotOpen","inputs":[]},
+	{"type":"error","name":"ChannelAlreadyExists","inputs":[]},
+	{"type":"error","name":"InvalidParty","inputs":[]},
+	{"type":"error","name":"InvalidDeposit","inputs":[]},
+	{"type":"error","name":"InvalidSignature","inputs":[]},
+	{"type":"error","name":"InvalidNonce","inputs":[]},
+	{"type":"error","name":"InvalidBalances","inputs":[]},
+	{"type":"error","name":"ChallengePeriodNotOver","inputs":[]},
+	{"type":"error","name":"ChallengePeriodOver","inputs":[]},
+	{"type":"error","name":"NotParty","inputs":[]},
+	{"type":"error","name":"ChannelNotClosing","inputs":[]}
+]`
+
+const reputationABIJSON = `[
+	{"type":"function","name":"registrationFee","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"event","name":"AgentRegistered","anonymous":false,"inputs":[
+		{"name":"agent","type":"address","indexed":true},
+		{"name":"agentId","type":"bytes32","indexed":true},
+		{"name":"stake","type":"uint256","indexed":false},
+		{"name":"metadataURI","type":"string","indexed":false}
+	]},
+	{"type":"event","name":"AgentUpdated","anonymous":false,"inputs":[
+		{"name":"agent","type":"address","indexed":true},
+		{"name":"newScore","type":"uint256","indexed":false},
+		{"name":"newTier","type":"uint8","indexed":false}
+	]},
+	{"type":"event","name":"TransactionRecorded","anonymous":false,"inputs":[
+		{"name":"agent","type":"address","indexed":true},
+		{"name":"transactionId","type":"bytes32","indexed":true},
+		{"name":"success","type":"bool","indexed":false},
+		{"name":"amount","type":"uint256","indexed":false}
+	]},
+	{"type":"event","name":"ServiceRated","anonymous":false,"inputs":[
+		{"name":"agent","type":"address","indexed":true},
+		{"name":"serviceType","type":"bytes32","indexed":true},
+		{"name":"rater","type":"address","indexed":true},
+		{"name":"rating","type":"uint8","indexed":false}
+	]},
+	{"type":"function","name":"registerAgent","stateMutability":"nonpayable","inputs":[{"name":"metadataURI","type":"string"},{"name":"initialStake","type":"uint256"}],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"function","name":"addStake","stateMutability":"nonpayable","inputs":[{"name":"amount","type":"uint256"}],"outputs":[]},
+	{"type":"function","name":"createDispute","stateMutability":"nonpayable","inputs":[
+		{"name":"defendant","type":"address"},
+		{"name":"transactionId","type":"bytes32"},
+		{"name":"amount","type":"uint256"},
+		{"name":"evidence","type":"string"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"DisputeCreated","anonymous":false,"inputs":[
+		{"name":"disputeId","type":"bytes32","indexed":true},
+		{"name":"claimant","type":"address","indexed":true},
+		{"name":"defendant","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"getServiceRating","stateMutability":"view","inputs":[{"name":"agentAddress","type":"address"},{"name":"serviceType","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"totalRatings","type":"uint256"},
+		{"name":"sumRatings","type":"uint256"},
+		{"name":"averageRating","type":"uint256"}
+	]}]},
+	{"type":"function","name":"rateService","stateMutability":"nonpayable","inputs":[{"name":"agentAddress","type":"address"},{"name":"serviceType","type":"bytes32"},{"name":"rating","type":"uint8"}],"outputs":[]},
+	{"type":"function","name":"getAgent","stateMutability":"view","inputs":[{"name":"agentAddress","type":"address"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"agentId","type":"bytes32"},
+		{"name":"owner","type":"address"},
+		{"name":"registrationTime","type":"uint256"},
+		{"name":"stakedAmount","type":"uint256"},
+		{"name":"reputationScore","type":"uint256"},
+		{"name":"totalTransactions","type":"uint256"},
+		{"name":"successfulTransactions","type":"uint256"},
+		{"name":"failedTransactions","type":"uint256"},
+		{"name":"totalVolume","type":"uint256"},
+		{"name":"disputesRaised","type":"uint256"},
+		{"name":"disputesLost","type":"uint256"},
+		{"name":"tier","type":"uint8"},
+		{"name":"status","type":"uint8"},
+		{"name":"metadataURI","type":"string"}
+	]}]},
+	{"type":"function","name":"getAgentTier","stateMutability":"view","inputs":[{"name":"agentAddress","type":"address"}],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"getTierRequirements","stateMutability":"view","inputs":[{"name":"tier","type":"uint8"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"minTransactions","type":"uint256"},
+		{"name":"minSuccessRate","type":"uint256"},
+		{"name":"minStake","type":"uint256"},
+		{"name":"feeDiscount","type":"uint256"}
+	]}]},
+	{"type":"error","name":"AgentNotFound","inputs":[]},
+	{"type":"error","name":"AgentAlreadyRegistered","inputs":[]},
+	{"type":"error","name":"InsufficientStake","inputs":[]},
+	{"type":"error","name":"InvalidRating","inputs":[]},
+	{"type":"error","name":"InvalidTier","inputs":[]},
+	{"type":"error","name":"DisputeNotFound","inputs":[]},
+	{"type":"error","name":"DisputeDeadlinePassed","inputs":[]},
+	{"type":"error","name":"DisputeAlreadyResolved","inputs":[]},
+	{"type":"error","name":"Unauthorized","inputs":[]},
+	{"type":"error","name":"AgentNotActive","inputs":[]},
+	{"type":"error","name":"WithdrawalLocked","inputs":[]}
+]`
+
+const serviceRegistryABIJSON = `[
+	{"type":"function","name":"getAllCategories","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"bytes32[]"}]},
+	{"type":"function","name":"getService","stateMutability":"view","inputs":[{"name":"serviceId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"serviceId","type":"bytes32"},
+		{"name":"provider","type":"address"},
+		{"name":"category","type":"bytes32"},
+		{"name":"name","type":"string"},
+		{"name":"description","type":"string"},
+		{"name":"metadataURI","type":"string"},
+		{"name":"endpoint","type":"string"},
+		{"name":"pricingModel","type":"uint8"},
+		{"name":"basePrice","type":"uint256"},
+		{"name":"minAmount","type":"uint256"},
+		{"name":"maxAmount","type":"uint256"},
+		{"name":"registrationTime","type":"uint256"},
+		{"name":"lastUpdateTime","type":"uint256"},
+		{"name":"status","type":"uint8"},
+		{"name":"totalRequests","type":"uint256"},
+		{"name":"totalVolume","type":"uint256"}
+	]}]},
+	{"type":"function","name":"getServicesByProvider","stateMutability":"view","inputs":[{"name":"provider","type":"address"}],"outputs":[{"name":"","type":"bytes32[]"}]},
+	{"type":"function","name":"registerService","stateMutability":"nonpayable","inputs":[
+		{"name":"category","type":"bytes32"},
+		{"name":"name","type":"string"},
+		{"name":"description","type":"string"},
+		{"name":"metadataURI","type":"string"},
+		{"name":"endpoint","type":"string"},
+		{"name":"pricingModel","type":"uint8"},
+		{"name":"basePrice","type":"uint256"},
+		{"name":"minAmount","type":"uint256"},
+		{"name":"maxAmount","type":"uint256"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"ServiceRegistered","anonymous":false,"inputs":[
+		{"name":"serviceId","type":"bytes32","indexed":true},
+		{"name":"provider","type":"address","indexed":true},
+		{"name":"category","type":"bytes32","indexed":true},
+		{"name":"name","type":"string","indexed":false},
+		{"name":"basePrice","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"getEstimatedPrice","stateMutability":"view","inputs":[{"name":"serviceId","type":"bytes32"},{"name":"amount","type":"uint256"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"requestQuote","stateMutability":"nonpayable","inputs":[
+		{"name":"serviceId","type":"bytes32"},
+		{"name":"paramsHash","type":"bytes32"},
+		{"name":"estimatedAmount","type":"uint256"}
+	],"outputs":[{"name":"","type":"bytes32"}]},
+	{"type":"event","name":"QuoteCreated","anonymous":false,"inputs":[
+		{"name":"quoteId","type":"bytes32","indexed":true},
+		{"name":"serviceId","type":"bytes32","indexed":true},
+		{"name":"requester","type":"address","indexed":true},
+		{"name":"estimatedAmount","type":"uint256","indexed":false}
+	]},
+	{"type":"function","name":"getQuote","stateMutability":"view","inputs":[{"name":"quoteId","type":"bytes32"}],"outputs":[{"name":"","type":"tuple","components":[
+		{"name":"quoteId","type":"bytes32"},
+		{"name":"serviceId","type":"bytes32"},
+		{"name":"requester","type":"address"},
+		{"name":"estimatedAmount","type":"uint256"},
+		{"name":"validUntil","type":"uint256"},
+		{"name":"accepted","type":"bool"},
+		{"name":"params","type":"bytes32"}
+	]}]},
+	{"type":"function","name":"acceptQuote","stateMutability":"nonpayable","inputs":[{"name":"quoteId","type":"bytes32"}],"outputs":[]},
+	{"type":"event","name":"ServiceRequest","anonymous":false,"inputs":[
+		{"name":"serviceId","type":"bytes32","indexed":true},
+		{"name":"requester","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false}
+	]},
+	{"type":"error","name":"ServiceNotFound","inputs":[]},
+	{"type":"error","name":"ServiceNotActive","inputs":[]},
+	{"type":"error","name":"InvalidCategory","inputs":[]},
+	{"type":"error","name":"TooManyServices","inputs":[]},
+	{"type":"error","name":"InvalidPrice","inputs":[]},
+	{"type":"error","name":"InvalidAmount","inputs":[]},
+	{"type":"error","name":"QuoteNotFound","inputs":[]},
+	{"type":"error","name":"QuoteExpired","inputs":[]},
+	{"type":"error","name":"QuoteAlreadyAccepted","inputs":[]},
+	{"type":"error","name":"Unauthorized","inputs":[]}
+]`
+
+// multicallABIJSON covers the Multicall3 aggregate3 function
+const multicallABIJSON = `[
+	{"type":"function","name":"aggregate3","stateMutability":"payable","inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},
+		{"name":"allowFailure","type":"bool"},
+		{"name":"callData","type":"bytes"}
+	]}],"outputs":[{"name":"returnData","type":"tuple[]","components":[
+		{"name":"success","type":"bool"},
+		{"name":"returnData","type":"bytes"}
+	]}]}
+]`
+
+var (
+	tokenABI           = mustParseABI(tokenABIJSON)
+	serviceRegistryABI = mustParseABI(serviceRegistryABIJSON)
+	reputationABI      = mustParseABI(reputationABIJSON)
+	paymentRouterABI   = mustParseABI(paymentRouterABIJSON)
+	paymentChannelABI  = mustParseABI(paymentChannelABIJSON)
+	multicallABI       = mustParseABI(multicallABIJSON)
+)
+
+// paymentData mirrors the PaymentRouter.Payment struct
+type paymentData struct {
+	PaymentId   [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	Amount      *big.Int
+	Fee         *big.Int
+	Timestamp   *big.Int
+	Status      uint8
+	ServiceType [32]byte
+	Metadata    string
+}
+
+// serviceRatingData mirrors the ReputationRegistry.ServiceRating struct
+type serviceRatingData struct {
+	TotalRatings  *big.Int
+	SumRatings    *big.Int
+	AverageRating *big.Int
+}
+
+// escrowData mirrors the PaymentRouter.EscrowPayment struct
+type escrowData struct {
+	EscrowId      [32]byte
+	Sender        common.Address
+	Recipient     common.Address
+	Arbiter       common.Address
+	Amount        *big.Int
+	Fee           *big.Int
+	Deadline      *big.Int
+	Status        uint8
+	ConditionHash [32]byte
+}
+
+// streamData mirrors the PaymentRouter.PaymentStream struct
+type streamData struct {
+	StreamId    [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	TotalAmount *big.Int
+	Withdrawn   *big.Int
+	StartTime   *big.Int
+	EndTime     *big.Int
+	Active      bool
+}
+
+// quoteData mirrors the ServiceRegistry.ServiceQuote struct
+type quoteData struct {
+	QuoteId         [32]byte
+	ServiceId       [32]byte
+	Requester       common.Address
+	EstimatedAmount *big.Int
+	ValidUntil      *big.Int
+	Accepted        bool
+	Params          [32]byte
+}
+
+// serviceData mirrors the ServiceRegistry.Service struct
+type serviceData struct {
+	ServiceId        [32]byte
+	Provider         common.Address
+	Category         [32]byte
+	Name             string
+	Description      string
+	MetadataURI      string
+	Endpoint         string
+	PricingModel     uint8
+	BasePrice        *big.Int
+	MinAmount        *big.Int
+	MaxAmount        *big.Int
+	RegistrationTime *big.Int
+	LastUpdateTime   *big.Int
+	Status           uint8
+	TotalRequests    *big.Int
+	TotalVolume      *big.Int
+}
+
+// serviceStatusActive is ServiceRegistry.ServiceStatus.Active
+const serviceStatusActive = 1
+
+// agentData mirrors the ReputationRegistry.AIAgent struct
+type agentData struct {
+	AgentId                [32]byte
+	Owner                  common.Address
+	RegistrationTime       *big.Int
+	StakedAmount           *big.Int
+	ReputationScore        *big.Int
+	TotalTransactions      *big.Int
+	SuccessfulTransactions *big.Int
+	FailedTransactions     *big.Int
+	TotalVolume            *big.Int
+	DisputesRaised         *big.Int
+	DisputesLost           *big.Int
+	Tier                   uint8
+	Status                 uint8
+	MetadataURI            string
+}
+
+// ReputationRegistry.AgentStatus values
+const (
+	agentStatusUnregistered = 0
+	agentStatusActive       = 1
+)
+
+// tierRequirementsData mirrors the ReputationRegistry.TierRequirements struct
+type tierRequirementsData struct {
+	MinTransactions *big.Int
+	MinSuccessRate  *big.Int
+	MinStake        *big.Int
+	FeeDiscount     *big.Int
+}
+
+// channelData mirrors the PaymentChannel.Channel struct
+type channelData struct {
+	ChannelId       [32]byte
+	PartyA          common.Address
+	PartyB          common.Address
+	DepositA        *big.Int
+	DepositB        *big.Int
+	BalanceA        *big.Int
+	BalanceB        *big.Int
+	Nonce           *big.Int
+	OpenTime        *big.Int
+	CloseTime       *big.Int
+	ChallengeEnd    *big.Int
+	Status          uint8
+	LatestStateHash [32]byte
+}
+
+// Contract names used to key Config.ABIOverrides
+const (
+	ContractToken           = "Token"
+	ContractPaymentRouter   = "PaymentRouter"
+	ContractReputation      = "Reputation"
+	ContractServiceRegistry = "ServiceRegistry"
+	ContractPaymentChannel  = "PaymentChannel"
+	ContractMulticall       = "Multicall"
+)
+
+// embeddedABIs maps contract names to the ABIs shipped with the SDK
+var embeddedABIs = map[string]abi.ABI{
+	ContractToken:           tokenABI,
+	ContractPaymentRouter:   paymentRouterABI,
+	ContractReputation:      reputationABI,
+	ContractServiceRegistry: serviceRegistryABI,
+	ContractPaymentChannel:  paymentChannelABI,
+	ContractMulticall:       multicallABI,
+}
+
+// mustParseABI parses an embedded ABI definition
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(fmt.Sprintf("synapse: invalid embedded ABI: %v", err))
+	}
+	return parsed
+}
+
+// contractABI returns the ABI for a protocol contract, preferring any
+// override from Config.ABIOverrides over the embedded definition
+func (c *Client) contractABI(name string) abi.ABI {
+	if override, ok := c.config.ABIOverrides[name]; ok {
+		return override
+	}
+	return embeddedABIs[name]
+}
+
+// contractAddress returns the configured address of a protocol contract
+func (c *Client) contractAddress(name string) (common.Address, error) {
+	var address common.Address
+	switch name {
+	case ContractToken:
+		address = c.config.Contracts.Token
+	case ContractPaymentRouter:
+		address = c.config.Contracts.PaymentRouter
+	case ContractReputation:
+		address = c.config.Contracts.Reputation
+	case ContractServiceRegistry:
+		address = c.config.Contracts.ServiceRegistry
+	case ContractPaymentChannel:
+		address = c.config.Contracts.PaymentChannel
+	case ContractMulticall:
+		address = c.config.Contracts.Multicall
+	default:
+		return common.Address{}, fmt.Errorf("unknown contract: %s", name)
+	}
+
+	if address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%s contract address not configured", name)
+	}
+
+	return address, nil
+}
+
+// boundContract binds a protocol contract by name
+func (c *Client) boundContract(name string) (*bind.BoundContract, error) {
+	address, err := c.contractAddress(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return bind.NewBoundContract(address, c.contractABI(name), c.client, c.client, c.client), nil
+}
+
+// Call performs a read-only call of any method on a protocol contract,
+// including methods only present in an ABI override
+func (c *Client) Call(ctx context.Context, contract, method string, args ...interface{}) ([]interface{}, error) {
+	return c.callContract(ctx, contract, method, args...)
+}
+
+// Transact submits a call of any method on a protocol contract, including
+// methods only present in an ABI override
+func (c *Client) Transact(ctx context.Context, contract, method string, args []interface{}, opts ...TxOption) (common.Hash, error) {
+	tx, err := c.transactContract(ctx, contract, method, args, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// callContract performs a read-only contract call against the latest block
+// and returns the unpacked outputs
+func (c *Client) callContract(ctx context.Context, contract, method string, args ...interface{}) ([]interface{}, error) {
+	return c.callContractOpts(&bind.CallOpts{Context: ctx}, contract, method, args...)
+}
+
+// callContractOpts performs a read-only contract call with explicit call options
+func (c *Client) callContractOpts(opts *bind.CallOpts, contract, method string, args ...interface{}) ([]interface{}, error) {
+	bound, err := c.boundContract(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	callOpts := bind.CallOpts{}
+	if opts != nil {
+		callOpts = *opts
+	}
+	if callOpts.Context == nil {
+		callOpts.Context = context.Background()
+	}
+	ctx, cancel := c.withTimeout(callOpts.Context, timeoutRead)
+	defer cancel()
+	callOpts.Context = ctx
+	opts = &callOpts
+
+	var out []interface{}
+	if err := bound.Call(opts, &out, method, args...); err != nil {
+		return nil, fmt.Errorf("failed to call %s: %w", method, c.decodeRevert(err))
+	}
+
+	return out, nil
+}
+
+// DefaultGasEstimateMultiplier is the default Config.GasEstimateMultiplier
+const DefaultGasEstimateMultiplier = 1.2
+
+// estimateContractGas estimates the gas a contract call from the client's
+// account would use
+func (c *Client) estimateContractGas(ctx context.Context, contract, method string, args ...interface{}) (uint64, error) {
+	return c.estimateContractGasFrom(ctx, c.address, contract, method, args...)
+}
+
+// estimateContractGasFrom is estimateContractGas for the account from
+func (c *Client) estimateContractGasFrom(ctx context.Context, from common.Address, contract, method string, args ...interface{}) (uint64, error) {
+	address, err := c.contractAddress(contract)
+	if err != nil {
+		return 0, err
+	}
+
+	contractABI := c.contractABI(contract)
+	data, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to pack %s: %w", method, err)
+	}
+
+	gas, err := c.client.EstimateGas(ctx, ethereum.CallMsg{
+		From: from,
+		To:   &address,
+		Data: data,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to estimate gas for %s: %w", method, c.decodeRevert(err))
+	}
+
+	return gas, nil
+}
+
+// transactContract signs and submits a contract call from the client's
+// account, or the one selected with WithFrom
+func (c *Client) transactContract(ctx context.Context, contract, method string, args []interface{}, opts ...TxOption) (tx *types.Transaction, err error) {
+	o := applyTxOptions(opts)
+	if err := requireMaxFeeChecked(method, o); err != nil {
+		return nil, err
+	}
+
+	acct, err := c.sender(o.from)
+	if err != nil {
+		return nil, err
+	}
+
+	bound, err := c.boundContract(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := c.withTimeout(ctx, timeoutWrite)
+	defer cancel()
+
+	if o.gasLimit == 0 && c.config.GasLimits[method] != 0 {
+		opts = append(opts[:len(opts):len(opts)], WithGasLimit(c.config.GasLimits[method]))
+	} else if o.gasLimit == 0 {
+		gas, err := c.estimateContractGasFrom(ctx, acct.address, contract, method, args...)
+		if err != nil {
+			return nil, err
+		}
+
+		multiplier := o.gasMultiplier
+		if multiplier == 0 {
+			multiplier = c.config.GasEstimateMultiplier
+		}
+		if multiplier == 0 {
+			multiplier = DefaultGasEstimateMultiplier
+		}
+		opts = append(opts[:len(opts):len(opts)], WithGasLimit(uint64(float64(gas)*multiplier)))
+	}
+
+	if o.nonce == nil {
+		nonce, err := c.acquireNonce(ctx, acct)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { c.releaseNonce(acct, nonce, tx) }()
+
+		opts = append(opts[:len(opts):len(opts)], WithNonce(nonce))
+	}
+
+	auth, err := c.getTransactOpts(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.accessList != nil {
+		tx, err = c.transactWithAccessList(auth, contract, method, args, o.accessList)
+	} else {
+		tx, err = bound.Transact(auth, method, args...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to submit %s: %w", method, c.decodeRevert(err))
+	}
+
+	c.logDebug(ctx, "submitted transaction",
+		slog.String("contract", contract),
+		slog.String("method", method),
+		slog.String("tx", tx.Hash().Hex()),
+		slog.Uint64("nonce", tx.Nonce()),
+		c.logAddress("from", acct.address),
+		slog.String("correlation_id", o.correlationID),
+	)
+
+	if c.config.Journal != nil {
+		entry := JournalEntry{
+			TxHash:        tx.Hash(),
+			From:          acct.address,
+			Nonce:         tx.Nonce(),
+			Contract:      contract,
+			Method:        method,
+			Submitted:     time.Now(),
+			CorrelationID: o.correlationID,
+		}
+		// The transaction is already broadcast, so failing here would drop
+		// its hash and invite a resubmission
+		if err := c.config.Journal.Record(entry); err != nil {
+			c.logWarn(ctx, "failed to journal transaction",
+				slog.String("tx", tx.Hash().Hex()),
+				slog.Any("error", err),
+			)
+		}
+	}
+
+	return tx, nil
+}
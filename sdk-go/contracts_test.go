@@ -0,0 +1,52 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+func TestABIOverride(t *testing.T) {
+	upgraded, err := abi.JSON(strings.NewReader(`[
+		{"type":"function","name":"version","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"string"}]},
+		{"type":"function","name":"pause","stateMutability":"nonpayable","inputs":[],"outputs":[]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := newMockBackend()
+	backend.returns(testContracts.PaymentRouter, upgraded, "version", "2.0.0")
+	backend.returns(testContracts.PaymentRouter, upgraded, "pause")
+
+	embedded := newTestClient(t, backend, Config{})
+	if _, err := embedded.Call(context.Background(), ContractPaymentRouter, "version"); err == nil {
+		t.Error("Call of a method missing from the embedded ABI succeeded")
+	}
+
+	c := newTestClient(t, backend, Config{ABIOverrides: map[string]abi.ABI{ContractPaymentRouter: upgraded}})
+	out, err := c.Call(context.Background(), ContractPaymentRouter, "version")
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+	if len(out) != 1 || out[0] != "2.0.0" {
+		t.Errorf("version() = %v, want 2.0.0", out)
+	}
+
+	hash, err := c.Transact(context.Background(), ContractPaymentRouter, "pause", nil)
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(sent) != 1 || sent[0].Hash() != hash {
+		t.Fatalf("Transact returned %s for %d sent transactions", hash.Hex(), len(sent))
+	}
+	if !bytes.Equal(sent[0].Data(), upgraded.Methods["pause"].ID) {
+		t.Errorf("calldata = %x, want pause()", sent[0].Data())
+	}
+	if to := sent[0].To(); to == nil || *to != testContracts.PaymentRouter {
+		t.Errorf("sent to %v, want the PaymentRouter", to)
+	}
+}
@@ -0,0 +1,81 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+// FeeStrategy prices EIP-1559 transactions
+type FeeStrategy interface {
+	// DynamicFees returns the maxFeePerGas and maxPriorityFeePerGas of a
+	// transaction given the latest base fee and the node's suggested tip
+	DynamicFees(baseFee, suggestedTip *big.Int) (feeCap, tip *big.Int)
+}
+
+// FeeStrategyFunc adapts a function to a FeeStrategy, for custom pricing
+type FeeStrategyFunc func(baseFee, suggestedTip *big.Int) (feeCap, tip *big.Int)
+
+// DynamicFees calls f
+func (f FeeStrategyFunc) DynamicFees(baseFee, suggestedTip *big.Int) (feeCap, tip *big.Int) {
+	return f(baseFee, suggestedTip)
+}
+
+// ScaledFeeStrategy pays TipPercent percent of the suggested tip and caps the
+// fee at BaseFeeMultiplier times the base fee plus the tip, so the
+// transaction stays includable while the base fee rises that far
+type ScaledFeeStrategy struct {
+	TipPercent        uint64
+	BaseFeeMultiplier uint64
+}
+
+// DynamicFees implements FeeStrategy
+func (s ScaledFeeStrategy) DynamicFees(baseFee, suggestedTip *big.Int) (feeCap, tip *big.Int) {
+	tip = new(big.Int).Mul(suggestedTip, new(big.Int).SetUint64(s.TipPercent))
+	tip.Quo(tip, big.NewInt(100))
+
+	feeCap = new(big.Int).Mul(baseFee, new(big.Int).SetUint64(max(s.BaseFeeMultiplier, 1)))
+	feeCap.Add(feeCap, tip)
+
+	return feeCap, tip
+}
+
+// Preset fee strategies. FeeStandard matches go-ethereum's own defaults.
+var (
+	// FeeSlow underpays the tip and is only included while the base fee
+	// does not rise
+	FeeSlow FeeStrategy = ScaledFeeStrategy{TipPercent: 80, BaseFeeMultiplier: 1}
+	// FeeStandard pays the suggested tip and tolerates the base fee
+	// doubling
+	FeeStandard FeeStrategy = ScaledFeeStrategy{TipPercent: 100, BaseFeeMultiplier: 2}
+	// FeeFast doubles the suggested tip and tolerates the base fee tripling
+	FeeFast FeeStrategy = ScaledFeeStrategy{TipPercent: 200, BaseFeeMultiplier: 3}
+)
+
+// suggestDynamicFees prices a transaction with strategy, or Config.FeeStrategy
+// if nil. ok is false if the chain has no base fee and needs a legacy gas
+// price instead.
+func (c *Client) suggestDynamicFees(ctx context.Context, strategy FeeStrategy) (feeCap, tip *big.Int, ok bool, err error) {
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get latest header: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, false, nil
+	}
+
+	suggestedTip, err := c.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+
+	if strategy == nil {
+		strategy = c.config.FeeStrategy
+	}
+	if strategy == nil {
+		strategy = FeeStandard
+	}
+
+	feeCap, tip = strategy.DynamicFees(head.BaseFee, suggestedTip)
+	return feeCap, tip, true, nil
+}
@@ -0,0 +1,230 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// EIP-712 domain used for off-chain protocol signatures. No protocol contract
+// verifies signatures in this domain, so it is only checked off-chain.
+const (
+	eip712DomainName    = "SYNAPSE Protocol"
+	eip712DomainVersion = "1"
+)
+
+var (
+	eip712DomainTypeHash    = crypto.Keccak256Hash([]byte("EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	quoteAcceptanceTypeHash = crypto.Keccak256Hash([]byte("QuoteAcceptance(bytes32 quoteId,uint256 maxPrice,uint256 deadline)"))
+)
+
+// eip712DomainSeparator computes the EIP-712 domain separator for a contract
+func eip712DomainSeparator(chainID *big.Int, verifyingContract common.Address) common.Hash {
+	return crypto.Keccak256Hash(
+		eip712DomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(eip712DomainName)),
+		crypto.Keccak256([]byte(eip712DomainVersion)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(verifyingContract.Bytes(), 32),
+	)
+}
+
+// eip712Digest combines a domain separator and struct hash into the digest
+// that is signed
+func eip712Digest(domainSeparator, structHash common.Hash) []byte {
+	return crypto.Keccak256([]byte("\x19\x01"), domainSeparator.Bytes(), structHash.Bytes())
+}
+
+// quoteAcceptanceDigest returns the EIP-712 digest of a QuoteAcceptance
+func (c *Client) quoteAcceptanceDigest(quoteID [32]byte, maxPrice *big.Int, deadline uint64) []byte {
+	structHash := crypto.Keccak256Hash(
+		quoteAcceptanceTypeHash.Bytes(),
+		quoteID[:],
+		common.LeftPadBytes(maxPrice.Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(deadline).Bytes(), 32),
+	)
+
+	return eip712Digest(eip712DomainSeparator(c.chainID, c.config.Contracts.ServiceRegistry), structHash)
+}
+
+// SignQuoteAcceptance signs an EIP-712 QuoteAcceptance committing the client to
+// pay at most maxPrice for a quote before deadline. The signature is bound to
+// the ServiceRegistry contract and chain the client is configured for, in the
+// "SYNAPSE Protocol" version "1" domain.
+//
+// The signature is an off-chain commitment for providers to check with
+// VerifyQuoteAcceptance before reserving resources. The ServiceRegistry does
+// not implement EIP-712 and cannot verify it; the quote is still accepted
+// on-chain with AcceptQuote.
+func (c *Client) SignQuoteAcceptance(quoteID [32]byte, maxPrice *big.Int, deadline uint64) ([]byte, error) {
+	if err := requireAmount("maxPrice", maxPrice); err != nil {
+		return nil, err
+	}
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+
+	signature, err := c.signHash(c.quoteAcceptanceDigest(quoteID, maxPrice, deadline))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign quote acceptance: %w", err)
+	}
+
+	// Use the 27/28 recovery ID expected by ecrecover
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+// VerifyQuoteAcceptance recovers the signer of a QuoteAcceptance signature
+// made with SignQuoteAcceptance
+func (c *Client) VerifyQuoteAcceptance(quoteID [32]byte, maxPrice *big.Int, deadline uint64, signature []byte) (common.Address, error) {
+	if err := requireAmount("maxPrice", maxPrice); err != nil {
+		return common.Address{}, err
+	}
+
+	return recoverSigner(c.quoteAcceptanceDigest(quoteID, maxPrice, deadline), signature)
+}
+
+// recoverSigner recovers the address that produced a signature over a digest,
+// accepting both 0/1 and 27/28 recovery IDs
+func recoverSigner(digest, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length: %d", len(signature))
+	}
+
+	sig := make([]byte, len(signature))
+	copy(sig, signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(digest, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover signer: %w", err)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
+// eip712DomainABI covers the two ways a contract exposes its EIP-712 domain:
+// the EIP-5267 eip712Domain() fields and a stored DOMAIN_SEPARATOR()
+var eip712DomainABI = mustParseABI(`[
+	{"type":"function","name":"eip712Domain","stateMutability":"view","inputs":[],"outputs":[
+		{"name":"fields","type":"bytes1"},
+		{"name":"name","type":"string"},
+		{"name":"version","type":"string"},
+		{"name":"chainId","type":"uint256"},
+		{"name":"verifyingContract","type":"address"},
+		{"name":"salt","type":"bytes32"},
+		{"name":"extensions","type":"uint256[]"}
+	]},
+	{"type":"function","name":"DOMAIN_SEPARATOR","stateMutability":"view","inputs":[],"outputs":[
+		{"name":"","type":"bytes32"}
+	]}
+]`)
+
+// DomainSeparator returns the EIP-712 domain separator the SDK signs with for
+// a contract, the "SYNAPSE Protocol" version "1" domain on the client's chain,
+// after checking it against the contract's own. The contract's separator is
+// computed from the fields it reports through EIP-5267 eip712Domain() and
+// read from DOMAIN_SEPARATOR(); any of them that differs from the SDK's
+// returns ErrDomainSeparatorMismatch. A contract exposing neither cannot be
+// checked, and the SDK's separator is returned with ErrNoDomainSeparator.
+// None of the current protocol contracts expose a domain.
+func (c *Client) DomainSeparator(ctx context.Context, contract common.Address) ([32]byte, error) {
+	ctx, cancel := c.withTimeout(ctx, timeoutRead)
+	defer cancel()
+
+	computed := eip712DomainSeparator(c.chainID, contract)
+	checked := false
+	for _, method := range []string{"eip712Domain", "DOMAIN_SEPARATOR"} {
+		onChain, exposed, err := c.callDomainSeparator(ctx, contract, method)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		if !exposed {
+			continue
+		}
+		if onChain != computed {
+			return [32]byte{}, fmt.Errorf("%w: contract %s %s gives %s, the SDK signs with %s", ErrDomainSeparatorMismatch, contract.Hex(), method, onChain.Hex(), computed.Hex())
+		}
+		checked = true
+	}
+
+	if !checked {
+		return computed, fmt.Errorf("%w: contract %s", ErrNoDomainSeparator, contract.Hex())
+	}
+	return computed, nil
+}
+
+// callDomainSeparator calls eip712Domain or DOMAIN_SEPARATOR on a contract
+// and returns the separator it gives. A contract without the method reports
+// false rather than an error.
+func (c *Client) callDomainSeparator(ctx context.Context, contract common.Address, method string) (common.Hash, bool, error) {
+	data, err := eip712DomainABI.Pack(method)
+	if err != nil {
+		return common.Hash{}, false, err
+	}
+	out, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil {
+		if isMissingMethod(err) {
+			return common.Hash{}, false, nil
+		}
+		return common.Hash{}, false, fmt.Errorf("failed to call %s: %w", method, c.decodeRevert(err))
+	}
+	if len(out) == 0 {
+		// An account without code returns nothing
+		return common.Hash{}, false, nil
+	}
+
+	values, err := eip712DomainABI.Unpack(method, out)
+	if err != nil {
+		return common.Hash{}, false, fmt.Errorf("failed to unpack %s: %w", method, err)
+	}
+	if method == "DOMAIN_SEPARATOR" {
+		return common.Hash(values[0].([32]byte)), true, nil
+	}
+
+	return hashEIP712Domain(
+		values[0].([1]byte)[0],
+		values[1].(string),
+		values[2].(string),
+		values[3].(*big.Int),
+		values[4].(common.Address),
+		values[5].([32]byte),
+	), true, nil
+}
+
+// hashEIP712Domain computes the separator of an EIP-5267 domain, including
+// only the members the fields bitmap marks as used
+func hashEIP712Domain(fields byte, name, version string, chainID *big.Int, verifyingContract common.Address, salt [32]byte) common.Hash {
+	var members []string
+	var encoded [][]byte
+	if fields&0x01 != 0 {
+		members = append(members, "string name")
+		encoded = append(encoded, crypto.Keccak256([]byte(name)))
+	}
+	if fields&0x02 != 0 {
+		members = append(members, "string version")
+		encoded = append(encoded, crypto.Keccak256([]byte(version)))
+	}
+	if fields&0x04 != 0 {
+		members = append(members, "uint256 chainId")
+		encoded = append(encoded, common.LeftPadBytes(chainID.Bytes(), 32))
+	}
+	if fields&0x08 != 0 {
+		members = append(members, "address verifyingContract")
+		encoded = append(encoded, common.LeftPadBytes(verifyingContract.Bytes(), 32))
+	}
+	if fields&0x10 != 0 {
+		members = append(members, "bytes32 salt")
+		encoded = append(encoded, salt[:])
+	}
+
+	typeHash := crypto.Keccak256([]byte("EIP712Domain(" + strings.Join(members, ",") + ")"))
+	return crypto.Keccak256Hash(append([][]byte{typeHash}, encoded...)...)
+}
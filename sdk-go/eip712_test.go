@@ -0,0 +1,121 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestDomainSeparator(t *testing.T) {
+	contract := testContracts.ServiceRegistry
+	chainID := big.NewInt(1337)
+	sdk := eip712DomainSeparator(chainID, contract)
+	domain := func(name string, chainID int64) []interface{} {
+		return []interface{}{[1]byte{0x0f}, name, eip712DomainVersion, big.NewInt(chainID), contract, [32]byte{}, []*big.Int{}}
+	}
+
+	tests := []struct {
+		name    string
+		domain  []interface{}
+		stored  *common.Hash
+		wantErr error
+	}{
+		{"computed and on-chain agree", domain(eip712DomainName, 1337), &sdk, nil},
+		{"eip712Domain only", domain(eip712DomainName, 1337), nil, nil},
+		{"DOMAIN_SEPARATOR only", nil, &sdk, nil},
+		{"stored separator differs", domain(eip712DomainName, 1337), &common.Hash{1}, ErrDomainSeparatorMismatch},
+		{"other name", domain("Synapse Token", 1337), nil, ErrDomainSeparatorMismatch},
+		{"other chain", domain(eip712DomainName, 1), nil, ErrDomainSeparatorMismatch},
+		{"neither exposed", nil, nil, ErrNoDomainSeparator},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			if tt.domain != nil {
+				backend.returns(contract, eip712DomainABI, "eip712Domain", tt.domain...)
+			}
+			if tt.stored != nil {
+				backend.returns(contract, eip712DomainABI, "DOMAIN_SEPARATOR", [32]byte(*tt.stored))
+			}
+
+			got, err := c.DomainSeparator(context.Background(), contract)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DomainSeparator error = %v, want %v", err, tt.wantErr)
+			}
+			// The SDK's separator comes back unless it mismatches, unchecked
+			// for a contract without a domain
+			if tt.wantErr != ErrDomainSeparatorMismatch && common.Hash(got) != sdk {
+				t.Errorf("DomainSeparator = %x, want %x", got, sdk)
+			}
+		})
+	}
+}
+
+func TestHashEIP712DomainHonoursFields(t *testing.T) {
+	// A domain of only a name and chain ID
+	got := hashEIP712Domain(0x05, "Token", "ignored", big.NewInt(1), common.Address{1}, [32]byte{1})
+	full := hashEIP712Domain(0x0f, "Token", "ignored", big.NewInt(1), common.Address{1}, [32]byte{1})
+	if got == full {
+		t.Fatal("separator ignores the fields bitmap")
+	}
+	if again := hashEIP712Domain(0x05, "Token", "other", big.NewInt(1), common.Address{2}, [32]byte{2}); again != got {
+		t.Errorf("separator = %x, want %x when only unused members differ", again, got)
+	}
+}
+
+func TestQuoteAcceptanceSignature(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	quoteID, maxPrice, deadline := [32]byte{1}, big.NewInt(500), uint64(1_700_000_000)
+
+	signature, err := c.SignQuoteAcceptance(quoteID, maxPrice, deadline)
+	if err != nil {
+		t.Fatalf("SignQuoteAcceptance: %v", err)
+	}
+
+	signer, err := c.VerifyQuoteAcceptance(quoteID, maxPrice, deadline, signature)
+	if err != nil {
+		t.Fatalf("VerifyQuoteAcceptance: %v", err)
+	}
+	if signer != c.Address() {
+		t.Errorf("recovered %s, want %s", signer.Hex(), c.Address().Hex())
+	}
+
+	// The signature does not carry over to other terms, another registry or
+	// another chain
+	otherContracts := testContracts
+	otherContracts.ServiceRegistry = testAddress(9)
+	otherRegistry := newTestClient(t, backend, Config{Contracts: otherContracts})
+	otherBackend := newMockBackend()
+	otherBackend.chainID = big.NewInt(1)
+	otherChain := newTestClient(t, otherBackend, Config{})
+
+	tests := []struct {
+		name     string
+		verifier *Client
+		maxPrice *big.Int
+	}{
+		{"higher max price", c, big.NewInt(501)},
+		{"other registry", otherRegistry, maxPrice},
+		{"other chain", otherChain, maxPrice},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer, err := tt.verifier.VerifyQuoteAcceptance(quoteID, tt.maxPrice, deadline, signature)
+			if err == nil && signer == c.Address() {
+				t.Error("signature verified outside the terms and domain it was made for")
+			}
+		})
+	}
+
+	if _, err := c.SignQuoteAcceptance(quoteID, nil, deadline); !errors.Is(err, ErrZeroAmount) {
+		t.Errorf("SignQuoteAcceptance with a nil maxPrice: err = %v, want ErrZeroAmount", err)
+	}
+	if _, err := c.VerifyQuoteAcceptance(quoteID, nil, deadline, signature); !errors.Is(err, ErrZeroAmount) {
+		t.Errorf("VerifyQuoteAcceptance with a nil maxPrice: err = %v, want ErrZeroAmount", err)
+	}
+}
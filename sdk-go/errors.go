@@ -0,0 +1,125 @@
+package synapse
+
+import "errors"
+
+var (
+	// ErrInsufficientChannelCapacity is returned when open channels cannot cover a payment
+	ErrInsufficientChannelCapacity = errors.New("insufficient channel capacity")
+
+	// ErrNoChannelStateStore is returned by methods that build on earlier channel states when Config.ChannelStates is not set
+	ErrNoChannelStateStore = errors.New("no channel state store configured")
+
+	// ErrInsufficientGasFunds is returned when the wallet cannot pay for gas
+	ErrInsufficientGasFunds = errors.New("insufficient native balance for gas")
+
+	// ErrChannelNonceOverflow is returned when a channel nonce no longer fits in a uint64
+	ErrChannelNonceOverflow = errors.New("channel nonce overflow")
+
+	// ErrMetadataTooLarge is returned when payment metadata exceeds Config.MaxMetadataBytes
+	ErrMetadataTooLarge = errors.New("metadata too large")
+
+	// ErrChainIDChanged is returned when the node reports a different chain ID than at connect time
+	ErrChainIDChanged = errors.New("chain ID changed")
+
+	// ErrFeeExceedsMax is returned when the protocol fee exceeds the caller's tolerance
+	ErrFeeExceedsMax = errors.New("protocol fee exceeds maximum")
+
+	// ErrMaxFeeUnsupported is returned when WithMaxFee or WithMaxFeeBps is passed to a method that does not enforce them
+	ErrMaxFeeUnsupported = errors.New("max fee not supported")
+
+	// ErrReorgDetected is returned when a transaction moved to a different block while waiting for confirmations
+	ErrReorgDetected = errors.New("transaction reorganized")
+
+	// ErrServiceAlreadyExists is returned by RegisterService when the provider already has an active service with the same name and category
+	ErrServiceAlreadyExists = errors.New("service already exists")
+
+	// ErrUnknownNetwork is returned when the connected chain ID is not in the network registry
+	ErrUnknownNetwork = errors.New("unknown network")
+
+	// ErrNotStreamSender is returned when a stream operation reserved for the sender is attempted by another account
+	ErrNotStreamSender = errors.New("not the stream sender")
+
+	// ErrZeroAmount is returned by write methods given a zero amount where it would be a no-op
+	ErrZeroAmount = errors.New("amount must be positive")
+
+	// ErrQuoteValidityTooShort is returned when a quote expires sooner than the requested minimum validity
+	ErrQuoteValidityTooShort = errors.New("quote validity too short")
+
+	// ErrPaymentNotFound is returned when the PaymentRouter has no payment with an ID
+	ErrPaymentNotFound = errors.New("payment not found")
+
+	// ErrInvalidRecipient is returned for a zero recipient or one that is the sending account itself
+	ErrInvalidRecipient = errors.New("invalid recipient")
+
+	// ErrDomainSeparatorMismatch is returned when a contract's EIP-712 domain separator differs from the one the SDK signs with
+	ErrDomainSeparatorMismatch = errors.New("domain separator mismatch")
+
+	// ErrNoDomainSeparator is returned for a contract exposing neither eip712Domain() nor DOMAIN_SEPARATOR()
+	ErrNoDomainSeparator = errors.New("contract exposes no EIP-712 domain")
+
+	// ErrStaleChannelState is returned for a channel state whose nonce is not above the last accepted one
+	ErrStaleChannelState = errors.New("stale channel state")
+
+	// ErrInvalidChannelSignature is returned when a channel state is not signed by the expected party
+	ErrInvalidChannelSignature = errors.New("invalid channel state signature")
+
+	// ErrStateSumMismatch is returned when a channel state's balances do not add up to the channel's deposits
+	ErrStateSumMismatch = errors.New("channel state balances do not match deposits")
+
+	// ErrInvalidEndpoint is returned for a service endpoint that is not an absolute URL with an allowed scheme
+	ErrInvalidEndpoint = errors.New("invalid service endpoint")
+
+	// ErrReadOnly is returned by methods that sign or submit transactions on a client created without a private key
+	ErrReadOnly = errors.New("client is read-only")
+
+	// ErrUnknownAccount is returned when WithFrom names an account the client has no signer for
+	ErrUnknownAccount = errors.New("unknown account")
+
+	// ErrTxFailed is returned when a submitted transaction is mined but reverts
+	ErrTxFailed = errors.New("transaction failed")
+
+	// ErrInsufficientBalance is returned for failures caused by a token balance too low for a transfer, payment or stream
+	ErrInsufficientBalance = errors.New("insufficient balance")
+
+	// ErrInsufficientAllowance is returned for failures caused by a token allowance too low for a transfer
+	ErrInsufficientAllowance = errors.New("insufficient allowance")
+
+	// ErrChannelNotFound is returned for operations on a payment channel that does not exist
+	ErrChannelNotFound = errors.New("channel not found")
+
+	// ErrChannelNotOpen is returned for operations that need an open payment channel
+	ErrChannelNotOpen = errors.New("channel not open")
+
+	// ErrChannelNotClosing is returned for operations that need a payment channel in its challenge period
+	ErrChannelNotClosing = errors.New("channel not closing")
+
+	// ErrNotRegistered is returned for operations that need a registered agent
+	ErrNotRegistered = errors.New("agent not registered")
+
+	// ErrAlreadyRegistered is returned for registering an agent twice
+	ErrAlreadyRegistered = errors.New("agent already registered")
+
+	// ErrInsufficientStake is returned for failures caused by a stake below the tier's requirement
+	ErrInsufficientStake = errors.New("insufficient stake")
+
+	// ErrServiceNotFound is returned for operations on a service that does not exist
+	ErrServiceNotFound = errors.New("service not found")
+
+	// ErrServiceNotActive is returned for operations on a deactivated service
+	ErrServiceNotActive = errors.New("service not active")
+
+	// ErrQuoteExpired is returned for accepting an expired quote
+	ErrQuoteExpired = errors.New("quote expired")
+
+	// ErrDeadlineExpired is returned for escrow and payment operations past their deadline
+	ErrDeadlineExpired = errors.New("deadline expired")
+
+	// ErrStreamNotActive is returned for operations on a cancelled or finished stream
+	ErrStreamNotActive = errors.New("stream not active")
+
+	// ErrInvalidSignature is returned for failures caused by a signature the contract rejects
+	ErrInvalidSignature = errors.New("invalid signature")
+
+	// ErrUnauthorized is returned for operations the sender is not allowed to perform
+	ErrUnauthorized = errors.New("unauthorized")
+)
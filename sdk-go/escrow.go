@@ -0,0 +1,114 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// EscrowStatus represents escrow status
+type EscrowStatus uint8
+
+const (
+	EscrowActive EscrowStatus = iota
+	EscrowReleased
+	EscrowRefunded
+	EscrowDisputed
+)
+
+// EscrowRole is an address's role in an escrow
+type EscrowRole uint8
+
+const (
+	EscrowRoleNone EscrowRole = iota
+	EscrowRolePayer
+	EscrowRoleRecipient
+	EscrowRoleArbiter
+)
+
+// EscrowInfo represents an escrow payment
+type EscrowInfo struct {
+	EscrowID  [32]byte
+	Sender    common.Address
+	Recipient common.Address
+	Arbiter   common.Address
+	Amount    *big.Int
+	Fee       *big.Int
+	Deadline  uint64
+	Status    EscrowStatus
+	Role      EscrowRole
+}
+
+// GetEscrow returns escrow information
+func (c *Client) GetEscrow(ctx context.Context, escrowID [32]byte) (*EscrowInfo, error) {
+	out, err := c.callContract(ctx, ContractPaymentRouter, "getEscrow", escrowID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *abi.ConvertType(out[0], new(escrowData)).(*escrowData)
+
+	return &EscrowInfo{
+		EscrowID:  data.EscrowId,
+		Sender:    data.Sender,
+		Recipient: data.Recipient,
+		Arbiter:   data.Arbiter,
+		Amount:    data.Amount,
+		Fee:       data.Fee,
+		Deadline:  data.Deadline.Uint64(),
+		Status:    EscrowStatus(data.Status),
+	}, nil
+}
+
+// GetEscrows returns the active escrows an address is involved in as payer,
+// recipient or arbiter. The arbiter is not indexed in EscrowCreated, so every
+// escrow since Config.StartBlock is inspected.
+func (c *Client) GetEscrows(ctx context.Context, address common.Address) ([]EscrowInfo, error) {
+	router, err := c.contractAddress(ContractPaymentRouter)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["EscrowCreated"]
+	logs, err := c.filterLogsChunked(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{router},
+		Topics:    [][]common.Hash{{event.ID}},
+	}, c.config.StartBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var escrows []EscrowInfo
+	for _, log := range logs {
+		fields, err := decodeEvent(event, log)
+		if err != nil {
+			return nil, err
+		}
+
+		escrow, err := c.GetEscrow(ctx, fields["escrowId"].([32]byte))
+		if err != nil {
+			return nil, err
+		}
+		if escrow.Status != EscrowActive {
+			continue
+		}
+
+		switch address {
+		case escrow.Sender:
+			escrow.Role = EscrowRolePayer
+		case escrow.Recipient:
+			escrow.Role = EscrowRoleRecipient
+		case escrow.Arbiter:
+			escrow.Role = EscrowRoleArbiter
+		default:
+			continue
+		}
+
+		escrows = append(escrows, *escrow)
+	}
+
+	return escrows, nil
+}
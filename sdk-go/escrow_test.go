@@ -0,0 +1,77 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// withEscrows makes the PaymentRouter mock serve escrows by ID and logs
+// their creation
+func withEscrows(backend *mockBackend, escrows ...escrowData) {
+	byID := make(map[[32]byte]escrowData)
+	for _, escrow := range escrows {
+		byID[escrow.EscrowId] = escrow
+		backend.addLog(*eventLog(testContracts.PaymentRouter, paymentRouterABI, "EscrowCreated",
+			[]common.Hash{escrow.EscrowId, common.BytesToHash(escrow.Sender.Bytes()), common.BytesToHash(escrow.Recipient.Bytes())},
+			escrow.Amount, escrow.Deadline,
+		))
+	}
+
+	backend.handle(testContracts.PaymentRouter, paymentRouterABI, "getEscrow", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		return []interface{}{byID[args[0].([32]byte)]}, nil
+	})
+}
+
+// testEscrow returns an active escrow of 1000
+func testEscrow(id byte, sender, recipient, arbiter common.Address) escrowData {
+	return escrowData{
+		EscrowId:  [32]byte{id},
+		Sender:    sender,
+		Recipient: recipient,
+		Arbiter:   arbiter,
+		Amount:    big.NewInt(1000),
+		Fee:       big.NewInt(10),
+		Deadline:  big.NewInt(1_700_086_400),
+		Status:    uint8(EscrowActive),
+	}
+}
+
+func TestGetEscrows(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	me, a, b := c.Address(), testAddress(1), testAddress(2)
+	released := testEscrow(5, me, a, b)
+	released.Status = uint8(EscrowReleased)
+	withEscrows(backend,
+		testEscrow(1, me, a, b),
+		testEscrow(2, a, me, b),
+		testEscrow(3, a, b, me),
+		testEscrow(4, a, b, a),
+		released,
+	)
+
+	escrows, err := c.GetEscrows(context.Background(), me)
+	if err != nil {
+		t.Fatalf("GetEscrows: %v", err)
+	}
+
+	want := []struct {
+		id   byte
+		role EscrowRole
+	}{
+		{1, EscrowRolePayer},
+		{2, EscrowRoleRecipient},
+		{3, EscrowRoleArbiter},
+	}
+	if len(escrows) != len(want) {
+		t.Fatalf("GetEscrows returned %d escrows, want the %d active ones involving the address", len(escrows), len(want))
+	}
+	for i, w := range want {
+		if escrows[i].EscrowID != [32]byte{w.id} || escrows[i].Role != w.role {
+			t.Errorf("escrow %d = %x role %d, want %x role %d", i, escrows[i].EscrowID, escrows[i].Role, [32]byte{w.id}, w.role)
+		}
+	}
+}
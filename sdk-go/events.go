@@ -0,0 +1,432 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// logScanChunkSize is the number of blocks requested per FilterLogs call, to
+// stay within the range limits most RPC providers enforce
+const logScanChunkSize = 10000
+
+// filterLogsChunked runs a log query over [fromBlock, latest] in chunks.
+// The FromBlock and ToBlock of the query are ignored.
+func (c *Client) filterLogsChunked(ctx context.Context, query ethereum.FilterQuery, fromBlock uint64) ([]types.Log, error) {
+	latest, err := c.client.BlockNumber(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block number: %w", err)
+	}
+
+	var logs []types.Log
+	for start := fromBlock; start <= latest; start += logScanChunkSize {
+		end := start + logScanChunkSize - 1
+		if end > latest {
+			end = latest
+		}
+
+		query.FromBlock = new(big.Int).SetUint64(start)
+		query.ToBlock = new(big.Int).SetUint64(end)
+
+		chunk, err := c.client.FilterLogs(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter logs in blocks %d-%d: %w", start, end, err)
+		}
+		logs = append(logs, chunk...)
+	}
+
+	return logs, nil
+}
+
+// decodeEvent decodes a log into a map keyed by argument name. Indexed
+// arguments are read from Topics[1:] and the rest from Data; indexed dynamic
+// types (string, bytes, arrays) decode to their keccak256 hash.
+func decodeEvent(event abi.Event, log types.Log) (map[string]interface{}, error) {
+	topics := log.Topics
+	if !event.Anonymous {
+		if len(topics) == 0 || topics[0] != event.ID {
+			return nil, fmt.Errorf("log is not a %s event", event.Name)
+		}
+		topics = topics[1:]
+	}
+
+	var indexed abi.Arguments
+	for _, input := range event.Inputs {
+		if input.Indexed {
+			indexed = append(indexed, input)
+		}
+	}
+	if len(topics) != len(indexed) {
+		return nil, fmt.Errorf("%s event has %d indexed topics, want %d", event.Name, len(topics), len(indexed))
+	}
+
+	fields := make(map[string]interface{})
+	if err := event.Inputs.NonIndexed().UnpackIntoMap(fields, log.Data); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event data: %w", event.Name, err)
+	}
+	if err := abi.ParseTopicsIntoMap(fields, indexed, topics); err != nil {
+		return nil, fmt.Errorf("failed to decode %s event topics: %w", event.Name, err)
+	}
+
+	return fields, nil
+}
+
+// EventKind identifies a protocol event streamed by SubscribeEvents
+type EventKind uint8
+
+const (
+	// EventPaymentSent is PaymentRouter.PaymentExecuted, streamed as PaymentEvent
+	EventPaymentSent EventKind = iota + 1
+	// EventEscrowCreated is PaymentRouter.EscrowCreated
+	EventEscrowCreated
+	// EventStreamCreated is PaymentRouter.StreamCreated
+	EventStreamCreated
+	// EventChannelOpened is PaymentChannel.ChannelOpened
+	EventChannelOpened
+	// EventAgentRegistered is ReputationRegistry.AgentRegistered
+	EventAgentRegistered
+	// EventServiceRegistered is ServiceRegistry.ServiceRegistered
+	EventServiceRegistered
+)
+
+// protocolEventSources maps each event kind to its contract and event name
+var protocolEventSources = map[EventKind]struct{ contract, event string }{
+	EventPaymentSent:       {ContractPaymentRouter, "PaymentExecuted"},
+	EventEscrowCreated:     {ContractPaymentRouter, "EscrowCreated"},
+	EventStreamCreated:     {ContractPaymentRouter, "StreamCreated"},
+	EventChannelOpened:     {ContractPaymentChannel, "ChannelOpened"},
+	EventAgentRegistered:   {ContractReputation, "AgentRegistered"},
+	EventServiceRegistered: {ContractServiceRegistry, "ServiceRegistered"},
+}
+
+// ProtocolEvent is an event streamed by SubscribeEvents: a PaymentEvent,
+// EscrowCreatedEvent, StreamCreatedEvent, ChannelOpenedEvent,
+// AgentRegisteredEvent or ServiceRegisteredEvent
+type ProtocolEvent interface {
+	// Kind returns the kind of the event
+	Kind() EventKind
+	// RawLog returns the log the event was decoded from
+	RawLog() types.Log
+	// involves reports whether an address takes part in the event
+	involves(address common.Address) bool
+}
+
+// EscrowCreatedEvent is an escrow created in the PaymentRouter
+type EscrowCreatedEvent struct {
+	EscrowID  [32]byte
+	Sender    common.Address
+	Recipient common.Address
+	Amount    *big.Int
+	Deadline  uint64
+	Log       types.Log
+}
+
+// StreamCreatedEvent is a payment stream created in the PaymentRouter
+type StreamCreatedEvent struct {
+	StreamID    [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	TotalAmount *big.Int
+	Duration    uint64
+	Log         types.Log
+}
+
+// ChannelOpenedEvent is a channel opened in the PaymentChannel contract
+type ChannelOpenedEvent struct {
+	ChannelID    [32]byte
+	Participant1 common.Address
+	Participant2 common.Address
+	Deposit1     *big.Int
+	Deposit2     *big.Int
+	Log          types.Log
+}
+
+// AgentRegisteredEvent is an agent registered in the ReputationRegistry
+type AgentRegisteredEvent struct {
+	Agent       common.Address
+	AgentID     [32]byte
+	Stake       *big.Int
+	MetadataURI string
+	Log         types.Log
+}
+
+// ServiceRegisteredEvent is a service registered in the ServiceRegistry
+type ServiceRegisteredEvent struct {
+	ServiceID [32]byte
+	Provider  common.Address
+	Category  string
+	Name      string
+	BasePrice *big.Int
+	Log       types.Log
+}
+
+// Kind returns EventPaymentSent
+func (e PaymentEvent) Kind() EventKind {
+	return EventPaymentSent
+}
+
+// RawLog returns the log the event was decoded from
+func (e PaymentEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e PaymentEvent) involves(a common.Address) bool {
+	return a == e.Sender || a == e.Recipient
+}
+
+// Kind returns EventEscrowCreated
+func (e EscrowCreatedEvent) Kind() EventKind {
+	return EventEscrowCreated
+}
+
+// RawLog returns the log the event was decoded from
+func (e EscrowCreatedEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e EscrowCreatedEvent) involves(a common.Address) bool {
+	return a == e.Sender || a == e.Recipient
+}
+
+// Kind returns EventStreamCreated
+func (e StreamCreatedEvent) Kind() EventKind {
+	return EventStreamCreated
+}
+
+// RawLog returns the log the event was decoded from
+func (e StreamCreatedEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e StreamCreatedEvent) involves(a common.Address) bool {
+	return a == e.Sender || a == e.Recipient
+}
+
+// Kind returns EventChannelOpened
+func (e ChannelOpenedEvent) Kind() EventKind {
+	return EventChannelOpened
+}
+
+// RawLog returns the log the event was decoded from
+func (e ChannelOpenedEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e ChannelOpenedEvent) involves(a common.Address) bool {
+	return a == e.Participant1 || a == e.Participant2
+}
+
+// Kind returns EventAgentRegistered
+func (e AgentRegisteredEvent) Kind() EventKind {
+	return EventAgentRegistered
+}
+
+// RawLog returns the log the event was decoded from
+func (e AgentRegisteredEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e AgentRegisteredEvent) involves(a common.Address) bool {
+	return a == e.Agent
+}
+
+// Kind returns EventServiceRegistered
+func (e ServiceRegisteredEvent) Kind() EventKind {
+	return EventServiceRegistered
+}
+
+// RawLog returns the log the event was decoded from
+func (e ServiceRegisteredEvent) RawLog() types.Log {
+	return e.Log
+}
+
+func (e ServiceRegisteredEvent) involves(a common.Address) bool {
+	return a == e.Provider
+}
+
+// decodeProtocolEvent decodes a log of the given kind into its typed event
+func decodeProtocolEvent(kind EventKind, event abi.Event, log types.Log) (ProtocolEvent, error) {
+	fields, err := decodeEvent(event, log)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case EventPaymentSent:
+		return PaymentEvent{
+			PaymentID:   fields["paymentId"].([32]byte),
+			Sender:      fields["sender"].(common.Address),
+			Recipient:   fields["recipient"].(common.Address),
+			Amount:      fields["amount"].(*big.Int),
+			Fee:         fields["fee"].(*big.Int),
+			ServiceType: fields["serviceType"].([32]byte),
+			Log:         log,
+		}, nil
+	case EventEscrowCreated:
+		return EscrowCreatedEvent{
+			EscrowID:  fields["escrowId"].([32]byte),
+			Sender:    fields["sender"].(common.Address),
+			Recipient: fields["recipient"].(common.Address),
+			Amount:    fields["amount"].(*big.Int),
+			Deadline:  fields["deadline"].(*big.Int).Uint64(),
+			Log:       log,
+		}, nil
+	case EventStreamCreated:
+		return StreamCreatedEvent{
+			StreamID:    fields["streamId"].([32]byte),
+			Sender:      fields["sender"].(common.Address),
+			Recipient:   fields["recipient"].(common.Address),
+			TotalAmount: fields["totalAmount"].(*big.Int),
+			Duration:    fields["duration"].(*big.Int).Uint64(),
+			Log:         log,
+		}, nil
+	case EventChannelOpened:
+		return ChannelOpenedEvent{
+			ChannelID:    fields["channelId"].([32]byte),
+			Participant1: fields["partyA"].(common.Address),
+			Participant2: fields["partyB"].(common.Address),
+			Deposit1:     fields["depositA"].(*big.Int),
+			Deposit2:     fields["depositB"].(*big.Int),
+			Log:          log,
+		}, nil
+	case EventAgentRegistered:
+		return AgentRegisteredEvent{
+			Agent:       fields["agent"].(common.Address),
+			AgentID:     fields["agentId"].([32]byte),
+			Stake:       fields["stake"].(*big.Int),
+			MetadataURI: fields["metadataURI"].(string),
+			Log:         log,
+		}, nil
+	case EventServiceRegistered:
+		return ServiceRegisteredEvent{
+			ServiceID: fields["serviceId"].([32]byte),
+			Provider:  fields["provider"].(common.Address),
+			Category:  categoryName(fields["category"].([32]byte)),
+			Name:      fields["name"].(string),
+			BasePrice: fields["basePrice"].(*big.Int),
+			Log:       log,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event kind %d", kind)
+}
+
+// EventFilter selects the events streamed by SubscribeEvents
+type EventFilter struct {
+	// Kinds lists the event kinds to stream. Empty means all of them.
+	Kinds []EventKind
+
+	// Participants, if set, keeps only events in which one of these
+	// addresses is a sender, recipient, channel party, agent or provider
+	Participants []common.Address
+}
+
+// SubscribeEvents streams protocol events matching filter until ctx is
+// cancelled, resubscribing if the connection drops. Logs removed by a reorg
+// are skipped. Switch on the type of each event, or on its Kind, to handle
+// it. Events of contracts without a configured address are left out. Both
+// channels are closed when the stream ends.
+func (c *Client) SubscribeEvents(ctx context.Context, filter EventFilter) (<-chan ProtocolEvent, <-chan error, error) {
+	kinds := filter.Kinds
+	if len(kinds) == 0 {
+		for kind := EventPaymentSent; kind <= EventServiceRegistered; kind++ {
+			kinds = append(kinds, kind)
+		}
+	}
+
+	type source struct {
+		kind    EventKind
+		event   abi.Event
+		address common.Address
+	}
+	sources := make(map[common.Hash]source)
+	var addresses []common.Address
+	var ids []common.Hash
+	for _, kind := range kinds {
+		s, ok := protocolEventSources[kind]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown event kind %d", kind)
+		}
+
+		address, err := c.contractAddress(s.contract)
+		if err != nil {
+			if len(filter.Kinds) == 0 {
+				continue
+			}
+			return nil, nil, err
+		}
+
+		event := c.contractABI(s.contract).Events[s.event]
+		if _, seen := sources[event.ID]; !seen {
+			sources[event.ID] = source{kind, event, address}
+			ids = append(ids, event.ID)
+		}
+		if !slices.Contains(addresses, address) {
+			addresses = append(addresses, address)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil, fmt.Errorf("no protocol contracts configured")
+	}
+
+	logs := make(chan types.Log)
+	sub, err := c.SubscribeLogs(ctx, ethereum.FilterQuery{
+		Addresses: addresses,
+		Topics:    [][]common.Hash{ids},
+	}, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan ProtocolEvent)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(errc)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case log := <-logs:
+				if log.Removed || len(log.Topics) == 0 {
+					continue
+				}
+
+				// An event ID is only trusted from the contract that
+				// declares it
+				s, ok := sources[log.Topics[0]]
+				if !ok || log.Address != s.address {
+					continue
+				}
+
+				event, err := decodeProtocolEvent(s.kind, s.event, log)
+				if err != nil {
+					errc <- err
+					return
+				}
+				if len(filter.Participants) > 0 && !slices.ContainsFunc(filter.Participants, event.involves) {
+					continue
+				}
+
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-sub.Err():
+				errc <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errc, nil
+}
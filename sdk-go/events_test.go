@@ -0,0 +1,124 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	// Indexed and non-indexed fields interleave, with an indexed string
+	// that can only be recovered as its hash
+	eventABI, err := abi.JSON(strings.NewReader(`[{"type":"event","name":"Settled","anonymous":false,"inputs":[
+		{"name":"payer","type":"address","indexed":true},
+		{"name":"amount","type":"uint256","indexed":false},
+		{"name":"label","type":"string","indexed":true},
+		{"name":"memo","type":"string","indexed":false}
+	]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := eventABI.Events["Settled"]
+	payer := testAddress(1)
+	labelHash := crypto.Keccak256Hash([]byte("invoice"))
+	log := eventLog(testContracts.PaymentRouter, eventABI, "Settled",
+		[]common.Hash{common.BytesToHash(payer.Bytes()), labelHash},
+		big.NewInt(1234), "thanks",
+	)
+
+	fields, err := decodeEvent(event, *log)
+	if err != nil {
+		t.Fatalf("decodeEvent: %v", err)
+	}
+	if fields["payer"] != payer {
+		t.Errorf("payer = %v, want %s", fields["payer"], payer.Hex())
+	}
+	if amount, _ := fields["amount"].(*big.Int); amount == nil || amount.Int64() != 1234 {
+		t.Errorf("amount = %v, want 1234", fields["amount"])
+	}
+	if fields["label"] != labelHash {
+		t.Errorf("label = %v, want its hash %s", fields["label"], labelHash.Hex())
+	}
+	if fields["memo"] != "thanks" {
+		t.Errorf("memo = %v, want thanks", fields["memo"])
+	}
+
+	missingTopic := *log
+	missingTopic.Topics = log.Topics[:2]
+	if _, err := decodeEvent(event, missingTopic); err == nil {
+		t.Error("decoded a log missing an indexed topic")
+	}
+	if _, err := decodeEvent(paymentRouterABI.Events["EscrowCreated"], *log); err == nil {
+		t.Error("decoded a log of another event")
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{WatchMode: WatchModePoll, PollInterval: 10 * time.Millisecond})
+	me, other, third := c.Address(), testAddress(1), testAddress(2)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errc, err := c.SubscribeEvents(ctx, EventFilter{
+		Kinds:        []EventKind{EventEscrowCreated, EventChannelOpened, EventAgentRegistered},
+		Participants: []common.Address{me},
+	})
+	if err != nil {
+		t.Fatalf("SubscribeEvents: %v", err)
+	}
+
+	topic := func(a common.Address) common.Hash { return common.BytesToHash(a.Bytes()) }
+	for _, log := range []*types.Log{
+		// A kind outside the filter
+		eventLog(testContracts.PaymentRouter, paymentRouterABI, "PaymentExecuted", []common.Hash{{1}, topic(me), topic(other)}, big.NewInt(1), big.NewInt(0), [32]byte{}),
+		eventLog(testContracts.PaymentRouter, paymentRouterABI, "EscrowCreated", []common.Hash{{2}, topic(me), topic(other)}, big.NewInt(500), big.NewInt(1_800_000_000)),
+		// A channel the client is not part of
+		eventLog(testContracts.PaymentChannel, paymentChannelABI, "ChannelOpened", []common.Hash{{3}, topic(other), topic(third)}, big.NewInt(1), big.NewInt(1)),
+		// A ChannelOpened event from a contract other than PaymentChannel
+		eventLog(testContracts.PaymentRouter, paymentChannelABI, "ChannelOpened", []common.Hash{{4}, topic(me), topic(other)}, big.NewInt(1), big.NewInt(1)),
+		eventLog(testContracts.Reputation, reputationABI, "AgentRegistered", []common.Hash{topic(me), {5}}, big.NewInt(1000), "ipfs://agent"),
+		eventLog(testContracts.PaymentChannel, paymentChannelABI, "ChannelOpened", []common.Hash{{6}, topic(other), topic(me)}, big.NewInt(300), big.NewInt(200)),
+	} {
+		backend.addLog(*log)
+	}
+
+	next := func() ProtocolEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case err := <-errc:
+			t.Fatalf("subscription failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("event was not delivered")
+		}
+		return nil
+	}
+
+	escrow, ok := next().(EscrowCreatedEvent)
+	if !ok || escrow.EscrowID != [32]byte{2} || escrow.Sender != me || escrow.Amount.Int64() != 500 || escrow.Deadline != 1_800_000_000 {
+		t.Errorf("first event = %+v, want the escrow", escrow)
+	}
+	agent, ok := next().(AgentRegisteredEvent)
+	if !ok || agent.Agent != me || agent.AgentID != [32]byte{5} || agent.Stake.Int64() != 1000 || agent.MetadataURI != "ipfs://agent" {
+		t.Errorf("second event = %+v, want the agent registration", agent)
+	}
+	channel, ok := next().(ChannelOpenedEvent)
+	if !ok || channel.ChannelID != [32]byte{6} || channel.Participant2 != me || channel.Deposit1.Int64() != 300 {
+		t.Errorf("third event = %+v, want the client's channel", channel)
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("delivered unexpected %T %+v", event, event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
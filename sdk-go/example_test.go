@@ -0,0 +1,39 @@
+package synapse_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	synapse "github.com/synapse-protocol/sdk-go"
+)
+
+// Registering an agent end-to-end on a simulated chain, with the contracts
+// compiled by `npx hardhat compile` in the repository root
+func ExampleNewSimulatedClient() {
+	client, err := synapse.NewSimulatedClient(synapse.SimulatedConfig{ArtifactsDir: "../artifacts"})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer client.Close()
+
+	ctx := context.Background()
+	stake, _ := synapse.ParseSYNX("100")
+
+	if _, _, err := client.Approve(ctx, client.Contracts().Reputation, stake); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := client.RegisterAgent(ctx, synapse.RegisterAgentParams{
+		Name:        "translator",
+		MetadataURI: "ipfs://translator",
+		Stake:       stake,
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	agent, err := client.GetAgent(ctx, client.Address())
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(agent.Registered, synapse.FormatSYNX(agent.Stake))
+}
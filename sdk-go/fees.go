@@ -0,0 +1,264 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// feeDenominator is PaymentRouter.FEE_DENOMINATOR
+var feeDenominator = big.NewInt(10000)
+
+// CostBreakdown itemizes the cost of a payment
+type CostBreakdown struct {
+	// Amount is the SYNX debited from the payer, including the fee
+	Amount *big.Int
+	// Fee is the protocol fee deducted from Amount
+	Fee *big.Int
+	// NetAmount is the SYNX the recipient receives
+	NetAmount *big.Int
+	GasUnits  uint64
+	GasPrice  *big.Int
+	// GasCost is GasUnits × GasPrice in wei of the native currency
+	GasCost *big.Int
+}
+
+// EstimateFee returns the protocol fee the PaymentRouter would charge the
+// client for a payment, including its reputation tier discount. A zero
+// amount returns ErrZeroAmount.
+func (c *Client) EstimateFee(ctx context.Context, amount *big.Int) (*big.Int, error) {
+	if err := requireAmount("amount", amount); err != nil {
+		return nil, err
+	}
+
+	schedule, err := c.feeSchedule(ctx, c.address)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule.fee(amount), nil
+}
+
+// feeSchedule is the PaymentRouter's fee rate for one payer
+type feeSchedule struct {
+	baseFee *big.Int
+	// discount is the payer's tier discount in basis points of the fee
+	discount *big.Int
+}
+
+// feeSchedule reads the base fee and payer's tier discount from the router
+func (c *Client) feeSchedule(ctx context.Context, payer common.Address) (*feeSchedule, error) {
+	out, err := c.callContract(ctx, ContractPaymentRouter, "baseFee")
+	if err != nil {
+		return nil, err
+	}
+	schedule := &feeSchedule{baseFee: out[0].(*big.Int), discount: new(big.Int)}
+
+	// Like the router, apply no discount if the tier cannot be read
+	out, err = c.callContract(ctx, ContractReputation, "getAgentTier", payer)
+	if err != nil {
+		return schedule, nil
+	}
+	tier := out[0].(uint8)
+
+	out, err = c.callContract(ctx, ContractPaymentRouter, "tierDiscounts", tier)
+	if err != nil {
+		return nil, err
+	}
+	schedule.discount = out[0].(*big.Int)
+
+	return schedule, nil
+}
+
+// fee returns the fee on amount, rounded like PaymentRouter._calculateFee
+func (s *feeSchedule) fee(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(amount, s.baseFee)
+	fee.Quo(fee, feeDenominator)
+
+	if s.discount.Sign() > 0 {
+		reduction := new(big.Int).Mul(fee, s.discount)
+		reduction.Quo(reduction, feeDenominator)
+		fee.Sub(fee, reduction)
+	}
+
+	return fee
+}
+
+// checkMaxFee returns ErrFeeExceedsMax if the fees payer would currently be
+// charged on amounts, charged one by one, exceed the tolerance set with
+// WithMaxFee or WithMaxFeeBps. WithMaxFee caps the total fee and
+// WithMaxFeeBps caps it relative to the total amount.
+func (c *Client) checkMaxFee(ctx context.Context, payer common.Address, amounts []*big.Int, o *txOptions) error {
+	if o.maxFee == nil && o.maxFeeBps == nil {
+		return nil
+	}
+
+	schedule, err := c.feeSchedule(ctx, payer)
+	if err != nil {
+		return err
+	}
+
+	fee, total := new(big.Int), new(big.Int)
+	for _, amount := range amounts {
+		fee.Add(fee, schedule.fee(amount))
+		total.Add(total, amount)
+	}
+
+	if o.maxFee != nil && fee.Cmp(o.maxFee) > 0 {
+		return fmt.Errorf("%w: fee %s, max %s", ErrFeeExceedsMax, fee, o.maxFee)
+	}
+	if o.maxFeeBps != nil {
+		limit := new(big.Int).Mul(total, new(big.Int).SetUint64(*o.maxFeeBps))
+		limit.Quo(limit, feeDenominator)
+		if fee.Cmp(limit) > 0 {
+			return fmt.Errorf("%w: fee %s exceeds %d bps of %s", ErrFeeExceedsMax, fee, *o.maxFeeBps, total)
+		}
+	}
+
+	return nil
+}
+
+// requireMaxFeeChecked returns ErrMaxFeeUnsupported if WithMaxFee or
+// WithMaxFeeBps was passed to a method that does not enforce them
+func requireMaxFeeChecked(method string, o *txOptions) error {
+	if (o.maxFee != nil || o.maxFeeBps != nil) && !o.maxFeeChecked {
+		return fmt.Errorf("%w: %s", ErrMaxFeeUnsupported, method)
+	}
+	return nil
+}
+
+// EstimatePayGas estimates the gas used by a Pay call
+func (c *Client) EstimatePayGas(ctx context.Context, recipient common.Address, amount *big.Int, metadata []byte) (uint64, error) {
+	return c.estimateContractGas(ctx, ContractPaymentRouter, "pay", recipient, amount, [32]byte{}, string(metadata))
+}
+
+// EstimateTotalCost returns the protocol fee and gas cost of a payment in one
+// call. A zero amount returns ErrZeroAmount.
+func (c *Client) EstimateTotalCost(ctx context.Context, recipient common.Address, amount *big.Int, metadata []byte) (*CostBreakdown, error) {
+	fee, err := c.EstimateFee(ctx, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	gasUnits, err := c.EstimatePayGas(ctx, recipient, amount, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CostBreakdown{
+		Amount:    new(big.Int).Set(amount),
+		Fee:       fee,
+		NetAmount: new(big.Int).Sub(amount, fee),
+		GasUnits:  gasUnits,
+		GasPrice:  gasPrice,
+		GasCost:   new(big.Int).Mul(new(big.Int).SetUint64(gasUnits), gasPrice),
+	}, nil
+}
+
+// TxCost is the native-currency cost of a mined transaction
+type TxCost struct {
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+	GasCost           *big.Int
+	Value             *big.Int
+	Total             *big.Int
+}
+
+// GetTransactionCost returns what a mined transaction cost: gas used times the
+// effective gas price, plus the value it transferred
+func (c *Client) GetTransactionCost(ctx context.Context, txHash common.Hash) (*TxCost, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get receipt: %w", err)
+	}
+
+	tx, _, err := c.client.TransactionByHash(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+
+	// Nodes predating London omit effectiveGasPrice
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		gasPrice = tx.GasPrice()
+	}
+
+	gasCost := new(big.Int).Mul(new(big.Int).SetUint64(receipt.GasUsed), gasPrice)
+
+	return &TxCost{
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: gasPrice,
+		GasCost:           gasCost,
+		Value:             tx.Value(),
+		Total:             new(big.Int).Add(gasCost, tx.Value()),
+	}, nil
+}
+
+// ServiceCostBreakdown is the cost of using a service when paying with Pay
+type ServiceCostBreakdown struct {
+	Price *big.Int
+	Fee   *big.Int
+	Total *big.Int
+}
+
+// QuoteTotalCost returns the price of quantity units of a service, the
+// protocol fee on a payment of that price, and their sum
+func (c *Client) QuoteTotalCost(ctx context.Context, serviceID [32]byte, quantity uint64) (*ServiceCostBreakdown, error) {
+	price, err := c.CalculatePrice(ctx, serviceID, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	// The router charges nothing on a free service
+	fee := new(big.Int)
+	if price.Sign() > 0 {
+		if fee, err = c.EstimateFee(ctx, price); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ServiceCostBreakdown{
+		Price: price,
+		Fee:   fee,
+		Total: new(big.Int).Add(price, fee),
+	}, nil
+}
+
+// GetLifetimeFees sums the protocol fees an agent has paid in PaymentExecuted
+// events since fromBlock and returns the total with the number of payments.
+// Direct payments and meta-transaction payments are covered; batchPay emits
+// no per-payment event, so its fees are not included.
+func (c *Client) GetLifetimeFees(ctx context.Context, agent common.Address, fromBlock uint64) (*big.Int, uint64, error) {
+	router, err := c.contractAddress(ContractPaymentRouter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["PaymentExecuted"]
+	logs, err := c.filterLogsChunked(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{router},
+		Topics:    [][]common.Hash{{event.ID}, nil, {common.BytesToHash(agent.Bytes())}},
+	}, fromBlock)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := new(big.Int)
+	for _, log := range logs {
+		fields, err := decodeEvent(event, log)
+		if err != nil {
+			return nil, 0, err
+		}
+		total.Add(total, fields["fee"].(*big.Int))
+	}
+
+	return total, uint64(len(logs)), nil
+}
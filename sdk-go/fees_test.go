@@ -0,0 +1,158 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+func TestEstimateTotalCost(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "baseFee", big.NewInt(10))
+	backend.estimateGas = func(ethereum.CallMsg) (uint64, error) { return 90000, nil }
+	ctx := context.Background()
+
+	cost, err := c.EstimateTotalCost(ctx, testAddress(1), big.NewInt(1e6), nil)
+	if err != nil {
+		t.Fatalf("EstimateTotalCost: %v", err)
+	}
+	if cost.Amount.Int64() != 1e6 || cost.Fee.Int64() != 1000 || cost.NetAmount.Int64() != 1e6-1000 {
+		t.Errorf("amount, fee, net = %s, %s, %s, want 1000000, 1000, 999000", cost.Amount, cost.Fee, cost.NetAmount)
+	}
+	if cost.GasUnits != 90000 || cost.GasPrice.Cmp(backend.gasPrice) != 0 {
+		t.Errorf("gas = %d at %s, want 90000 at %s", cost.GasUnits, cost.GasPrice, backend.gasPrice)
+	}
+	if want := new(big.Int).Mul(big.NewInt(90000), backend.gasPrice); cost.GasCost.Cmp(want) != 0 {
+		t.Errorf("GasCost = %s, want %s", cost.GasCost, want)
+	}
+
+	for _, amount := range []*big.Int{nil, new(big.Int)} {
+		if _, err := c.EstimateFee(ctx, amount); !errors.Is(err, ErrZeroAmount) {
+			t.Errorf("EstimateFee(%v) error = %v, want ErrZeroAmount", amount, err)
+		}
+		if _, err := c.EstimateTotalCost(ctx, testAddress(1), amount, nil); !errors.Is(err, ErrZeroAmount) {
+			t.Errorf("EstimateTotalCost(%v) error = %v, want ErrZeroAmount", amount, err)
+		}
+	}
+}
+
+func TestGetTransactionCost(t *testing.T) {
+	sender := testAddress(0)
+	sim := simulated.NewBackend(types.GenesisAlloc{sender: {Balance: big.NewInt(1e18)}})
+	defer sim.Close()
+	c, err := NewClientWithBackend(sim.Client(), Config{})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	ctx := context.Background()
+
+	head, err := sim.Client().HeaderByNumber(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := testAddress(1)
+	tx, err := types.SignNewTx(testKey(0), types.LatestSignerForChainID(c.ChainID()), &types.DynamicFeeTx{
+		ChainID:   c.ChainID(),
+		Gas:       21000,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: new(big.Int).Add(head.BaseFee, big.NewInt(2e9)),
+		To:        &recipient,
+		Value:     big.NewInt(5e15),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Client().SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	sim.Commit()
+
+	cost, err := c.GetTransactionCost(ctx, tx.Hash())
+	if err != nil {
+		t.Fatalf("GetTransactionCost: %v", err)
+	}
+	if cost.GasUsed != 21000 || cost.Value.Cmp(tx.Value()) != 0 {
+		t.Errorf("gas used %d, value %s, want 21000 and %s", cost.GasUsed, cost.Value, tx.Value())
+	}
+	wantGas := new(big.Int).Mul(big.NewInt(21000), cost.EffectiveGasPrice)
+	if cost.GasCost.Cmp(wantGas) != 0 {
+		t.Errorf("gas cost = %s, want %s", cost.GasCost, wantGas)
+	}
+
+	// The total is exactly what left the sender's account
+	balance, err := sim.Client().BalanceAt(ctx, sender, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spent := new(big.Int).Sub(big.NewInt(1e18), balance); cost.Total.Cmp(spent) != 0 {
+		t.Errorf("total = %s, want the %s the sender spent", cost.Total, spent)
+	}
+}
+
+func TestQuoteTotalCost(t *testing.T) {
+	tests := []struct {
+		name               string
+		basePrice          int64
+		wantPrice, wantFee int64
+	}{
+		{"paid service", 1000, 5000, 5},
+		{"free service", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			service := testService(1, testAddress(1), "inference", "llm", tt.basePrice)
+			withServices(backend, service)
+			backend.handle(testContracts.ServiceRegistry, serviceRegistryABI, "getEstimatedPrice", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				return []interface{}{args[1]}, nil
+			})
+			// 10 basis points
+			backend.returns(testContracts.PaymentRouter, paymentRouterABI, "baseFee", big.NewInt(10))
+
+			cost, err := c.QuoteTotalCost(context.Background(), service.ServiceId, 5)
+			if err != nil {
+				t.Fatalf("QuoteTotalCost: %v", err)
+			}
+			if cost.Price.Int64() != tt.wantPrice || cost.Fee.Int64() != tt.wantFee {
+				t.Errorf("price, fee = %s, %s, want %d, %d", cost.Price, cost.Fee, tt.wantPrice, tt.wantFee)
+			}
+			if want := new(big.Int).Add(cost.Price, cost.Fee); cost.Total.Cmp(want) != 0 {
+				t.Errorf("Total = %s, want price plus fee %s", cost.Total, want)
+			}
+		})
+	}
+}
+
+func TestGetLifetimeFees(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	agent, other := testAddress(1), testAddress(2)
+	payment := func(id byte, sender common.Address, fee int64) {
+		backend.addLog(*eventLog(testContracts.PaymentRouter, paymentRouterABI, "PaymentExecuted",
+			[]common.Hash{{id}, common.BytesToHash(sender.Bytes()), common.BytesToHash(testAddress(3).Bytes())},
+			big.NewInt(1000), big.NewInt(fee), [32]byte{},
+		))
+	}
+	// Block 1 is before the scan, and block 3 is another payer's
+	payment(1, agent, 100)
+	payment(2, agent, 7)
+	payment(3, other, 50)
+	payment(4, agent, 11)
+	payment(5, agent, 3)
+
+	total, count, err := c.GetLifetimeFees(context.Background(), agent, 2)
+	if err != nil {
+		t.Fatalf("GetLifetimeFees: %v", err)
+	}
+	if total.Int64() != 21 || count != 3 {
+		t.Errorf("GetLifetimeFees = %s over %d payments, want 21 over 3", total, count)
+	}
+}
@@ -0,0 +1,146 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// HardwareSigner signs with an account of a Ledger or Trezor device. Each
+// transaction has to be confirmed on the device, which shows its recipient,
+// value and fees.
+//
+// go-ethereum's USB wallet drivers only sign transactions, so SignHash, and
+// with it signing channel states, profiles and meta-transactions, returns
+// accounts.ErrNotSupported. Use a separate software or KMS signer for those.
+type HardwareSigner struct {
+	hub     *usbwallet.Hub
+	wallet  accounts.Wallet
+	account accounts.Account
+}
+
+// NewLedgerSigner opens the first connected Ledger and derives the account at
+// path, e.g. accounts.DefaultBaseDerivationPath. The Ethereum app must be
+// open on the device.
+func NewLedgerSigner(path accounts.DerivationPath) (*HardwareSigner, error) {
+	hub, err := usbwallet.NewLedgerHub()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Ledger hub: %w", err)
+	}
+	return newHardwareSigner(hub, "Ledger", path, nil)
+}
+
+// NewTrezorSigner opens the first connected Trezor and derives the account
+// at path. It suits the Model T and Safe, which take the PIN and passphrase
+// on the device. A Trezor One with a PIN fails with
+// usbwallet.ErrTrezorPINNeeded; use NewTrezorSignerWithPrompt for it.
+func NewTrezorSigner(path accounts.DerivationPath) (*HardwareSigner, error) {
+	return NewTrezorSignerWithPrompt(path, TrezorPrompt{})
+}
+
+// TrezorPrompt asks the user for what a Trezor One needs to unlock. Either
+// func may be nil.
+type TrezorPrompt struct {
+	// PIN returns the PIN as positions on the scrambled keypad the device
+	// shows, numbered like a numeric keypad:
+	//
+	//	7 8 9
+	//	4 5 6
+	//	1 2 3
+	PIN func() (string, error)
+
+	// Passphrase returns the passphrase of a hidden wallet. Nil, like an
+	// empty passphrase, opens the standard wallet.
+	Passphrase func() (string, error)
+}
+
+// NewTrezorSignerWithPrompt is NewTrezorSigner for devices that request the
+// PIN or passphrase from the host, calling prompt for them
+func NewTrezorSignerWithPrompt(path accounts.DerivationPath, prompt TrezorPrompt) (*HardwareSigner, error) {
+	hub, err := usbwallet.NewTrezorHubWithHID()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open Trezor hub: %w", err)
+	}
+	return newHardwareSigner(hub, "Trezor", path, func(wallet accounts.Wallet) error {
+		return openTrezor(wallet, prompt)
+	})
+}
+
+// openTrezor opens wallet, answering the device's PIN and passphrase
+// requests with prompt
+func openTrezor(wallet accounts.Wallet, prompt TrezorPrompt) error {
+	err := wallet.Open("")
+	if errors.Is(err, usbwallet.ErrTrezorPINNeeded) {
+		if prompt.PIN == nil {
+			return fmt.Errorf("%w: use NewTrezorSignerWithPrompt", err)
+		}
+		pin, promptErr := prompt.PIN()
+		if promptErr != nil {
+			return fmt.Errorf("failed to read PIN: %w", promptErr)
+		}
+		err = wallet.Open(pin)
+	}
+	if errors.Is(err, usbwallet.ErrTrezorPassphraseNeeded) {
+		var passphrase string
+		if prompt.Passphrase != nil {
+			var promptErr error
+			if passphrase, promptErr = prompt.Passphrase(); promptErr != nil {
+				return fmt.Errorf("failed to read passphrase: %w", promptErr)
+			}
+		}
+		err = wallet.Open(passphrase)
+	}
+	return err
+}
+
+// newHardwareSigner opens the first wallet of hub with open, or with no
+// passphrase if open is nil, and derives the account at path
+func newHardwareSigner(hub *usbwallet.Hub, device string, path accounts.DerivationPath, open func(accounts.Wallet) error) (*HardwareSigner, error) {
+	wallets := hub.Wallets()
+	if len(wallets) == 0 {
+		return nil, fmt.Errorf("no %s connected", device)
+	}
+
+	wallet := wallets[0]
+	if open == nil {
+		open = func(wallet accounts.Wallet) error { return wallet.Open("") }
+	}
+	if err := open(wallet); err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", device, err)
+	}
+
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		wallet.Close()
+		return nil, fmt.Errorf("failed to derive %s account %s: %w", device, path, err)
+	}
+
+	return &HardwareSigner{hub: hub, wallet: wallet, account: account}, nil
+}
+
+// Address implements Signer
+func (s *HardwareSigner) Address() common.Address {
+	return s.account.Address
+}
+
+// SignHash implements Signer. Hardware wallets do not sign raw digests, so it
+// always fails.
+func (s *HardwareSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return nil, fmt.Errorf("hardware wallets only sign transactions: %w", accounts.ErrNotSupported)
+}
+
+// SignTx implements TxSigner, asking for confirmation on the device
+func (s *HardwareSigner) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	return s.wallet.SignTx(s.account, tx, chainID)
+}
+
+// Close releases the device
+func (s *HardwareSigner) Close() error {
+	return s.wallet.Close()
+}
@@ -0,0 +1,45 @@
+package synapse
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/usbwallet"
+)
+
+// trezorOne mimics how a Trezor One with a PIN and passphrase answers
+// Open: first the PIN, then the passphrase
+type trezorOne struct {
+	accounts.Wallet
+	opens []string
+}
+
+func (w *trezorOne) Open(passphrase string) error {
+	w.opens = append(w.opens, passphrase)
+	switch len(w.opens) {
+	case 1:
+		return usbwallet.ErrTrezorPINNeeded
+	case 2:
+		return usbwallet.ErrTrezorPassphraseNeeded
+	}
+	return nil
+}
+
+func TestOpenTrezorPrompts(t *testing.T) {
+	wallet := new(trezorOne)
+	err := openTrezor(wallet, TrezorPrompt{
+		PIN:        func() (string, error) { return "1379", nil },
+		Passphrase: func() (string, error) { return "hidden", nil },
+	})
+	if err != nil {
+		t.Fatalf("openTrezor: %v", err)
+	}
+	if len(wallet.opens) != 3 || wallet.opens[1] != "1379" || wallet.opens[2] != "hidden" {
+		t.Errorf("Open called with %q, want the PIN then the passphrase", wallet.opens)
+	}
+
+	if err := openTrezor(new(trezorOne), TrezorPrompt{}); !errors.Is(err, usbwallet.ErrTrezorPINNeeded) {
+		t.Errorf("error without a PIN prompt = %v, want ErrTrezorPINNeeded", err)
+	}
+}
@@ -0,0 +1,124 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// JournalEntry records a transaction submitted by the client
+type JournalEntry struct {
+	TxHash    common.Hash
+	From      common.Address
+	Nonce     uint64
+	Contract  string
+	Method    string
+	Submitted time.Time
+
+	// CorrelationID is the ID passed with WithCorrelationID, if any
+	CorrelationID string
+}
+
+// TxJournal persists submitted transactions so they can be reconciled
+// against the chain after a crash or restart
+type TxJournal interface {
+	Record(entry JournalEntry) error
+	Entries() ([]JournalEntry, error)
+}
+
+// MemoryJournal is an in-memory TxJournal
+type MemoryJournal struct {
+	mu      sync.Mutex
+	entries []JournalEntry
+}
+
+// NewMemoryJournal creates an empty in-memory journal
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{}
+}
+
+// Record appends an entry to the journal
+func (j *MemoryJournal) Record(entry JournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.entries = append(j.entries, entry)
+	return nil
+}
+
+// Entries returns a copy of all journaled entries
+func (j *MemoryJournal) Entries() ([]JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entries := make([]JournalEntry, len(j.entries))
+	copy(entries, j.entries)
+	return entries, nil
+}
+
+// TxStatus represents the on-chain status of a journaled transaction
+type TxStatus uint8
+
+const (
+	TxPending TxStatus = iota
+	TxMined
+	TxFailed
+	TxDropped
+)
+
+// ReconcileResult reports the on-chain status of a journaled transaction
+type ReconcileResult struct {
+	Entry    JournalEntry
+	Status   TxStatus
+	Receipt  *types.Receipt
+	Resubmit bool
+}
+
+// Reconcile checks every journaled transaction against the chain. Mined
+// transactions are reported with their receipt; transactions the node no
+// longer knows about are reported as dropped and flagged for resubmission.
+func (c *Client) Reconcile(ctx context.Context, journal TxJournal) ([]ReconcileResult, error) {
+	entries, err := journal.Entries()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+
+	results := make([]ReconcileResult, 0, len(entries))
+	for _, entry := range entries {
+		result := ReconcileResult{Entry: entry}
+
+		receipt, err := c.client.TransactionReceipt(ctx, entry.TxHash)
+		switch {
+		case err == nil:
+			result.Receipt = receipt
+			result.Status = TxMined
+			if receipt.Status != types.ReceiptStatusSuccessful {
+				result.Status = TxFailed
+			}
+		case errors.Is(err, ethereum.NotFound):
+			// Still known to the node (or mined since the receipt lookup)
+			_, _, err := c.client.TransactionByHash(ctx, entry.TxHash)
+			switch {
+			case err == nil:
+				result.Status = TxPending
+			case errors.Is(err, ethereum.NotFound):
+				result.Status = TxDropped
+				result.Resubmit = true
+			default:
+				return nil, fmt.Errorf("failed to get transaction %s: %w", entry.TxHash.Hex(), err)
+			}
+		default:
+			return nil, fmt.Errorf("failed to get receipt for %s: %w", entry.TxHash.Hex(), err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,92 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestReconcile(t *testing.T) {
+	backend := newMockBackend()
+	journal := NewMemoryJournal()
+	c := newTestClient(t, backend, Config{Journal: journal})
+	ctx := context.Background()
+
+	transfer := func() common.Hash {
+		t.Helper()
+		hash, err := c.Transfer(ctx, testAddress(1), big.NewInt(1))
+		if err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+		return hash
+	}
+
+	mined := transfer()
+	failed := transfer()
+	backend.mu.Lock()
+	backend.receipts[failed].Status = types.ReceiptStatusFailed
+	backend.mu.Unlock()
+	backend.autoMine = false
+	pending := transfer()
+	dropped := common.Hash{0xdd}
+	if err := journal.Record(JournalEntry{TxHash: dropped, From: c.Address(), Nonce: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := c.Reconcile(ctx, journal)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	want := []struct {
+		hash     common.Hash
+		status   TxStatus
+		resubmit bool
+	}{
+		{mined, TxMined, false},
+		{failed, TxFailed, false},
+		{pending, TxPending, false},
+		{dropped, TxDropped, true},
+	}
+	if len(results) != len(want) {
+		t.Fatalf("Reconcile returned %d results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		got := results[i]
+		if got.Entry.TxHash != w.hash || got.Status != w.status || got.Resubmit != w.resubmit {
+			t.Errorf("result %d = %s status %d resubmit %v, want %s status %d resubmit %v", i,
+				got.Entry.TxHash.Hex(), got.Status, got.Resubmit, w.hash.Hex(), w.status, w.resubmit)
+		}
+		if (got.Receipt != nil) != (w.status == TxMined || w.status == TxFailed) {
+			t.Errorf("result %d has receipt %v", i, got.Receipt)
+		}
+	}
+}
+
+// failingJournal is a TxJournal whose writes fail
+type failingJournal struct{}
+
+func (failingJournal) Record(JournalEntry) error        { return errors.New("disk full") }
+func (failingJournal) Entries() ([]JournalEntry, error) { return nil, nil }
+
+func TestJournalFailureKeepsTxHash(t *testing.T) {
+	logger, logs := captureLogs()
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{Journal: failingJournal{}, Logger: logger})
+
+	hash, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+	if sent := backend.sentTxs(); len(sent) != 1 || sent[0].Hash() != hash {
+		t.Fatalf("Transfer returned %s for the broadcast transaction", hash.Hex())
+	}
+	if !strings.Contains(logs.String(), "failed to journal transaction") {
+		t.Errorf("journal failure was not logged:\n%s", logs)
+	}
+}
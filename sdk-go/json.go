@@ -0,0 +1,273 @@
+package synapse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// AmountFormat selects how *big.Int amounts are written to JSON
+type AmountFormat uint8
+
+const (
+	// AmountDecimal writes amounts as decimal strings, e.g. "1500000000000000000"
+	AmountDecimal AmountFormat = iota
+	// AmountHex writes amounts as 0x-prefixed hex quantities, e.g. "0x14d1120d7b160000"
+	AmountHex
+)
+
+// JSONWithAmounts wraps one of the SDK's result types, a pointer to one or a
+// slice of them so that it marshals with amounts in format, e.g.
+//
+//	json.Marshal(synapse.JSONWithAmounts(result, synapse.AmountHex))
+//
+// The result types' own MarshalJSON writes decimal strings. Unmarshaling
+// accepts decimal strings, hex quantities and plain numbers in either case.
+func JSONWithAmounts(v interface{}, format AmountFormat) json.Marshaler {
+	return amountJSON{v: v, format: format}
+}
+
+// amountJSON is the json.Marshaler returned by JSONWithAmounts
+type amountJSON struct {
+	v      interface{}
+	format AmountFormat
+}
+
+func (a amountJSON) MarshalJSON() ([]byte, error) {
+	return marshalWithAmounts(reflect.ValueOf(a.v), a.format)
+}
+
+func (p PaymentResult) MarshalJSON() ([]byte, error)            { return marshalDecimal(p) }
+func (p *PaymentResult) UnmarshalJSON(data []byte) error        { return unmarshalWithAmounts(data, p) }
+func (c ChannelInfo) MarshalJSON() ([]byte, error)              { return marshalDecimal(c) }
+func (c *ChannelInfo) UnmarshalJSON(data []byte) error          { return unmarshalWithAmounts(data, c) }
+func (a AgentInfo) MarshalJSON() ([]byte, error)                { return marshalDecimal(a) }
+func (a *AgentInfo) UnmarshalJSON(data []byte) error            { return unmarshalWithAmounts(data, a) }
+func (s ServiceInfo) MarshalJSON() ([]byte, error)              { return marshalDecimal(s) }
+func (s *ServiceInfo) UnmarshalJSON(data []byte) error          { return unmarshalWithAmounts(data, s) }
+func (e EscrowInfo) MarshalJSON() ([]byte, error)               { return marshalDecimal(e) }
+func (e *EscrowInfo) UnmarshalJSON(data []byte) error           { return unmarshalWithAmounts(data, e) }
+func (s StreamInfo) MarshalJSON() ([]byte, error)               { return marshalDecimal(s) }
+func (s *StreamInfo) UnmarshalJSON(data []byte) error           { return unmarshalWithAmounts(data, s) }
+func (t TokenInfo) MarshalJSON() ([]byte, error)                { return marshalDecimal(t) }
+func (t *TokenInfo) UnmarshalJSON(data []byte) error            { return unmarshalWithAmounts(data, t) }
+func (c CostBreakdown) MarshalJSON() ([]byte, error)            { return marshalDecimal(c) }
+func (c *CostBreakdown) UnmarshalJSON(data []byte) error        { return unmarshalWithAmounts(data, c) }
+func (s ServiceCostBreakdown) MarshalJSON() ([]byte, error)     { return marshalDecimal(s) }
+func (s *ServiceCostBreakdown) UnmarshalJSON(data []byte) error { return unmarshalWithAmounts(data, s) }
+func (q QuoteInfo) MarshalJSON() ([]byte, error)                { return marshalDecimal(q) }
+func (q *QuoteInfo) UnmarshalJSON(data []byte) error            { return unmarshalWithAmounts(data, q) }
+func (c TxCost) MarshalJSON() ([]byte, error)                   { return marshalDecimal(c) }
+func (c *TxCost) UnmarshalJSON(data []byte) error               { return unmarshalWithAmounts(data, c) }
+
+var (
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+	bytes32    = reflect.TypeOf([32]byte{})
+)
+
+// formatAmount formats an amount in format. Negative hex amounts are written
+// as "-0x…", as hexutil.Big does.
+func formatAmount(amount *big.Int, format AmountFormat) string {
+	if format == AmountHex {
+		return hexutil.EncodeBig(amount)
+	}
+	return amount.String()
+}
+
+// parseAmount parses a JSON amount in any supported format
+func parseAmount(data json.RawMessage) (*big.Int, error) {
+	text := strings.Trim(string(data), `"`)
+	negative := strings.HasPrefix(text, "-")
+	digits := strings.TrimPrefix(text, "-")
+
+	amount, ok := new(big.Int), false
+	if strings.HasPrefix(digits, "0x") || strings.HasPrefix(digits, "0X") {
+		amount, ok = amount.SetString(digits[2:], 16)
+	} else {
+		amount, ok = amount.SetString(digits, 10)
+	}
+	if !ok || strings.HasPrefix(digits, "-") || strings.HasPrefix(digits, "+") {
+		return nil, fmt.Errorf("invalid amount %s", data)
+	}
+	if negative {
+		amount.Neg(amount)
+	}
+	return amount, nil
+}
+
+// jsonField is an exported struct field with its encoding/json name and
+// options
+type jsonField struct {
+	index     int
+	name      string
+	omitEmpty bool
+}
+
+// jsonFields returns the fields of struct type t as encoding/json would
+// encode them, honouring json tag names, "-" and omitempty
+func jsonFields(t reflect.Type) []jsonField {
+	var fields []jsonField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, jsonField{
+			index:     i,
+			name:      name,
+			omitEmpty: strings.Contains(","+options+",", ",omitempty,"),
+		})
+	}
+	return fields
+}
+
+// isEmptyJSONValue reports whether omitempty drops v, as in encoding/json
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// marshalDecimal marshals a result type with decimal amounts
+func marshalDecimal(v interface{}) ([]byte, error) {
+	return marshalWithAmounts(reflect.ValueOf(v), AmountDecimal)
+}
+
+// marshalWithAmounts marshals a struct, or a pointer to or slice of structs,
+// field by field like encoding/json, writing *big.Int fields in format and
+// [32]byte IDs as 0x-prefixed hex
+func marshalWithAmounts(v reflect.Value, format AmountFormat) ([]byte, error) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return []byte("null"), nil
+		}
+		return marshalWithAmounts(v.Elem(), format)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return []byte("null"), nil
+		}
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			item, err := marshalWithAmounts(v.Index(i), format)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(item)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	case reflect.Struct:
+	default:
+		return nil, fmt.Errorf("cannot marshal %s with amounts", v.Type())
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, field := range jsonFields(v.Type()) {
+		value := v.Field(field.index)
+		if field.omitEmpty && isEmptyJSONValue(value) {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+
+		name, _ := json.Marshal(field.name)
+		buf.Write(name)
+		buf.WriteByte(':')
+
+		var encoded []byte
+		var err error
+		switch {
+		case value.Type() == bigIntType && !value.IsNil():
+			encoded, err = json.Marshal(formatAmount(value.Interface().(*big.Int), format))
+		case value.Type() == bytes32:
+			encoded, err = json.Marshal(common.Hash(value.Interface().([32]byte)).Hex())
+		default:
+			encoded, err = json.Marshal(value.Interface())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Type().Field(field.index).Name, err)
+		}
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// unmarshalWithAmounts is the inverse of marshalWithAmounts. v must be a
+// pointer to a struct. Keys match field names case-insensitively, as in
+// encoding/json.
+func unmarshalWithAmounts(data []byte, v interface{}) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	for _, field := range jsonFields(rv.Type()) {
+		value, ok := raw[field.name]
+		if !ok {
+			for key, candidate := range raw {
+				if strings.EqualFold(key, field.name) {
+					value, ok = candidate, true
+					break
+				}
+			}
+		}
+		if !ok || string(value) == "null" {
+			continue
+		}
+
+		target := rv.Field(field.index)
+		switch {
+		case target.Type() == bigIntType:
+			amount, err := parseAmount(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
+			target.Set(reflect.ValueOf(amount))
+			continue
+		case target.Type() == bytes32 && bytes.HasPrefix(value, []byte(`"`)):
+			// Older encodings wrote IDs as arrays of numbers, which the
+			// default case still decodes
+			var id common.Hash
+			if err := json.Unmarshal(value, &id); err != nil {
+				return fmt.Errorf("%s: %w", field.name, err)
+			}
+			target.Set(reflect.ValueOf([32]byte(id)))
+			continue
+		}
+		if err := json.Unmarshal(value, target.Addr().Interface()); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+
+	return nil
+}
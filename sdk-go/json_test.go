@@ -0,0 +1,106 @@
+package synapse
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestJSONAmountsRoundTrip(t *testing.T) {
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	result := PaymentResult{
+		TxHash:       common.HexToHash("0x01"),
+		PaymentID:    [32]byte{0xab, 31: 0xcd},
+		Amount:       amount,
+		Fee:          big.NewInt(-42),
+		AppPaymentID: [32]byte{1},
+	}
+	stream := StreamInfo{StreamID: [32]byte{2}, TotalAmount: big.NewInt(5)}
+
+	tests := []struct {
+		name   string
+		format AmountFormat
+		want   []string
+	}{
+		{"decimal", AmountDecimal, []string{`"Amount":"1500000000000000000"`, `"Fee":"-42"`}},
+		{"hex", AmountHex, []string{`"Amount":"0x14d1120d7b160000"`, `"Fee":"-0x2a"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(JSONWithAmounts(result, tt.format))
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			want := append(tt.want, `"PaymentID":"0xab000000000000000000000000000000000000000000000000000000000000cd"`)
+			for _, w := range want {
+				if !strings.Contains(string(data), w) {
+					t.Errorf("%s does not contain %s", data, w)
+				}
+			}
+
+			var decoded PaymentResult
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, result) {
+				t.Errorf("round trip = %+v, want %+v", decoded, result)
+			}
+
+			// Nil amounts stay nil, and slices marshal element by element
+			data, err = json.Marshal(JSONWithAmounts([]*StreamInfo{&stream}, tt.format))
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			var streams []StreamInfo
+			if err := json.Unmarshal(data, &streams); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if len(streams) != 1 || !reflect.DeepEqual(streams[0], stream) {
+				t.Errorf("round trip = %+v, want %+v", streams, stream)
+			}
+		})
+	}
+
+	// A type's own MarshalJSON writes decimal amounts
+	data, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"Fee":"-42"`) {
+		t.Errorf("%s does not contain decimal amounts", data)
+	}
+}
+
+func TestJSONAmountsHonoursTags(t *testing.T) {
+	type tagged struct {
+		ID       [32]byte `json:"id"`
+		Amount   *big.Int `json:"amount,omitempty"`
+		Note     string   `json:"note,omitempty"`
+		Internal string   `json:"-"`
+		Plain    uint64
+	}
+
+	data, err := json.Marshal(JSONWithAmounts(tagged{Amount: big.NewInt(255), Internal: "x", Plain: 7}, AmountHex))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	want := `{"id":"0x0000000000000000000000000000000000000000000000000000000000000000","amount":"0xff","Plain":7}`
+	if string(data) != want {
+		t.Fatalf("Marshal = %s, want %s", data, want)
+	}
+
+	var decoded tagged
+	if err := unmarshalWithAmounts([]byte(`{"ID":"0x01","amount":"255","plain":7}`), &decoded); err == nil {
+		t.Fatal("Unmarshal accepted a short ID")
+	}
+	if err := unmarshalWithAmounts([]byte(`{"amount":"255","plain":7,"Internal":"x"}`), &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.Amount.Int64() != 255 || decoded.Plain != 7 || decoded.Internal != "" {
+		t.Errorf("Unmarshal = %+v", decoded)
+	}
+}
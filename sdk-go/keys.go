@@ -0,0 +1,127 @@
+package synapse
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// LoadKeystore decrypts a geth keystore file (JSON v3) and returns a signer
+// for its key
+func LoadKeystore(path, passphrase string) (*LocalSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keystore: %w", err)
+	}
+	return DecryptKeystore(data, passphrase)
+}
+
+// DecryptKeystore decrypts keystore JSON (v3) and returns a signer for its key
+func DecryptKeystore(keyJSON []byte, passphrase string) (*LocalSigner, error) {
+	key, err := keystore.DecryptKey(keyJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+	}
+	return NewLocalSigner(key.PrivateKey), nil
+}
+
+// NewMnemonicSigner derives the key at path from a BIP-39 mnemonic and
+// optional passphrase, following BIP-32. The mnemonic's checksum is verified.
+// accounts.DefaultBaseDerivationPath (m/44'/60'/0'/0/0) is the first account
+// of most wallets.
+func NewMnemonicSigner(mnemonic, passphrase string, path accounts.DerivationPath) (*LocalSigner, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	key, err := deriveKey(seed, path)
+	if err != nil {
+		return nil, err
+	}
+	return NewLocalSigner(key), nil
+}
+
+// DeriveMnemonicSigners derives n consecutive accounts from a BIP-39
+// mnemonic, starting at base and incrementing its last index, e.g. the
+// accounts m/44'/60'/0'/0/0 to m/44'/60'/0'/0/(n-1) for
+// accounts.DefaultBaseDerivationPath. Register them with Config.Accounts or
+// AddAccount to send from each with WithFrom.
+func DeriveMnemonicSigners(mnemonic, passphrase string, base accounts.DerivationPath, n int) ([]*LocalSigner, error) {
+	if len(base) == 0 {
+		return nil, fmt.Errorf("empty derivation path")
+	}
+
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("invalid mnemonic: %w", err)
+	}
+
+	path := make(accounts.DerivationPath, len(base))
+	copy(path, base)
+
+	signers := make([]*LocalSigner, 0, n)
+	for i := 0; i < n; i++ {
+		key, err := deriveKey(seed, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", path, err)
+		}
+		signers = append(signers, NewLocalSigner(key))
+		path[len(path)-1]++
+	}
+
+	return signers, nil
+}
+
+// hardenedKeyStart is the first hardened BIP-32 child index
+const hardenedKeyStart = 0x80000000
+
+// deriveKey derives the private key at path from a BIP-32 seed
+func deriveKey(seed []byte, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	n := crypto.S256().Params().N
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := new(big.Int).SetBytes(sum[:32]), sum[32:]
+	if key.Sign() == 0 || key.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("invalid master key")
+	}
+
+	for _, index := range path {
+		data := make([]byte, 0, 37)
+		if index >= hardenedKeyStart {
+			data = append(data, 0)
+			data = append(data, key.FillBytes(make([]byte, 32))...)
+		} else {
+			x, y := crypto.S256().ScalarBaseMult(key.FillBytes(make([]byte, 32)))
+			data = append(data, crypto.CompressPubkey(&ecdsa.PublicKey{Curve: crypto.S256(), X: x, Y: y})...)
+		}
+		data = binary.BigEndian.AppendUint32(data, index)
+
+		mac := hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum := mac.Sum(nil)
+
+		tweak := new(big.Int).SetBytes(sum[:32])
+		if tweak.Cmp(n) >= 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		key.Add(key, tweak).Mod(key, n)
+		if key.Sign() == 0 {
+			return nil, fmt.Errorf("invalid child key at index %d", index)
+		}
+		chainCode = sum[32:]
+	}
+
+	return crypto.ToECDSA(key.FillBytes(make([]byte, 32)))
+}
@@ -0,0 +1,92 @@
+package synapse
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// testMnemonic is the default mnemonic of hardhat and anvil, whose derived
+// accounts are widely published
+const testMnemonic = "test test test test test test test test test test test junk"
+
+func TestNewMnemonicSigner(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "first account", path: "m/44'/60'/0'/0/0", want: "0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266"},
+		{name: "second account", path: "m/44'/60'/0'/0/1", want: "0x70997970C51812dc3A010C7d01b50e0d17dc79C8"},
+		{name: "third account", path: "m/44'/60'/0'/0/2", want: "0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := accounts.ParseDerivationPath(tt.path)
+			if err != nil {
+				t.Fatalf("ParseDerivationPath: %v", err)
+			}
+			signer, err := NewMnemonicSigner(testMnemonic, "", path)
+			if err != nil {
+				t.Fatalf("NewMnemonicSigner: %v", err)
+			}
+			if got := signer.Address(); got != common.HexToAddress(tt.want) {
+				t.Errorf("address = %s, want %s", got.Hex(), tt.want)
+			}
+		})
+	}
+
+	signer, err := NewMnemonicSigner(testMnemonic, "", accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		t.Fatalf("NewMnemonicSigner: %v", err)
+	}
+	want := "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+	if got := common.Bytes2Hex(crypto.FromECDSA(signer.key)); got != want {
+		t.Errorf("private key = %s, want %s", got, want)
+	}
+}
+
+func TestDeriveMnemonicSigners(t *testing.T) {
+	signers, err := DeriveMnemonicSigners(testMnemonic, "", accounts.DefaultBaseDerivationPath, 3)
+	if err != nil {
+		t.Fatalf("DeriveMnemonicSigners: %v", err)
+	}
+
+	want := []string{
+		"0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266",
+		"0x70997970C51812dc3A010C7d01b50e0d17dc79C8",
+		"0x3C44CdDdB6a900fa2b585dd299e03d12FA4293BC",
+	}
+	for i, signer := range signers {
+		if got := signer.Address(); got != common.HexToAddress(want[i]) {
+			t.Errorf("account %d = %s, want %s", i, got.Hex(), want[i])
+		}
+	}
+
+	// The base path must not be modified
+	if got := accounts.DefaultBaseDerivationPath.String(); got != "m/44'/60'/0'/0/0" {
+		t.Errorf("base path changed to %s", got)
+	}
+}
+
+func TestNewMnemonicSignerRejectsInvalidMnemonic(t *testing.T) {
+	tests := []struct {
+		name     string
+		mnemonic string
+	}{
+		{name: "bad checksum", mnemonic: "test test test test test test test test test test test test"},
+		{name: "unknown word", mnemonic: "test test test test test test test test test test test junky"},
+		{name: "empty", mnemonic: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMnemonicSigner(tt.mnemonic, "", accounts.DefaultBaseDerivationPath); err == nil {
+				t.Error("NewMnemonicSigner accepted an invalid mnemonic")
+			}
+		})
+	}
+}
@@ -0,0 +1,429 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// The KMS signers talk to the services' HTTP APIs directly, so that using
+// them does not pull the vendor SDKs into every agent's build.
+
+// AWSKMSConfig configures NewAWSKMSSigner
+type AWSKMSConfig struct {
+	// Region is the key's AWS region, e.g. "us-east-1"
+	Region string
+
+	// KeyID is the ID, ARN or alias of an ECC_SECG_P256K1 signing key
+	KeyID string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials
+	// requests are signed with. Empty means AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides https://kms.<Region>.amazonaws.com, e.g. for a VPC
+	// endpoint or LocalStack
+	Endpoint string
+
+	// HTTPClient sends the requests. Nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// awsKMS calls the AWS KMS JSON API
+type awsKMS struct {
+	config AWSKMSConfig
+}
+
+// NewAWSKMSSigner returns a signer for an AWS KMS secp256k1 key. The key's
+// address is read from KMS, and digests are signed with ECDSA_SHA_256 as
+// precomputed digests, so the private key never leaves KMS.
+func NewAWSKMSSigner(ctx context.Context, config AWSKMSConfig) (Signer, error) {
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.Region == "" || config.KeyID == "" {
+		return nil, fmt.Errorf("AWS KMS region and key ID are required")
+	}
+	if config.AccessKeyID == "" || config.SecretAccessKey == "" {
+		return nil, fmt.Errorf("AWS credentials are required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://kms." + config.Region + ".amazonaws.com"
+	}
+	kms := &awsKMS{config: config}
+
+	var key struct {
+		PublicKey []byte
+	}
+	if err := kms.call(ctx, "GetPublicKey", map[string]string{"KeyId": config.KeyID}, &key); err != nil {
+		return nil, fmt.Errorf("failed to get AWS KMS public key: %w", err)
+	}
+	address, err := addressFromPublicKeyInfo(key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDERSigner(address, kms.sign), nil
+}
+
+// sign implements DigestSignFunc
+func (k *awsKMS) sign(ctx context.Context, digest []byte) ([]byte, error) {
+	var out struct {
+		Signature []byte
+	}
+	err := k.call(ctx, "Sign", map[string]interface{}{
+		"KeyId":            k.config.KeyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": "ECDSA_SHA_256",
+	}, &out)
+	return out.Signature, err
+}
+
+// call invokes a KMS action, signing the request with AWS Signature
+// Version 4
+func (k *awsKMS) call(ctx context.Context, action string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService."+action)
+	k.signRequest(req, body, time.Now().UTC())
+
+	return doJSON(k.config.HTTPClient, req, out)
+}
+
+// signRequest adds the Signature Version 4 headers for the kms service
+func (k *awsKMS) signRequest(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	scope := now.Format("20060102") + "/" + k.config.Region + "/kms/aws4_request"
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if k.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", k.config.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + k.config.SecretAccessKey)
+	for _, part := range []string{now.Format("20060102"), k.config.Region, "kms", "aws4_request", stringToSign} {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(part))
+		key = mac.Sum(nil)
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		k.config.AccessKeyID, scope, signedHeaders, hex.EncodeToString(key)))
+}
+
+// GCPKMSConfig configures NewGCPKMSSigner
+type GCPKMSConfig struct {
+	// KeyVersion is the resource name of an EC_SIGN_SECP256K1_SHA256 key
+	// version, e.g.
+	// "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+	KeyVersion string
+
+	// Token returns an OAuth 2.0 access token with the cloudkms scope, e.g.
+	// from golang.org/x/oauth2/google's DefaultTokenSource
+	Token func(ctx context.Context) (string, error)
+
+	// Endpoint overrides https://cloudkms.googleapis.com
+	Endpoint string
+
+	// HTTPClient sends the requests. Nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// gcpKMS calls the Cloud KMS REST API
+type gcpKMS struct {
+	config GCPKMSConfig
+}
+
+// NewGCPKMSSigner returns a signer for a Google Cloud KMS secp256k1 key
+// version. The key's address is read from Cloud KMS, and digests are signed
+// remotely, so the private key never leaves Cloud KMS.
+func NewGCPKMSSigner(ctx context.Context, config GCPKMSConfig) (Signer, error) {
+	if config.KeyVersion == "" || config.Token == nil {
+		return nil, fmt.Errorf("Cloud KMS key version and token are required")
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://cloudkms.googleapis.com"
+	}
+	kms := &gcpKMS{config: config}
+
+	var key struct {
+		PEM string `json:"pem"`
+	}
+	if err := kms.call(ctx, http.MethodGet, "/publicKey", nil, &key); err != nil {
+		return nil, fmt.Errorf("failed to get Cloud KMS public key: %w", err)
+	}
+	block, _ := pem.Decode([]byte(key.PEM))
+	if block == nil {
+		return nil, fmt.Errorf("Cloud KMS public key is not PEM encoded")
+	}
+	address, err := addressFromPublicKeyInfo(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDERSigner(address, kms.sign), nil
+}
+
+// sign implements DigestSignFunc. Cloud KMS signs the digest as given, so
+// the Keccak-256 digest is passed in the sha256 field.
+func (k *gcpKMS) sign(ctx context.Context, digest []byte) ([]byte, error) {
+	in := map[string]interface{}{
+		"digest": map[string][]byte{"sha256": digest},
+	}
+	var out struct {
+		Signature []byte `json:"signature"`
+	}
+	err := k.call(ctx, http.MethodPost, ":asymmetricSign", in, &out)
+	return out.Signature, err
+}
+
+// call invokes a method on the key version
+func (k *gcpKMS) call(ctx context.Context, method, suffix string, in, out interface{}) error {
+	token, err := k.config.Token(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get access token: %w", err)
+	}
+
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	endpoint := strings.TrimSuffix(k.config.Endpoint, "/") + "/v1/" + k.config.KeyVersion + suffix
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return doJSON(k.config.HTTPClient, req, out)
+}
+
+// VaultConfig configures NewVaultSigner
+type VaultConfig struct {
+	// Address is the Vault server, e.g. "https://vault.example.com:8200".
+	// Empty means VAULT_ADDR.
+	Address string
+
+	// Token authenticates the requests. Empty means VAULT_TOKEN.
+	Token string
+
+	// Mount is the path of the secrets engine. Empty means "transit".
+	Mount string
+
+	// Key is the name of the secp256k1 key
+	Key string
+
+	// HTTPClient sends the requests. Nil means http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// vault calls a transit-style secrets engine
+type vault struct {
+	config VaultConfig
+}
+
+// NewVaultSigner returns a signer for a secp256k1 key of a HashiCorp Vault
+// secrets engine with the transit API: keys/<Key> returns the PEM public
+// key, and sign/<Key> signs prehashed input as ASN.1. Vault's built-in
+// transit engine has no secp256k1 key type, so Mount must be a plugin that
+// provides one behind that API. The latest key version signs.
+func NewVaultSigner(ctx context.Context, config VaultConfig) (Signer, error) {
+	if config.Address == "" {
+		config.Address = os.Getenv("VAULT_ADDR")
+	}
+	if config.Token == "" {
+		config.Token = os.Getenv("VAULT_TOKEN")
+	}
+	if config.Mount == "" {
+		config.Mount = "transit"
+	}
+	if config.Address == "" || config.Key == "" {
+		return nil, fmt.Errorf("Vault address and key are required")
+	}
+	v := &vault{config: config}
+
+	var key struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, http.MethodGet, "keys", nil, &key); err != nil {
+		return nil, fmt.Errorf("failed to read Vault key: %w", err)
+	}
+	latest, ok := key.Data.Keys[fmt.Sprint(key.Data.LatestVersion)]
+	if !ok {
+		return nil, fmt.Errorf("Vault key %s has no version %d", config.Key, key.Data.LatestVersion)
+	}
+	block, _ := pem.Decode([]byte(latest.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("Vault public key is not PEM encoded")
+	}
+	address, err := addressFromPublicKeyInfo(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewDERSigner(address, v.sign), nil
+}
+
+// sign implements DigestSignFunc
+func (v *vault) sign(ctx context.Context, digest []byte) ([]byte, error) {
+	in := map[string]interface{}{
+		"input":                digest,
+		"prehashed":            true,
+		"marshaling_algorithm": "asn1",
+	}
+	var out struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	if err := v.call(ctx, http.MethodPost, "sign", in, &out); err != nil {
+		return nil, err
+	}
+
+	// Signatures are returned as vault:v<version>:<base64>
+	parts := strings.Split(out.Data.Signature, ":")
+	if len(parts) != 3 || parts[0] != "vault" {
+		return nil, fmt.Errorf("unexpected Vault signature %q", out.Data.Signature)
+	}
+	return base64.StdEncoding.DecodeString(parts[2])
+}
+
+// call invokes an operation of the secrets engine on the key
+func (v *vault) call(ctx context.Context, method, operation string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	endpoint := strings.TrimSuffix(v.config.Address, "/") + "/v1/" + strings.Trim(v.config.Mount, "/") + "/" + operation + "/" + url.PathEscape(v.config.Key)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.config.Token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return doJSON(v.config.HTTPClient, req, out)
+}
+
+// doJSON sends req and decodes a JSON response into out
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s %s: %s: %s", req.Method, req.URL.Redacted(), resp.Status, bytes.TrimSpace(data))
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+// addressFromPublicKeyInfo returns the Ethereum address of a DER encoded
+// SubjectPublicKeyInfo. crypto/x509 cannot parse secp256k1 keys, so the
+// structure is decoded directly.
+func addressFromPublicKeyInfo(der []byte) (common.Address, error) {
+	var info struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return common.Address{}, fmt.Errorf("failed to decode public key: %w", err)
+	}
+
+	pub, err := crypto.UnmarshalPubkey(info.PublicKey.Bytes)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("public key is not a secp256k1 key: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
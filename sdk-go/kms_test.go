@@ -0,0 +1,202 @@
+package synapse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// derSignature signs digest with key and encodes it as ASN.1 DER with a high
+// S, as KMS services may return it
+func derSignature(t *testing.T, key *ecdsa.PrivateKey, digest []byte) []byte {
+	t.Helper()
+
+	signature, err := crypto.Sign(digest, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := new(big.Int).SetBytes(signature[:32])
+	s := new(big.Int).Sub(crypto.S256().Params().N, new(big.Int).SetBytes(signature[32:64]))
+
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{r, s})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+// publicKeyInfo encodes the public key of key as a DER SubjectPublicKeyInfo
+func publicKeyInfo(t *testing.T, key *ecdsa.PrivateKey) []byte {
+	t.Helper()
+
+	curve, err := asn1.Marshal(asn1.ObjectIdentifier{1, 3, 132, 0, 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub := crypto.FromECDSAPub(&key.PublicKey)
+	der, err := asn1.Marshal(struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1},
+			Parameters: asn1.RawValue{FullBytes: curve},
+		},
+		PublicKey: asn1.BitString{Bytes: pub, BitLength: 8 * len(pub)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
+
+func TestKMSSigners(t *testing.T) {
+	key := testKey(3)
+	publicKeyPEM := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyInfo(t, key)}))
+	keyVersion := "projects/p/locations/global/keyRings/r/cryptoKeys/k/cryptoKeyVersions/1"
+
+	tests := []struct {
+		name    string
+		handler func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		signer  func(ctx context.Context, url string) (Signer, error)
+	}{
+		{
+			name: "AWS KMS",
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				auth := r.Header.Get("Authorization")
+				if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-east-1/kms/aws4_request") {
+					t.Errorf("Authorization = %q", auth)
+				}
+				var in struct {
+					KeyId       string
+					Message     []byte
+					MessageType string
+				}
+				json.NewDecoder(r.Body).Decode(&in)
+				if in.KeyId != "alias/agent" {
+					t.Errorf("KeyId = %q", in.KeyId)
+				}
+				switch r.Header.Get("X-Amz-Target") {
+				case "TrentService.GetPublicKey":
+					json.NewEncoder(w).Encode(map[string][]byte{"PublicKey": publicKeyInfo(t, key)})
+				case "TrentService.Sign":
+					if in.MessageType != "DIGEST" {
+						t.Errorf("MessageType = %q, want DIGEST", in.MessageType)
+					}
+					json.NewEncoder(w).Encode(map[string][]byte{"Signature": derSignature(t, key, in.Message)})
+				default:
+					http.Error(w, "unknown target", http.StatusBadRequest)
+				}
+			},
+			signer: func(ctx context.Context, url string) (Signer, error) {
+				return NewAWSKMSSigner(ctx, AWSKMSConfig{
+					Region:          "us-east-1",
+					KeyID:           "alias/agent",
+					AccessKeyID:     "AKID",
+					SecretAccessKey: "secret",
+					Endpoint:        url,
+				})
+			},
+		},
+		{
+			name: "Cloud KMS",
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("Authorization"); got != "Bearer token" {
+					t.Errorf("Authorization = %q", got)
+				}
+				switch r.URL.Path {
+				case "/v1/" + keyVersion + "/publicKey":
+					json.NewEncoder(w).Encode(map[string]string{"pem": publicKeyPEM})
+				case "/v1/" + keyVersion + ":asymmetricSign":
+					var in struct {
+						Digest struct {
+							SHA256 []byte `json:"sha256"`
+						} `json:"digest"`
+					}
+					json.NewDecoder(r.Body).Decode(&in)
+					json.NewEncoder(w).Encode(map[string][]byte{"signature": derSignature(t, key, in.Digest.SHA256)})
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			signer: func(ctx context.Context, url string) (Signer, error) {
+				return NewGCPKMSSigner(ctx, GCPKMSConfig{
+					KeyVersion: keyVersion,
+					Token:      func(context.Context) (string, error) { return "token", nil },
+					Endpoint:   url,
+				})
+			},
+		},
+		{
+			name: "Vault",
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				if got := r.Header.Get("X-Vault-Token"); got != "token" {
+					t.Errorf("X-Vault-Token = %q", got)
+				}
+				switch r.URL.Path {
+				case "/v1/transit/keys/agent":
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+						"latest_version": 1,
+						"keys":           map[string]interface{}{"1": map[string]string{"public_key": publicKeyPEM}},
+					}})
+				case "/v1/transit/sign/agent":
+					var in struct {
+						Input     []byte `json:"input"`
+						Prehashed bool   `json:"prehashed"`
+					}
+					json.NewDecoder(r.Body).Decode(&in)
+					if !in.Prehashed {
+						t.Error("input is not marked prehashed")
+					}
+					signature := "vault:v1:" + base64.StdEncoding.EncodeToString(derSignature(t, key, in.Input))
+					json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"signature": signature}})
+				default:
+					http.NotFound(w, r)
+				}
+			},
+			signer: func(ctx context.Context, url string) (Signer, error) {
+				return NewVaultSigner(ctx, VaultConfig{Address: url, Token: "token", Key: "agent"})
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, w, r)
+			}))
+			defer server.Close()
+
+			signer, err := tt.signer(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("failed to create signer: %v", err)
+			}
+			if want := crypto.PubkeyToAddress(key.PublicKey); signer.Address() != want {
+				t.Fatalf("Address = %s, want %s", signer.Address().Hex(), want.Hex())
+			}
+
+			digest := crypto.Keccak256([]byte("digest"))
+			signature, err := signer.SignHash(context.Background(), digest)
+			if err != nil {
+				t.Fatalf("SignHash: %v", err)
+			}
+			if s := new(big.Int).SetBytes(signature[32:64]); s.Cmp(secp256k1HalfN) > 0 {
+				t.Error("signature has a high S")
+			}
+			pub, err := crypto.SigToPub(digest, signature)
+			if err != nil || crypto.PubkeyToAddress(*pub) != signer.Address() {
+				t.Errorf("signature does not recover to %s", signer.Address().Hex())
+			}
+		})
+	}
+}
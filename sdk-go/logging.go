@@ -0,0 +1,55 @@
+package synapse
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// LogSensitivity selects how much identifying data Config.Logger receives.
+// Metadata is always logged as its hash and the private key never at all.
+type LogSensitivity uint8
+
+const (
+	// LogFullAddresses logs addresses in full
+	LogFullAddresses LogSensitivity = iota
+	// LogMaskAddresses logs only the first and last 4 hex digits of addresses
+	LogMaskAddresses
+)
+
+// logDebug writes a debug log if Config.Logger is set
+func (c *Client) logDebug(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if c.config.Logger == nil {
+		return
+	}
+	c.config.Logger.LogAttrs(ctx, slog.LevelDebug, msg, attrs...)
+}
+
+// logWarn writes a warning if Config.Logger is set
+func (c *Client) logWarn(ctx context.Context, msg string, attrs ...slog.Attr) {
+	if c.config.Logger == nil {
+		return
+	}
+	c.config.Logger.LogAttrs(ctx, slog.LevelWarn, msg, attrs...)
+}
+
+// logAddress returns a log attribute for an address, masked according to
+// Config.LogSensitivity
+func (c *Client) logAddress(key string, address common.Address) slog.Attr {
+	hex := address.Hex()
+	if c.config.LogSensitivity == LogMaskAddresses {
+		hex = hex[:6] + "…" + hex[len(hex)-4:]
+	}
+	return slog.String(key, hex)
+}
+
+// logMetadata returns a log attribute holding the hash of a payload, so its
+// contents never reach the log
+func logMetadata(key string, metadata []byte) slog.Attr {
+	if len(metadata) == 0 {
+		return slog.String(key, "")
+	}
+	return slog.String(key, crypto.Keccak256Hash(metadata).Hex())
+}
@@ -0,0 +1,121 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// logBuffer collects log output written from several goroutines
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// captureLogs returns a debug logger writing to the returned buffer
+func captureLogs() (*slog.Logger, *logBuffer) {
+	logs := new(logBuffer)
+	return slog.New(slog.NewTextHandler(logs, &slog.HandlerOptions{Level: slog.LevelDebug})), logs
+}
+
+func TestLogsNeverContainPrivateKey(t *testing.T) {
+	logger, logs := captureLogs()
+	backend := newMockBackend()
+	privateKey := hex.EncodeToString(crypto.FromECDSA(testKey(0)))
+	c := newTestClient(t, backend, Config{
+		PrivateKey:     privateKey,
+		Logger:         logger,
+		LogSensitivity: LogMaskAddresses,
+	})
+	recipient := testAddress(1)
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "pay", [32]byte{})
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		return []*types.Log{eventLog(testContracts.PaymentRouter, paymentRouterABI, "PaymentExecuted",
+			[]common.Hash{{1}, common.BytesToHash(c.Address().Bytes()), common.BytesToHash(recipient.Bytes())},
+			big.NewInt(1000), big.NewInt(0), [32]byte{},
+		)}
+	}
+
+	ctx := context.Background()
+	if _, err := c.Pay(ctx, recipient, big.NewInt(1000), []byte("secret metadata")); err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	if _, err := c.Transfer(ctx, recipient, big.NewInt(1000)); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	out := strings.ToLower(logs.String())
+	if out == "" {
+		t.Fatal("nothing was logged")
+	}
+	if strings.Contains(out, privateKey) {
+		t.Error("logs contain the private key")
+	}
+	if strings.Contains(out, "secret metadata") {
+		t.Error("logs contain the raw metadata")
+	}
+	if strings.Contains(out, strings.ToLower(recipient.Hex())) {
+		t.Error("logs contain an unmasked address")
+	}
+}
+
+func TestSubscriptionLogsResubscribe(t *testing.T) {
+	logger, logs := captureLogs()
+	c := newTestClient(t, newMockBackend(), Config{Logger: logger})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dropped := event.NewSubscription(func(quit <-chan struct{}) error {
+		return errors.New("connection reset")
+	})
+	subscribe := func(ctx context.Context) (ethereum.Subscription, error) {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			<-quit
+			return nil
+		}), nil
+	}
+
+	s := c.newSubscription()
+	go s.loop(ctx, dropped, subscribe)
+	defer s.Unsubscribe()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(logs.String(), "resubscribed") {
+		if time.Now().After(deadline) {
+			t.Fatal("subscription did not resubscribe")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	out := logs.String()
+	for _, want := range []string{"subscription dropped, resubscribing", "connection reset", "resubscribed"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("logs do not contain %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package synapse
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// PaymentLeaf returns the Merkle leaf of a payment, in the format of
+// OpenZeppelin's StandardMerkleTree:
+// keccak256(bytes.concat(keccak256(abi.encode(recipient, amount))))
+func PaymentLeaf(recipient common.Address, amount *big.Int) [32]byte {
+	inner := crypto.Keccak256(
+		common.LeftPadBytes(recipient.Bytes(), 32),
+		common.LeftPadBytes(amount.Bytes(), 32),
+	)
+	return crypto.Keccak256Hash(inner)
+}
+
+// BuildPaymentMerkleTree builds a Merkle tree over the payments and returns
+// its root and a proof per recipient. Pairs are hashed in sorted order, so the
+// proofs verify with OpenZeppelin's MerkleProof.verify. Each recipient may
+// appear only once.
+func BuildPaymentMerkleTree(payments []BatchPayment) (root [32]byte, proofs map[common.Address][][32]byte, err error) {
+	if len(payments) == 0 {
+		return [32]byte{}, nil, fmt.Errorf("no payments")
+	}
+
+	// index[i] is the position of payment i's ancestor in the current level
+	level := make([][32]byte, len(payments))
+	index := make([]int, len(payments))
+	proofs = make(map[common.Address][][32]byte, len(payments))
+	for i, payment := range payments {
+		if payment.Amount == nil || payment.Amount.Sign() <= 0 {
+			return [32]byte{}, nil, fmt.Errorf("payment %d to %s has no amount", i, payment.Recipient.Hex())
+		}
+		if _, ok := proofs[payment.Recipient]; ok {
+			return [32]byte{}, nil, fmt.Errorf("duplicate recipient %s", payment.Recipient.Hex())
+		}
+
+		level[i] = PaymentLeaf(payment.Recipient, payment.Amount)
+		index[i] = i
+		proofs[payment.Recipient] = [][32]byte{}
+	}
+
+	for len(level) > 1 {
+		for i, payment := range payments {
+			// An unpaired last node is promoted without a sibling
+			if sibling := index[i] ^ 1; sibling < len(level) {
+				proofs[payment.Recipient] = append(proofs[payment.Recipient], level[sibling])
+			}
+			index[i] /= 2
+		}
+
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, hashPair(level[i], level[i+1]))
+			}
+		}
+		level = next
+	}
+
+	return level[0], proofs, nil
+}
+
+// VerifyPaymentProof reports whether proof shows that the payment is part of
+// the tree with the given root
+func VerifyPaymentProof(root [32]byte, recipient common.Address, amount *big.Int, proof [][32]byte) bool {
+	node := PaymentLeaf(recipient, amount)
+	for _, sibling := range proof {
+		node = hashPair(node, sibling)
+	}
+	return node == root
+}
+
+// hashPair hashes two nodes in sorted order
+func hashPair(a, b [32]byte) [32]byte {
+	if bytes.Compare(a[:], b[:]) > 0 {
+		a, b = b, a
+	}
+	return crypto.Keccak256Hash(a[:], b[:])
+}
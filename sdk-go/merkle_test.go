@@ -0,0 +1,78 @@
+package synapse
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestPaymentMerkleTreeKnownRoot(t *testing.T) {
+	payments := []BatchPayment{
+		{Recipient: common.HexToAddress("0x1111111111111111111111111111111111111111"), Amount: big.NewInt(100)},
+		{Recipient: common.HexToAddress("0x2222222222222222222222222222222222222222"), Amount: big.NewInt(200)},
+	}
+
+	// StandardMerkleTree.of([[0x11..11, 100], [0x22..22, 200]], ["address", "uint256"])
+	wantLeaf := "0x922c8389ffeb7a618b1f9fe2e9a75c76d86291502713033e5951dbad45b3fc31"
+	wantRoot := "0xf7d4f275a5ca2ac4cd5eb89d243a68d2432c10a15687185fde46c0cd2c0f38df"
+
+	if leaf := common.Hash(PaymentLeaf(payments[0].Recipient, payments[0].Amount)).Hex(); leaf != wantLeaf {
+		t.Errorf("PaymentLeaf = %s, want %s", leaf, wantLeaf)
+	}
+
+	root, _, err := BuildPaymentMerkleTree(payments)
+	if err != nil {
+		t.Fatalf("BuildPaymentMerkleTree: %v", err)
+	}
+	if got := common.Hash(root).Hex(); got != wantRoot {
+		t.Errorf("root = %s, want %s", got, wantRoot)
+	}
+}
+
+func TestPaymentMerkleProofs(t *testing.T) {
+	for n := 1; n <= 9; n++ {
+		payments := make([]BatchPayment, n)
+		for i := range payments {
+			payments[i] = BatchPayment{Recipient: testAddress(i), Amount: big.NewInt(int64(i + 1))}
+		}
+
+		root, proofs, err := BuildPaymentMerkleTree(payments)
+		if err != nil {
+			t.Fatalf("%d payments: BuildPaymentMerkleTree: %v", n, err)
+		}
+
+		for _, payment := range payments {
+			proof := proofs[payment.Recipient]
+			if !VerifyPaymentProof(root, payment.Recipient, payment.Amount, proof) {
+				t.Errorf("%d payments: proof of %s does not verify", n, payment.Recipient.Hex())
+			}
+			if VerifyPaymentProof(root, payment.Recipient, new(big.Int).Add(payment.Amount, big.NewInt(1)), proof) {
+				t.Errorf("%d payments: proof of %s verifies a different amount", n, payment.Recipient.Hex())
+			}
+		}
+	}
+}
+
+func TestBuildPaymentMerkleTreeRejectsInvalidPayments(t *testing.T) {
+	tests := []struct {
+		name     string
+		payments []BatchPayment
+	}{
+		{name: "no payments"},
+		{name: "zero amount", payments: []BatchPayment{{Recipient: testAddress(1), Amount: new(big.Int)}}},
+		{name: "nil amount", payments: []BatchPayment{{Recipient: testAddress(1)}}},
+		{name: "duplicate recipient", payments: []BatchPayment{
+			{Recipient: testAddress(1), Amount: big.NewInt(1)},
+			{Recipient: testAddress(1), Amount: big.NewInt(2)},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := BuildPaymentMerkleTree(tt.payments); err == nil {
+				t.Error("BuildPaymentMerkleTree succeeded")
+			}
+		})
+	}
+}
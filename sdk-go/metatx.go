@@ -0,0 +1,117 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// MetaTxRequest is a payment to be submitted on the payer's behalf by a
+// relayer through PaymentRouter.payWithSignature. Nonce must be the payer's
+// current router nonce, see GetPaymentNonce.
+type MetaTxRequest struct {
+	Recipient   common.Address
+	Amount      *big.Int
+	ServiceType [32]byte
+	Nonce       uint64
+	Deadline    uint64
+}
+
+// MetaTxSigned is a MetaTxRequest signed by Sender
+type MetaTxSigned struct {
+	Request   MetaTxRequest
+	Sender    common.Address
+	Signature []byte
+}
+
+// metaTxDigest returns the digest payWithSignature verifies: the EIP-191
+// personal-message hash of metaTxMessage
+func (c *Client) metaTxDigest(sender common.Address, req MetaTxRequest) []byte {
+	return accounts.TextHash(c.metaTxMessage(sender, req))
+}
+
+// metaTxMessage returns the personal message signed for a meta-transaction,
+// keccak256(abi.encodePacked(sender, recipient, amount, serviceType, nonce, deadline, chainid, router))
+func (c *Client) metaTxMessage(sender common.Address, req MetaTxRequest) []byte {
+	amount := req.Amount
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	return crypto.Keccak256(
+		sender.Bytes(),
+		req.Recipient.Bytes(),
+		common.LeftPadBytes(amount.Bytes(), 32),
+		req.ServiceType[:],
+		common.LeftPadBytes(new(big.Int).SetUint64(req.Nonce).Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(req.Deadline).Bytes(), 32),
+		common.LeftPadBytes(c.chainID.Bytes(), 32),
+		c.config.Contracts.PaymentRouter.Bytes(),
+	)
+}
+
+// SignMetaTx signs a payment for a relayer to submit. The signature is bound
+// to the PaymentRouter and chain the client is configured for. The payer
+// still needs SYNX allowance for the router, but no native gas.
+func (c *Client) SignMetaTx(req MetaTxRequest) (MetaTxSigned, error) {
+	if err := c.requireSigner(); err != nil {
+		return MetaTxSigned{}, err
+	}
+
+	if err := requireAmount("amount", req.Amount); err != nil {
+		return MetaTxSigned{}, err
+	}
+
+	signature, err := c.signMessage(c.metaTxMessage(c.address, req))
+	if err != nil {
+		return MetaTxSigned{}, fmt.Errorf("failed to sign meta-transaction: %w", err)
+	}
+
+	// Use the 27/28 recovery ID expected by ECDSA.recover
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return MetaTxSigned{Request: req, Sender: c.address, Signature: signature}, nil
+}
+
+// RecoverMetaTxSigner returns the address that signed a meta-transaction
+func (c *Client) RecoverMetaTxSigner(signed MetaTxSigned) (common.Address, error) {
+	return recoverSigner(c.metaTxDigest(signed.Sender, signed.Request), signed.Signature)
+}
+
+// RelaySubmit submits a signed meta-transaction, paying its gas. The relayer
+// needs the router's OPERATOR_ROLE. The signature is checked locally first.
+// WithMaxFee applies to the protocol fee charged to the signer.
+func (c *Client) RelaySubmit(ctx context.Context, signed MetaTxSigned, opts ...TxOption) (common.Hash, error) {
+	signer, err := c.RecoverMetaTxSigner(signed)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if signer != signed.Sender {
+		return common.Hash{}, fmt.Errorf("meta-transaction signed by %s, not sender %s", signer.Hex(), signed.Sender.Hex())
+	}
+
+	// The router charges the fee to the meta-transaction's sender
+	req := signed.Request
+	if err := c.checkMaxFee(ctx, signed.Sender, []*big.Int{req.Amount}, applyTxOptions(opts)); err != nil {
+		return common.Hash{}, err
+	}
+
+	opts = append(opts[:len(opts):len(opts)], maxFeeChecked())
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "payWithSignature", []interface{}{
+		signed.Sender,
+		req.Recipient,
+		req.Amount,
+		req.ServiceType,
+		new(big.Int).SetUint64(req.Deadline),
+		signed.Signature,
+	}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
@@ -0,0 +1,60 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestMetaTx(t *testing.T) {
+	backend := newMockBackend()
+	agent := newTestClient(t, backend, Config{})
+	relayer := newTestClient(t, backend, Config{Signer: NewLocalSigner(testKey(1))})
+
+	req := MetaTxRequest{Recipient: testAddress(2), Amount: big.NewInt(250), ServiceType: [32]byte{1}, Nonce: 3, Deadline: 1_700_000_600}
+	signed, err := agent.SignMetaTx(req)
+	if err != nil {
+		t.Fatalf("SignMetaTx: %v", err)
+	}
+	if v := signed.Signature[64]; v != 27 && v != 28 {
+		t.Errorf("signature v = %d, want 27 or 28", v)
+	}
+
+	signer, err := relayer.RecoverMetaTxSigner(signed)
+	if err != nil {
+		t.Fatalf("RecoverMetaTxSigner: %v", err)
+	}
+	if signer != agent.Address() {
+		t.Fatalf("recovered %s, want the agent %s", signer.Hex(), agent.Address().Hex())
+	}
+
+	if _, err := relayer.RelaySubmit(context.Background(), signed); err != nil {
+		t.Fatalf("RelaySubmit: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+	if from, _ := types.Sender(types.LatestSignerForChainID(backend.chainID), sent[0]); from != relayer.Address() {
+		t.Errorf("relayed from %s, want the relayer %s", from.Hex(), relayer.Address().Hex())
+	}
+	args, err := paymentRouterABI.Methods["payWithSignature"].Inputs.Unpack(sent[0].Data()[4:])
+	if err != nil {
+		t.Fatalf("unpack payWithSignature: %v", err)
+	}
+	if args[0] != agent.Address() || args[1] != req.Recipient || args[2].(*big.Int).Cmp(req.Amount) != 0 {
+		t.Errorf("payWithSignature(%v, %v, %v), want the agent's payment", args[0], args[1], args[2])
+	}
+
+	// A request altered after signing no longer recovers to the sender
+	tampered := signed
+	tampered.Request.Amount = big.NewInt(251)
+	if _, err := relayer.RelaySubmit(context.Background(), tampered); err == nil {
+		t.Error("RelaySubmit accepted a request altered after signing")
+	}
+	if n := len(backend.sentTxs()); n != 1 {
+		t.Errorf("sent %d transactions after the tampered request, want 1", n)
+	}
+}
@@ -0,0 +1,99 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// multicallCall mirrors the Multicall3.Call3 struct
+type multicallCall struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicallResult mirrors the Multicall3.Result struct
+type multicallResult struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// multicall performs calls in a single Multicall3 aggregate3 call when
+// Contracts.Multicall is configured, and one by one otherwise. A failing call
+// fails the whole batch unless it allows failure, in which case its result
+// reports it.
+func (c *Client) multicall(ctx context.Context, calls []multicallCall) ([]multicallResult, error) {
+	if c.config.Contracts.Multicall == (common.Address{}) {
+		ctx, cancel := c.withTimeout(ctx, timeoutRead)
+		defer cancel()
+
+		results := make([]multicallResult, len(calls))
+		for i, call := range calls {
+			data, err := c.client.CallContract(ctx, ethereum.CallMsg{To: &call.Target, Data: call.CallData}, nil)
+			if err != nil {
+				if !call.AllowFailure {
+					return nil, fmt.Errorf("failed to call %s: %w", call.Target.Hex(), err)
+				}
+				continue
+			}
+			results[i] = multicallResult{Success: true, ReturnData: data}
+		}
+		return results, nil
+	}
+
+	out, err := c.callContract(ctx, ContractMulticall, "aggregate3", calls)
+	if err != nil {
+		return nil, err
+	}
+
+	results := *abi.ConvertType(out[0], new([]multicallResult)).(*[]multicallResult)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall returned %d results for %d calls", len(results), len(calls))
+	}
+
+	return results, nil
+}
+
+// callContractBatch calls the same method of a protocol contract once per
+// argument list through multicall and returns the unpacked outputs in input
+// order
+func (c *Client) callContractBatch(ctx context.Context, contract, method string, args [][]interface{}) ([][]interface{}, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+
+	target, err := c.contractAddress(contract)
+	if err != nil {
+		return nil, err
+	}
+
+	contractABI := c.contractABI(contract)
+	calls := make([]multicallCall, len(args))
+	for i, callArgs := range args {
+		data, err := contractABI.Pack(method, callArgs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack %s: %w", method, err)
+		}
+		calls[i] = multicallCall{Target: target, CallData: data}
+	}
+
+	returned, err := c.multicall(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([][]interface{}, len(returned))
+	for i, result := range returned {
+		unpacked, err := contractABI.Unpack(method, result.ReturnData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack %s: %w", method, err)
+		}
+		results[i] = unpacked
+	}
+
+	return results, nil
+}
@@ -0,0 +1,58 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+)
+
+// Network describes a chain the protocol is deployed to
+type Network struct {
+	Name    string
+	ChainID uint64
+	Testnet bool
+}
+
+// knownNetworks lists the networks in the deployment configuration, keyed by
+// chain ID
+var knownNetworks = map[uint64]Network{
+	1:        {Name: "mainnet", ChainID: 1},
+	42161:    {Name: "arbitrum", ChainID: 42161},
+	11155111: {Name: "sepolia", ChainID: 11155111, Testnet: true},
+	421614:   {Name: "arbitrumSepolia", ChainID: 421614, Testnet: true},
+	31337:    {Name: "hardhat", ChainID: 31337, Testnet: true},
+}
+
+// GetNetwork returns the registry entry of the connected chain
+func (c *Client) GetNetwork(ctx context.Context) (*Network, error) {
+	if err := c.checkChainID(ctx); err != nil {
+		return nil, err
+	}
+
+	network, ok := knownNetworks[c.chainID.Uint64()]
+	if !c.chainID.IsUint64() || !ok {
+		return nil, fmt.Errorf("%w: chain ID %s", ErrUnknownNetwork, c.chainID)
+	}
+
+	return &network, nil
+}
+
+// NetworkName returns the name of the connected chain
+func (c *Client) NetworkName(ctx context.Context) (string, error) {
+	network, err := c.GetNetwork(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return network.Name, nil
+}
+
+// IsTestnet reports whether the connected chain is a testnet or local
+// development chain
+func (c *Client) IsTestnet(ctx context.Context) (bool, error) {
+	network, err := c.GetNetwork(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	return network.Testnet, nil
+}
@@ -0,0 +1,38 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestIsTestnet(t *testing.T) {
+	tests := []struct {
+		chainID     int64
+		wantName    string
+		wantTestnet bool
+		wantErr     error
+	}{
+		{1, "mainnet", false, nil},
+		{42161, "arbitrum", false, nil},
+		{11155111, "sepolia", true, nil},
+		{421614, "arbitrumSepolia", true, nil},
+		{31337, "hardhat", true, nil},
+		{1337, "", false, ErrUnknownNetwork},
+	}
+	for _, tt := range tests {
+		backend := newMockBackend()
+		backend.chainID = big.NewInt(tt.chainID)
+		c := newTestClient(t, backend, Config{})
+
+		testnet, err := c.IsTestnet(context.Background())
+		if !errors.Is(err, tt.wantErr) || testnet != tt.wantTestnet {
+			t.Errorf("chain %d: IsTestnet = %v, %v, want %v, %v", tt.chainID, testnet, err, tt.wantTestnet, tt.wantErr)
+		}
+		name, err := c.NetworkName(context.Background())
+		if !errors.Is(err, tt.wantErr) || name != tt.wantName {
+			t.Errorf("chain %d: NetworkName = %q, %v, want %q, %v", tt.chainID, name, err, tt.wantName, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// nonceManager assigns the nonces of an account's transactions. A nonce is
+// reserved by acquireNonce and handed back by releaseNonce; the lock is only
+// held while reserving and releasing, so a slow signer does not stall other
+// write calls, and concurrent calls never share a nonce.
+type nonceManager struct {
+	mu sync.Mutex
+
+	// synced is false until the first nonce is read from the node and after
+	// a submission fails with nothing else in flight, forcing the next
+	// acquire to trust the node
+	synced bool
+	next   uint64
+	// last is the most recent transaction sent with a managed nonce
+	last common.Hash
+
+	// reserved counts the nonces acquired but not yet released
+	reserved int
+	// free holds nonces below next whose submission failed while others were
+	// in flight; they are handed out again before next
+	free []uint64
+}
+
+// acquireNonce reserves the nonce for the next transaction. The caller must
+// call releaseNonce once the transaction has been sent or has failed.
+//
+// The node's pending nonce wins if it is ahead, e.g. after transactions sent
+// from another process. If it is behind, the node may be lagging behind
+// transactions the client sent, or those may have been dropped; a gap is
+// assumed, and the pending nonce used, only if nothing is in flight and the
+// node no longer knows the last transaction.
+func (c *Client) acquireNonce(ctx context.Context, acct *account) (uint64, error) {
+	m := &acct.nonces
+
+	// The node is queried without the lock, so the state it is compared to is
+	// re-read afterwards
+	m.mu.Lock()
+	synced, next, last, idle := m.synced, m.next, m.last, m.reserved == 0
+	m.mu.Unlock()
+
+	pending, err := c.client.PendingNonceAt(ctx, acct.address)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce: %w", err)
+	}
+
+	dropped := false
+	if synced && idle && pending < next {
+		_, _, err := c.client.TransactionByHash(ctx, last)
+		dropped = errors.Is(err, ethereum.NotFound)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case pending > m.next:
+		m.next = pending
+		m.free = slices.DeleteFunc(m.free, func(n uint64) bool { return n < pending })
+	case pending < m.next && m.reserved == 0 && (!m.synced || dropped && m.last == last):
+		if dropped {
+			c.logDebug(ctx, "nonce gap detected, resyncing",
+				slog.Uint64("expected", m.next),
+				slog.Uint64("pending", pending),
+				slog.String("last_tx", m.last.Hex()),
+			)
+		}
+		m.next = pending
+		m.free = nil
+	}
+	m.synced = true
+	m.reserved++
+
+	if len(m.free) > 0 {
+		i := slices.Index(m.free, slices.Min(m.free))
+		nonce := m.free[i]
+		m.free = slices.Delete(m.free, i, i+1)
+		return nonce, nil
+	}
+	nonce := m.next
+	m.next++
+	return nonce, nil
+}
+
+// releaseNonce records the transaction sent with a nonce from acquireNonce,
+// or nil if none was sent, in which case the nonce is reused
+func (c *Client) releaseNonce(acct *account, nonce uint64, tx *types.Transaction) {
+	m := &acct.nonces
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.reserved--
+	switch {
+	case tx != nil:
+		m.last = tx.Hash()
+	case m.reserved == 0:
+		// The submission may still have reached the node, so it decides
+		m.synced = false
+		m.free = nil
+	default:
+		m.free = append(m.free, nonce)
+	}
+}
+
+// resyncNonces makes the next acquire trust the node and unlocks the nonce
+// manager, after the caller held it to submit transactions itself
+func (m *nonceManager) resyncNonces() {
+	m.synced = false
+	m.free = nil
+	m.mu.Unlock()
+}
@@ -0,0 +1,115 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConcurrentNonceAllocation(t *testing.T) {
+	backend := newMockBackend()
+	backend.autoMine = false
+	c := newTestClient(t, backend, Config{})
+
+	const calls = 16
+	var wg sync.WaitGroup
+	errs := make(chan error, calls)
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1)); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Errorf("Transfer: %v", err)
+	}
+
+	var nonces []uint64
+	for _, tx := range backend.sentTxs() {
+		nonces = append(nonces, tx.Nonce())
+	}
+	slices.Sort(nonces)
+	for i, nonce := range nonces {
+		if nonce != uint64(i) {
+			t.Fatalf("sent nonces = %v, want 0 to %d once each", nonces, calls-1)
+		}
+	}
+	if len(nonces) != calls {
+		t.Fatalf("sent %d transactions, want %d", len(nonces), calls)
+	}
+}
+
+// gatedSigner blocks its first signature, once armed, until the gate opens
+type gatedSigner struct {
+	Signer
+	armed   atomic.Bool
+	entered chan struct{}
+	gate    chan struct{}
+}
+
+func (s *gatedSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	if s.armed.CompareAndSwap(true, false) {
+		close(s.entered)
+		<-s.gate
+	}
+	return s.Signer.SignHash(ctx, hash)
+}
+
+func TestNonceReservedWhileSigning(t *testing.T) {
+	backend := newMockBackend()
+	backend.autoMine = false
+	signer := &gatedSigner{Signer: NewLocalSigner(testKey(0)), entered: make(chan struct{}), gate: make(chan struct{})}
+	var failSend atomic.Bool
+	backend.err = func(method string) error {
+		if method == "SendTransaction" && failSend.CompareAndSwap(true, false) {
+			return errors.New("connection reset")
+		}
+		return nil
+	}
+	c := newTestClient(t, backend, Config{Signer: signer})
+	ctx := context.Background()
+
+	// The first transfer reserves nonce 0 and waits in its signer
+	signer.armed.Store(true)
+	slow := make(chan error, 1)
+	go func() {
+		_, err := c.Transfer(ctx, testAddress(1), big.NewInt(1))
+		slow <- err
+	}()
+	<-signer.entered
+
+	// Meanwhile nonce 1 fails to send and is handed out again
+	failSend.Store(true)
+	if _, err := c.Transfer(ctx, testAddress(1), big.NewInt(2)); err == nil {
+		t.Fatal("Transfer succeeded despite the failing node")
+	}
+	hash, err := c.Transfer(ctx, testAddress(1), big.NewInt(3))
+	if err != nil {
+		t.Fatalf("Transfer while another call signs: %v", err)
+	}
+
+	close(signer.gate)
+	if err := <-slow; err != nil {
+		t.Fatalf("slow Transfer: %v", err)
+	}
+
+	sent := backend.sentTxs()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d transactions, want 2", len(sent))
+	}
+	if sent[0].Hash() != hash || sent[0].Nonce() != 1 {
+		t.Errorf("transfer during signing sent with nonce %d, want the released 1", sent[0].Nonce())
+	}
+	if sent[1].Nonce() != 0 {
+		t.Errorf("slow transfer sent with nonce %d, want its reserved 0", sent[1].Nonce())
+	}
+}
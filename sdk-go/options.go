@@ -0,0 +1,142 @@
+package synapse
+
+import (
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TxOption customizes a transaction submitted by a write method
+type TxOption func(*txOptions)
+
+// txOptions holds per-transaction overrides
+type txOptions struct {
+	gasLimit uint64
+	gasPrice *big.Int
+	nonce    *uint64
+	from     common.Address
+
+	feeStrategy FeeStrategy
+
+	maxFee    *big.Int
+	maxFeeBps *uint64
+	// maxFeeChecked is set by the methods that enforce maxFee and maxFeeBps
+	maxFeeChecked bool
+
+	exactApproval  bool
+	approvalBuffer *big.Int
+	correlationID  string
+	gasMultiplier  float64
+	accessList     types.AccessList
+}
+
+// WithGasLimit sets an explicit gas limit, skipping gas estimation
+func WithGasLimit(gasLimit uint64) TxOption {
+	return func(o *txOptions) {
+		o.gasLimit = gasLimit
+	}
+}
+
+// WithGasPrice sets an explicit gas price instead of the node's suggestion,
+// sending a legacy transaction even on EIP-1559 chains
+func WithGasPrice(gasPrice *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.gasPrice = gasPrice
+	}
+}
+
+// WithFeeStrategy overrides Config.FeeStrategy for the call
+func WithFeeStrategy(strategy FeeStrategy) TxOption {
+	return func(o *txOptions) {
+		o.feeStrategy = strategy
+	}
+}
+
+// WithNonce sets an explicit account nonce instead of the pending nonce
+func WithNonce(nonce uint64) TxOption {
+	return func(o *txOptions) {
+		o.nonce = &nonce
+	}
+}
+
+// WithFrom sends the call's transactions from another account of the client,
+// one added with AddAccount or Config.Accounts, instead of the default one.
+// Unknown addresses fail with ErrUnknownAccount. Argument checks that compare
+// against the sender, such as Pay rejecting payments to itself, use the
+// selected account.
+func WithFrom(address common.Address) TxOption {
+	return func(o *txOptions) {
+		o.from = address
+	}
+}
+
+// WithMaxFee makes Pay, BatchPay, CreateEscrow, CreateStream and
+// RelaySubmit fail with ErrFeeExceedsMax instead of submitting if the
+// protocol fee at current rates exceeds maxFee. For BatchPay it caps the
+// total fee. Other methods fail with ErrMaxFeeUnsupported.
+func WithMaxFee(maxFee *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.maxFee = maxFee
+	}
+}
+
+// WithMaxFeeBps is like WithMaxFee, but caps the protocol fee at bps basis
+// points of the amount
+func WithMaxFeeBps(bps uint64) TxOption {
+	return func(o *txOptions) {
+		o.maxFeeBps = &bps
+	}
+}
+
+// maxFeeChecked marks the call's protocol fee as checked against WithMaxFee
+// and WithMaxFeeBps
+func maxFeeChecked() TxOption {
+	return func(o *txOptions) {
+		o.maxFeeChecked = true
+	}
+}
+
+// WithExactApproval makes Pay and OpenChannel first approve exactly the
+// amount they need, plus buffer if non-nil, when the current allowance is
+// below that. The approval is waited on before the operation is submitted.
+func WithExactApproval(buffer *big.Int) TxOption {
+	return func(o *txOptions) {
+		o.exactApproval = true
+		o.approvalBuffer = buffer
+	}
+}
+
+// WithGasMultiplier overrides Config.GasEstimateMultiplier for the call. It
+// has no effect together with WithGasLimit or a Config.GasLimits entry.
+func WithGasMultiplier(multiplier float64) TxOption {
+	return func(o *txOptions) {
+		o.gasMultiplier = multiplier
+	}
+}
+
+// WithAccessList attaches an access list to the transaction, see
+// AccessListFor. Legacy-priced transactions are sent as EIP-2930
+// transactions.
+func WithAccessList(accessList types.AccessList) TxOption {
+	return func(o *txOptions) {
+		o.accessList = accessList
+	}
+}
+
+// WithCorrelationID tags the call's transactions with a caller-supplied ID
+// recorded in Config.Journal and Config.Logger. The ID is not sent on-chain.
+func WithCorrelationID(id string) TxOption {
+	return func(o *txOptions) {
+		o.correlationID = id
+	}
+}
+
+// applyTxOptions collects the given options
+func applyTxOptions(opts []TxOption) *txOptions {
+	o := &txOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
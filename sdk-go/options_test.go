@@ -0,0 +1,198 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestTxOptionsReachTransaction(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+
+	_, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1),
+		WithGasLimit(90_000), WithGasPrice(big.NewInt(3e9)), WithNonce(7))
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	sent := backend.sentTxs()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d transactions, want 1", len(sent))
+	}
+	tx := sent[0]
+	if tx.Gas() != 90_000 {
+		t.Errorf("gas limit = %d, want 90000", tx.Gas())
+	}
+	if tx.Type() != types.LegacyTxType || tx.GasPrice().Cmp(big.NewInt(3e9)) != 0 {
+		t.Errorf("type %d with gas price %s, want a legacy transaction at 3 gwei", tx.Type(), tx.GasPrice())
+	}
+	if tx.Nonce() != 7 {
+		t.Errorf("nonce = %d, want 7", tx.Nonce())
+	}
+}
+
+func TestGasEstimateMultiplier(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		opts   []TxOption
+		want   uint64
+	}{
+		{"default", Config{}, nil, 120_000},
+		{"configured", Config{GasEstimateMultiplier: 1.5}, nil, 150_000},
+		{"per call", Config{GasEstimateMultiplier: 1.5}, []TxOption{WithGasMultiplier(2)}, 200_000},
+		{"explicit limit", Config{}, []TxOption{WithGasLimit(90_000), WithGasMultiplier(2)}, 90_000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.estimateGas = func(ethereum.CallMsg) (uint64, error) { return 100_000, nil }
+			c := newTestClient(t, backend, tt.config)
+
+			if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1), tt.opts...); err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+			sent := backend.sentTxs()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d transactions, want 1", len(sent))
+			}
+			if sent[0].Gas() != tt.want {
+				t.Errorf("gas limit = %d, want %d", sent[0].Gas(), tt.want)
+			}
+		})
+	}
+}
+
+func TestWithAccessList(t *testing.T) {
+	accessList := types.AccessList{{
+		Address:     testContracts.Token,
+		StorageKeys: []common.Hash{{1}, {2}},
+	}}
+
+	tests := []struct {
+		name     string
+		opts     []TxOption
+		wantType uint8
+	}{
+		{"dynamic fee", nil, types.DynamicFeeTxType},
+		{"legacy gas price", []TxOption{WithGasPrice(big.NewInt(3e9))}, types.AccessListTxType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+
+			opts := append(tt.opts, WithAccessList(accessList))
+			if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1), opts...); err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+			sent := backend.sentTxs()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d transactions, want 1", len(sent))
+			}
+			if sent[0].Type() != tt.wantType {
+				t.Errorf("transaction type = %d, want %d", sent[0].Type(), tt.wantType)
+			}
+			if !reflect.DeepEqual(sent[0].AccessList(), accessList) {
+				t.Errorf("access list = %v, want %v", sent[0].AccessList(), accessList)
+			}
+		})
+	}
+}
+
+func TestGasLimitsOverrideEstimation(t *testing.T) {
+	tests := []struct {
+		name          string
+		limits        map[string]uint64
+		opts          []TxOption
+		estimate      uint64
+		want          uint64
+		wantEstimated bool
+	}{
+		{"method override", map[string]uint64{"transfer": 70_000}, nil, 50_000, 70_000, false},
+		{"override for another method", map[string]uint64{"batchPay": 900_000}, nil, 50_000, 60_000, true},
+		{"WithGasLimit wins", map[string]uint64{"transfer": 70_000}, []TxOption{WithGasLimit(80_000)}, 50_000, 80_000, false},
+		// Estimates are no longer capped by a fixed 500000 limit
+		{"large estimate", nil, nil, 1_000_000, 1_200_000, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			estimated := false
+			backend.estimateGas = func(ethereum.CallMsg) (uint64, error) {
+				estimated = true
+				return tt.estimate, nil
+			}
+			c := newTestClient(t, backend, Config{GasLimits: tt.limits})
+
+			if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1), tt.opts...); err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+			sent := backend.sentTxs()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d transactions, want 1", len(sent))
+			}
+			if sent[0].Gas() != tt.want {
+				t.Errorf("gas limit = %d, want %d", sent[0].Gas(), tt.want)
+			}
+			if estimated != tt.wantEstimated {
+				t.Errorf("estimated gas: %v, want %v", estimated, tt.wantEstimated)
+			}
+		})
+	}
+}
+
+func TestFeeStrategies(t *testing.T) {
+	// The mock's base fee and suggested tip are both 1 gwei
+	custom := FeeStrategyFunc(func(baseFee, suggestedTip *big.Int) (*big.Int, *big.Int) {
+		return big.NewInt(7e9), big.NewInt(5e8)
+	})
+
+	tests := []struct {
+		name        string
+		legacyChain bool
+		config      Config
+		opts        []TxOption
+		wantType    uint8
+		// a legacy transaction reports its gas price as both
+		wantFeeCap, wantTip int64
+	}{
+		{"standard by default", false, Config{}, nil, types.DynamicFeeTxType, 3e9, 1e9},
+		{"configured strategy", false, Config{FeeStrategy: FeeFast}, nil, types.DynamicFeeTxType, 5e9, 2e9},
+		{"slow", false, Config{FeeStrategy: FeeSlow}, nil, types.DynamicFeeTxType, 1.8e9, 8e8},
+		{"per-call custom strategy", false, Config{FeeStrategy: FeeFast}, []TxOption{WithFeeStrategy(custom)}, types.DynamicFeeTxType, 7e9, 5e8},
+		{"explicit gas price", false, Config{}, []TxOption{WithGasPrice(big.NewInt(4e9))}, types.LegacyTxType, 4e9, 4e9},
+		{"chain without a base fee", true, Config{FeeStrategy: FeeFast}, nil, types.LegacyTxType, 2e9, 2e9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			if tt.legacyChain {
+				backend.baseFee = nil
+				backend.headers[0].BaseFee = nil
+			}
+			c := newTestClient(t, backend, tt.config)
+
+			if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1), tt.opts...); err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+			sent := backend.sentTxs()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d transactions, want 1", len(sent))
+			}
+			tx := sent[0]
+			if tx.Type() != tt.wantType {
+				t.Errorf("transaction type = %d, want %d", tx.Type(), tt.wantType)
+			}
+			if tx.GasFeeCap().Int64() != tt.wantFeeCap || tx.GasTipCap().Int64() != tt.wantTip {
+				t.Errorf("fee cap %s tip %s, want %d and %d", tx.GasFeeCap(), tx.GasTipCap(), tt.wantFeeCap, tt.wantTip)
+			}
+		})
+	}
+}
@@ -0,0 +1,296 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// PaymentStatus represents payment status
+type PaymentStatus uint8
+
+const (
+	PaymentPending PaymentStatus = iota
+	PaymentCompleted
+	PaymentFailed
+	PaymentRefunded
+)
+
+// PaymentRecord is a payment recorded by the PaymentRouter
+type PaymentRecord struct {
+	PaymentID   [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	Amount      *big.Int
+	Fee         *big.Int
+	Timestamp   uint64
+	Status      PaymentStatus
+	ServiceType [32]byte
+	Metadata    string
+}
+
+// GetPayment returns a recorded payment, or ErrPaymentNotFound if the router
+// has no payment with that ID
+func (c *Client) GetPayment(ctx context.Context, paymentID [32]byte) (*PaymentRecord, error) {
+	out, err := c.callContract(ctx, ContractPaymentRouter, "getPayment", paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *abi.ConvertType(out[0], new(paymentData)).(*paymentData)
+	if data.Sender == (common.Address{}) {
+		return nil, ErrPaymentNotFound
+	}
+
+	return &PaymentRecord{
+		PaymentID:   data.PaymentId,
+		Sender:      data.Sender,
+		Recipient:   data.Recipient,
+		Amount:      data.Amount,
+		Fee:         data.Fee,
+		Timestamp:   data.Timestamp.Uint64(),
+		Status:      PaymentStatus(data.Status),
+		ServiceType: data.ServiceType,
+		Metadata:    data.Metadata,
+	}, nil
+}
+
+// WaitForPayment polls GetPayment every poll interval until the payment is
+// recorded or ctx is done. A poll of zero uses DefaultPollInterval.
+func (c *Client) WaitForPayment(ctx context.Context, paymentID [32]byte, poll time.Duration) (*PaymentRecord, error) {
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		payment, err := c.GetPayment(ctx, paymentID)
+		if err == nil {
+			return payment, nil
+		}
+		if !errors.Is(err, ErrPaymentNotFound) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PaymentEvent is a PaymentExecuted event of the PaymentRouter
+type PaymentEvent struct {
+	PaymentID   [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	Amount      *big.Int
+	Fee         *big.Int
+	ServiceType [32]byte
+	Log         types.Log
+}
+
+// SubscribePayments streams payments to recipient until ctx is cancelled,
+// resubscribing if the connection drops. Logs removed by a reorg are skipped.
+// Both channels are closed when the stream ends.
+func (c *Client) SubscribePayments(ctx context.Context, recipient common.Address) (<-chan PaymentEvent, <-chan error, error) {
+	router, err := c.contractAddress(ContractPaymentRouter)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["PaymentExecuted"]
+	logs := make(chan types.Log)
+	sub, err := c.SubscribeLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{router},
+		Topics:    [][]common.Hash{{event.ID}, nil, nil, {common.BytesToHash(recipient.Bytes())}},
+	}, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	payments := make(chan PaymentEvent)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(payments)
+		defer close(errc)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case log := <-logs:
+				if log.Removed {
+					continue
+				}
+
+				payment, err := decodeProtocolEvent(EventPaymentSent, event, log)
+				if err != nil {
+					errc <- err
+					return
+				}
+
+				select {
+				case payments <- payment.(PaymentEvent):
+				case <-ctx.Done():
+					return
+				}
+			case err := <-sub.Err():
+				errc <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return payments, errc, nil
+}
+
+const (
+	// DefaultPaymentHandlerAttempts is the number of times OnPaymentReceived
+	// calls the handler for one payment before dead-lettering it
+	DefaultPaymentHandlerAttempts = 5
+
+	// DefaultPaymentConfirmations is how many blocks deep OnPaymentReceived
+	// waits for a payment to be before handling it
+	DefaultPaymentConfirmations = 3
+
+	// paymentRetryMinBackoff is the delay before the handler is retried
+	paymentRetryMinBackoff = time.Second
+	// paymentRetryMaxBackoff caps the delay between handler retries
+	paymentRetryMaxBackoff = time.Minute
+)
+
+// OnPaymentReceived calls handler for each payment to the client's address
+// until ctx is cancelled or the subscription fails, and returns that error.
+// Payments are handled one at a time, once they are
+// Config.PaymentConfirmations blocks deep. A payment whose block is then no
+// longer canonical is skipped, as the subscription delivers it again if it
+// is re-included. If handler returns an error it is retried with exponential
+// backoff, up to Config.PaymentHandlerAttempts calls; the payment is then
+// passed to Config.PaymentDeadLetter and the next one is handled.
+func (c *Client) OnPaymentReceived(ctx context.Context, handler func(PaymentEvent) error) error {
+	payments, errc, err := c.SubscribePayments(ctx, c.address)
+	if err != nil {
+		return err
+	}
+
+	confirmations := c.config.PaymentConfirmations
+	if confirmations == 0 {
+		confirmations = DefaultPaymentConfirmations
+	}
+
+	for payment := range payments {
+		err := c.waitForCanonicalLog(ctx, payment.Log, confirmations)
+		if errors.Is(err, ErrReorgDetected) {
+			c.logDebug(ctx, "skipping reorganized payment",
+				slog.String("paymentId", common.Hash(payment.PaymentID).Hex()),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := c.handlePayment(ctx, handler, payment); err != nil {
+			return err
+		}
+	}
+
+	if err := <-errc; err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// waitForCanonicalLog waits until log is confirmations blocks deep (1 means
+// mined) and returns ErrReorgDetected if its block is then no longer
+// canonical
+func (c *Client) waitForCanonicalLog(ctx context.Context, log types.Log, confirmations uint64) error {
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		head, err := c.client.BlockNumber(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get block number: %w", err)
+		}
+
+		if head+1 >= log.BlockNumber+confirmations {
+			header, err := c.client.HeaderByNumber(ctx, new(big.Int).SetUint64(log.BlockNumber))
+			if err != nil {
+				return fmt.Errorf("failed to get header: %w", err)
+			}
+			if header.Hash() != log.BlockHash {
+				return fmt.Errorf("%w: block %d of log in %s is no longer canonical", ErrReorgDetected, log.BlockNumber, log.TxHash.Hex())
+			}
+			return nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// handlePayment runs handler for one payment with retries, dead-lettering the
+// payment if every attempt fails. It only returns an error if ctx is done.
+func (c *Client) handlePayment(ctx context.Context, handler func(PaymentEvent) error, payment PaymentEvent) error {
+	attempts := c.config.PaymentHandlerAttempts
+	if attempts <= 0 {
+		attempts = DefaultPaymentHandlerAttempts
+	}
+
+	backoff := paymentRetryMinBackoff
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = handler(payment); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		c.logDebug(ctx, "payment handler failed",
+			slog.String("paymentId", common.Hash(payment.PaymentID).Hex()),
+			slog.Int("attempt", attempt),
+			slog.String("error", err.Error()),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > paymentRetryMaxBackoff {
+			backoff = paymentRetryMaxBackoff
+		}
+	}
+
+	err = fmt.Errorf("payment handler failed after %d attempts: %w", attempts, err)
+	c.logDebug(ctx, "dead-lettering payment",
+		slog.String("paymentId", common.Hash(payment.PaymentID).Hex()),
+		slog.String("error", err.Error()),
+	)
+	if c.config.PaymentDeadLetter != nil {
+		c.config.PaymentDeadLetter(payment, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestWaitForCanonicalLog(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	backend.addLog(types.Log{Address: testContracts.PaymentRouter})
+	backend.mine()
+	logs, _ := backend.FilterLogs(context.Background(), ethereum.FilterQuery{})
+	payment := logs[0]
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := c.waitForCanonicalLog(ctx, payment, 3); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error before 3 confirmations = %v, want the wait to time out", err)
+	}
+
+	backend.mine()
+	if err := c.waitForCanonicalLog(context.Background(), payment, 3); err != nil {
+		t.Fatalf("waitForCanonicalLog: %v", err)
+	}
+
+	reorged := payment
+	reorged.BlockHash = common.Hash{1}
+	if err := c.waitForCanonicalLog(context.Background(), reorged, 3); !errors.Is(err, ErrReorgDetected) {
+		t.Errorf("error for a log off the canonical chain = %v, want ErrReorgDetected", err)
+	}
+}
+
+func TestWaitForPayment(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	paymentID := [32]byte{7}
+	polls := 0
+	backend.handle(testContracts.PaymentRouter, paymentRouterABI, "getPayment", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		polls++
+		payment := paymentData{Amount: new(big.Int), Fee: new(big.Int), Timestamp: new(big.Int)}
+		// The payment lands on the third poll
+		if args[0].([32]byte) == paymentID && polls >= 3 {
+			payment = paymentData{
+				PaymentId: paymentID,
+				Sender:    testAddress(1),
+				Recipient: c.Address(),
+				Amount:    big.NewInt(500),
+				Fee:       big.NewInt(1),
+				Timestamp: big.NewInt(1_700_000_000),
+				Status:    uint8(PaymentCompleted),
+			}
+		}
+		return []interface{}{payment}, nil
+	})
+
+	payment, err := c.WaitForPayment(context.Background(), paymentID, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForPayment: %v", err)
+	}
+	if polls != 3 {
+		t.Errorf("polled %d times, want 3", polls)
+	}
+	if payment.PaymentID != paymentID || payment.Sender != testAddress(1) || payment.Amount.Int64() != 500 || payment.Status != PaymentCompleted {
+		t.Errorf("payment = %+v", payment)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := c.WaitForPayment(ctx, [32]byte{8}, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForPayment for a payment that never lands: err = %v, want a timeout", err)
+	}
+}
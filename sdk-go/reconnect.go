@@ -0,0 +1,305 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// redialBackend is the Backend used for HTTP RPC URLs. When a call fails with
+// a connection-level error it re-dials the endpoint and retries the call once,
+// after the client has re-verified the chain ID on the new connection.
+// SendTransaction is never retried, since the node may have received the
+// transaction before the connection failed.
+type redialBackend struct {
+	url     string
+	redials atomic.Uint64
+
+	// onRedial, if set, is called after the endpoint is re-dialed and before
+	// the failed call is retried. An error fails the call instead.
+	onRedial func(ctx context.Context) error
+
+	mu     sync.RWMutex
+	client *ethclient.Client
+}
+
+// dialRedialBackend connects to rawURL
+func dialRedialBackend(rawURL string) (*redialBackend, error) {
+	client, err := ethclient.Dial(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redialBackend{url: rawURL, client: client}, nil
+}
+
+// current returns the active connection
+func (b *redialBackend) current() *ethclient.Client {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.client
+}
+
+// noRedialKey marks the context of onRedial, whose own calls are not
+// re-dialed so that a still failing endpoint cannot recurse into onRedial
+type noRedialKey struct{}
+
+// redial replaces the failed connection, unless another call already has,
+// and runs onRedial for a new connection
+func (b *redialBackend) redial(ctx context.Context, failed *ethclient.Client) error {
+	replaced, err := b.replace(ctx, failed)
+	if err != nil || !replaced || b.onRedial == nil {
+		return err
+	}
+	return b.onRedial(context.WithValue(ctx, noRedialKey{}, true))
+}
+
+// replace dials a new connection in place of failed, unless another call
+// already has, and reports whether it did
+func (b *redialBackend) replace(ctx context.Context, failed *ethclient.Client) (bool, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.client != failed {
+		return false, nil
+	}
+
+	client, err := ethclient.DialContext(ctx, b.url)
+	if err != nil {
+		return false, err
+	}
+
+	b.client.Close()
+	b.client = client
+	b.redials.Add(1)
+
+	return true, nil
+}
+
+// Client returns the RPC client of the active connection
+func (b *redialBackend) Client() *rpc.Client {
+	return b.current().Client()
+}
+
+// do runs fn, re-dialing and retrying once on a connection error
+func (b *redialBackend) do(ctx context.Context, fn func(*ethclient.Client) error) error {
+	client := b.current()
+	err := fn(client)
+	if err == nil || !isConnectionError(err) || ctx.Err() != nil || ctx.Value(noRedialKey{}) != nil {
+		return err
+	}
+
+	if redialErr := b.redial(ctx, client); redialErr != nil {
+		if errors.Is(redialErr, ErrChainIDChanged) {
+			return redialErr
+		}
+		return err
+	}
+	return fn(b.current())
+}
+
+// isConnectionError reports whether err is a transport failure rather than an
+// error returned by the node
+func isConnectionError(err error) bool {
+	var netErr net.Error
+	var urlErr *url.Error
+	return errors.As(err, &netErr) ||
+		errors.As(err, &urlErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// Close closes the active connection
+func (b *redialBackend) Close() {
+	b.current().Close()
+}
+
+func (b *redialBackend) BlockNumber(ctx context.Context) (n uint64, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { n, err = c.BlockNumber(ctx); return })
+	return
+}
+
+func (b *redialBackend) BlockByHash(ctx context.Context, hash common.Hash) (block *types.Block, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { block, err = c.BlockByHash(ctx, hash); return })
+	return
+}
+
+func (b *redialBackend) BlockByNumber(ctx context.Context, number *big.Int) (block *types.Block, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { block, err = c.BlockByNumber(ctx, number); return })
+	return
+}
+
+func (b *redialBackend) HeaderByHash(ctx context.Context, hash common.Hash) (header *types.Header, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { header, err = c.HeaderByHash(ctx, hash); return })
+	return
+}
+
+func (b *redialBackend) HeaderByNumber(ctx context.Context, number *big.Int) (header *types.Header, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { header, err = c.HeaderByNumber(ctx, number); return })
+	return
+}
+
+func (b *redialBackend) TransactionCount(ctx context.Context, blockHash common.Hash) (n uint, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { n, err = c.TransactionCount(ctx, blockHash); return })
+	return
+}
+
+func (b *redialBackend) TransactionInBlock(ctx context.Context, blockHash common.Hash, index uint) (tx *types.Transaction, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { tx, err = c.TransactionInBlock(ctx, blockHash, index); return })
+	return
+}
+
+func (b *redialBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return b.current().SubscribeNewHead(ctx, ch)
+}
+
+func (b *redialBackend) BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (balance *big.Int, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { balance, err = c.BalanceAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (b *redialBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) (value []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) {
+		value, err = c.StorageAt(ctx, account, key, blockNumber)
+		return
+	})
+	return
+}
+
+func (b *redialBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) (code []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { code, err = c.CodeAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (b *redialBackend) NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (nonce uint64, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { nonce, err = c.NonceAt(ctx, account, blockNumber); return })
+	return
+}
+
+func (b *redialBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) (out []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { out, err = c.CallContract(ctx, msg, blockNumber); return })
+	return
+}
+
+func (b *redialBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (gas uint64, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { gas, err = c.EstimateGas(ctx, msg); return })
+	return
+}
+
+func (b *redialBackend) SuggestGasPrice(ctx context.Context) (price *big.Int, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { price, err = c.SuggestGasPrice(ctx); return })
+	return
+}
+
+func (b *redialBackend) SuggestGasTipCap(ctx context.Context) (tip *big.Int, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { tip, err = c.SuggestGasTipCap(ctx); return })
+	return
+}
+
+func (b *redialBackend) FeeHistory(ctx context.Context, blockCount uint64, lastBlock *big.Int, rewardPercentiles []float64) (history *ethereum.FeeHistory, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) {
+		history, err = c.FeeHistory(ctx, blockCount, lastBlock, rewardPercentiles)
+		return
+	})
+	return
+}
+
+func (b *redialBackend) FilterLogs(ctx context.Context, query ethereum.FilterQuery) (logs []types.Log, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { logs, err = c.FilterLogs(ctx, query); return })
+	return
+}
+
+func (b *redialBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return b.current().SubscribeFilterLogs(ctx, query, ch)
+}
+
+func (b *redialBackend) PendingBalanceAt(ctx context.Context, account common.Address) (balance *big.Int, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { balance, err = c.PendingBalanceAt(ctx, account); return })
+	return
+}
+
+func (b *redialBackend) PendingStorageAt(ctx context.Context, account common.Address, key common.Hash) (value []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { value, err = c.PendingStorageAt(ctx, account, key); return })
+	return
+}
+
+func (b *redialBackend) PendingCodeAt(ctx context.Context, account common.Address) (code []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { code, err = c.PendingCodeAt(ctx, account); return })
+	return
+}
+
+func (b *redialBackend) PendingNonceAt(ctx context.Context, account common.Address) (nonce uint64, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { nonce, err = c.PendingNonceAt(ctx, account); return })
+	return
+}
+
+func (b *redialBackend) PendingTransactionCount(ctx context.Context) (n uint, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { n, err = c.PendingTransactionCount(ctx); return })
+	return
+}
+
+func (b *redialBackend) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) (out []byte, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { out, err = c.PendingCallContract(ctx, msg); return })
+	return
+}
+
+func (b *redialBackend) TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { tx, isPending, err = c.TransactionByHash(ctx, hash); return })
+	return
+}
+
+func (b *redialBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (receipt *types.Receipt, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { receipt, err = c.TransactionReceipt(ctx, txHash); return })
+	return
+}
+
+func (b *redialBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	client := b.current()
+	err := client.SendTransaction(ctx, tx)
+	if err != nil && isConnectionError(err) && ctx.Err() == nil && ctx.Value(noRedialKey{}) == nil {
+		b.redial(ctx, client)
+	}
+	return err
+}
+
+func (b *redialBackend) ChainID(ctx context.Context) (id *big.Int, err error) {
+	err = b.do(ctx, func(c *ethclient.Client) (err error) { id, err = c.ChainID(ctx); return })
+	return
+}
+
+// afterRedial logs a re-dial of the RPC endpoint and re-verifies the chain
+// ID, since the endpoint may now resolve to a different node
+func (c *Client) afterRedial(ctx context.Context) error {
+	c.logDebug(ctx, "re-dialed RPC endpoint", slog.Uint64("reconnects", c.RPCReconnects()))
+
+	c.chainMu.Lock()
+	c.chainIDCheckedAt = time.Time{}
+	c.chainMu.Unlock()
+
+	return c.checkChainID(ctx)
+}
+
+// RPCReconnects returns how many times the client has re-dialed its HTTP RPC
+// endpoint after a connection error
+func (c *Client) RPCReconnects() uint64 {
+	if b, ok := c.client.(*redialBackend); ok {
+		return b.redials.Load()
+	}
+	return 0
+}
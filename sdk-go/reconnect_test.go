@@ -0,0 +1,131 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyRPC is a JSON-RPC endpoint that can drop the connection of its next
+// request and change the chain ID it reports
+type flakyRPC struct {
+	chainID  atomic.Value
+	dropNext atomic.Bool
+}
+
+func newFlakyRPC(t *testing.T) (*flakyRPC, *httptest.Server) {
+	t.Helper()
+
+	rpc := &flakyRPC{}
+	rpc.chainID.Store("0x539")
+	server := httptest.NewServer(rpc)
+	t.Cleanup(server.Close)
+	return rpc, server
+}
+
+func (f *flakyRPC) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.dropNext.CompareAndSwap(true, false) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			conn.Close()
+		}
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	var result interface{}
+	switch req.Method {
+	case "eth_chainId":
+		result = f.chainID.Load()
+	case "eth_blockNumber":
+		result = "0x10"
+	default:
+		http.Error(w, "unsupported method", http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestRedialRetriesDroppedConnection(t *testing.T) {
+	rpc, server := newFlakyRPC(t)
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c, err := NewClient(Config{RPCURL: server.URL, Logger: logger})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer c.Close()
+
+	rpc.dropNext.Store(true)
+	n, err := c.client.BlockNumber(context.Background())
+	if err != nil {
+		t.Fatalf("BlockNumber after dropped connection: %v", err)
+	}
+	if n != 0x10 {
+		t.Errorf("BlockNumber = %d, want 16", n)
+	}
+	if got := c.RPCReconnects(); got != 1 {
+		t.Errorf("RPCReconnects = %d, want 1", got)
+	}
+	if !strings.Contains(logs.String(), "re-dialed RPC endpoint") {
+		t.Errorf("redial not logged, logs:\n%s", logs.String())
+	}
+}
+
+func TestRedialRejectsChangedChainID(t *testing.T) {
+	rpc, server := newFlakyRPC(t)
+
+	c, err := NewClient(Config{RPCURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer c.Close()
+
+	rpc.chainID.Store("0x1")
+	rpc.dropNext.Store(true)
+	if _, err := c.client.BlockNumber(context.Background()); !errors.Is(err, ErrChainIDChanged) {
+		t.Fatalf("BlockNumber error = %v, want ErrChainIDChanged", err)
+	}
+}
+
+func TestChangedChainIDBlocksSigning(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1)); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	// The node fails over to another network, and the last check is stale
+	backend.mu.Lock()
+	backend.chainID = big.NewInt(1)
+	backend.mu.Unlock()
+	c.chainMu.Lock()
+	c.chainIDCheckedAt = time.Time{}
+	c.chainMu.Unlock()
+
+	if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1)); !errors.Is(err, ErrChainIDChanged) {
+		t.Fatalf("Transfer: err = %v, want ErrChainIDChanged", err)
+	}
+	if sent := len(backend.sentTxs()); sent != 1 {
+		t.Errorf("sent %d transactions, want only the one before the change", sent)
+	}
+	if err := c.VerifyChainID(context.Background()); !errors.Is(err, ErrChainIDChanged) {
+		t.Errorf("VerifyChainID: err = %v, want ErrChainIDChanged", err)
+	}
+}
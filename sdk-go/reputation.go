@@ -0,0 +1,281 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ReputationReason is the cause of a reputation change
+type ReputationReason uint8
+
+const (
+	ReputationTransactionSucceeded ReputationReason = iota + 1
+	ReputationTransactionFailed
+	ReputationRated
+	ReputationTierChanged
+)
+
+// ReputationEvent is a change to an agent's reputation. OldScore and NewScore
+// are read at the blocks before and of the event, so several changes in one
+// block all report the block's net change.
+type ReputationEvent struct {
+	Agent    common.Address
+	Reason   ReputationReason
+	OldScore uint64
+	NewScore uint64
+	Tier     Tier
+	// Rating is set for ReputationRated, Amount for transaction events
+	Rating uint8
+	Amount *big.Int
+	Log    types.Log
+}
+
+// reputationEvents are the Reputation events that change an agent's score
+var reputationEvents = []string{"TransactionRecorded", "ServiceRated", "AgentUpdated"}
+
+// SubscribeReputation streams reputation changes of an agent until ctx is
+// cancelled, resubscribing if the connection drops. Logs removed by a reorg
+// are skipped. Both channels are closed
+// when the stream ends.
+func (c *Client) SubscribeReputation(ctx context.Context, agent common.Address) (<-chan ReputationEvent, <-chan error, error) {
+	reputation, err := c.contractAddress(ContractReputation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reputationABI := c.contractABI(ContractReputation)
+	var ids []common.Hash
+	for _, name := range reputationEvents {
+		ids = append(ids, reputationABI.Events[name].ID)
+	}
+
+	logs := make(chan types.Log)
+	sub, err := c.SubscribeLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{reputation},
+		Topics:    [][]common.Hash{ids, {common.BytesToHash(agent.Bytes())}},
+	}, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	events := make(chan ReputationEvent)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(events)
+		defer close(errc)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case log := <-logs:
+				if log.Removed {
+					continue
+				}
+
+				event, err := c.decodeReputationEvent(ctx, reputationABI, log)
+				if err != nil {
+					errc <- err
+					return
+				}
+
+				select {
+				case events <- *event:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-sub.Err():
+				errc <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, errc, nil
+}
+
+// decodeReputationEvent decodes a reputation log and reads the agent's score
+// around it
+func (c *Client) decodeReputationEvent(ctx context.Context, reputationABI abi.ABI, log types.Log) (*ReputationEvent, error) {
+	event, err := reputationABI.EventByID(log.Topics[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to identify reputation event: %w", err)
+	}
+
+	fields, err := decodeEvent(*event, log)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ReputationEvent{
+		Agent: fields["agent"].(common.Address),
+		Log:   log,
+	}
+	switch event.Name {
+	case "TransactionRecorded":
+		result.Reason = ReputationTransactionFailed
+		if fields["success"].(bool) {
+			result.Reason = ReputationTransactionSucceeded
+		}
+		result.Amount = fields["amount"].(*big.Int)
+	case "ServiceRated":
+		result.Reason = ReputationRated
+		result.Rating = fields["rating"].(uint8)
+	case "AgentUpdated":
+		result.Reason = ReputationTierChanged
+	}
+
+	block := new(big.Int).SetUint64(log.BlockNumber)
+	after, err := c.getAgentAt(ctx, result.Agent, block)
+	if err != nil {
+		return nil, err
+	}
+	before, err := c.getAgentAt(ctx, result.Agent, block.Sub(block, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+
+	result.OldScore = before.ReputationScore.Uint64()
+	result.NewScore = after.ReputationScore.Uint64()
+	result.Tier = Tier(after.Tier)
+
+	return result, nil
+}
+
+// getAgentAt reads an agent's record as of a block
+func (c *Client) getAgentAt(ctx context.Context, agent common.Address, block *big.Int) (*agentData, error) {
+	out, err := c.callContractOpts(&bind.CallOpts{Context: ctx, BlockNumber: block}, ContractReputation, "getAgent", agent)
+	if err != nil {
+		return nil, err
+	}
+
+	return abi.ConvertType(out[0], new(agentData)).(*agentData), nil
+}
+
+// maxReputationScore mirrors ReputationRegistry.MAX_SCORE
+const maxReputationScore = 1000000
+
+// EstimateReputationDelta projects how recording one transaction of amount
+// would change an agent's reputation score and tier, following
+// ReputationRegistry.recordTransaction: a success adds 10 points plus one per
+// whole SYNX, a failure removes 50 plus two per whole SYNX, the score stays
+// within [0, MAX_SCORE], and the tier is re-evaluated against the updated
+// transaction counts. Nothing is submitted.
+func (c *Client) EstimateReputationDelta(ctx context.Context, agent common.Address, success bool, amount *big.Int) (int64, Tier, error) {
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	out, err := c.callContract(ctx, ContractReputation, "getAgent", agent)
+	if err != nil {
+		return 0, 0, err
+	}
+	data := *abi.ConvertType(out[0], new(agentData)).(*agentData)
+	if data.Status == agentStatusUnregistered {
+		return 0, 0, fmt.Errorf("%w: %s", ErrNotRegistered, agent.Hex())
+	}
+
+	wholeSYNX := new(big.Int).Quo(amount, big.NewInt(1e18))
+	score := new(big.Int).Set(data.ReputationScore)
+	if success {
+		score.Add(score, wholeSYNX.Add(wholeSYNX, big.NewInt(10)))
+		if score.Cmp(big.NewInt(maxReputationScore)) > 0 {
+			score.SetInt64(maxReputationScore)
+		}
+	} else {
+		points := new(big.Int).Quo(new(big.Int).Mul(amount, big.NewInt(2)), big.NewInt(1e18))
+		score.Sub(score, points.Add(points, big.NewInt(50)))
+		if score.Sign() < 0 {
+			score.SetInt64(0)
+		}
+	}
+	delta := new(big.Int).Sub(score, data.ReputationScore).Int64()
+
+	total := new(big.Int).Add(data.TotalTransactions, big.NewInt(1))
+	successful := new(big.Int).Set(data.SuccessfulTransactions)
+	if success {
+		successful.Add(successful, big.NewInt(1))
+	}
+	successRate := new(big.Int).Quo(new(big.Int).Mul(successful, big.NewInt(10000)), total)
+
+	tier := TierUnverified
+	for candidate := TierDiamond; candidate >= TierBronze; candidate-- {
+		out, err := c.callContract(ctx, ContractReputation, "getTierRequirements", uint8(candidate))
+		if err != nil {
+			return 0, 0, err
+		}
+		requirements := *abi.ConvertType(out[0], new(tierRequirementsData)).(*tierRequirementsData)
+
+		if total.Cmp(requirements.MinTransactions) >= 0 &&
+			successRate.Cmp(requirements.MinSuccessRate) >= 0 &&
+			data.StakedAmount.Cmp(requirements.MinStake) >= 0 {
+			tier = candidate
+			break
+		}
+	}
+
+	return delta, tier, nil
+}
+
+// RatingSummary aggregates the ratings of a provider in a category
+type RatingSummary struct {
+	Average float64
+	Count   uint64
+	// Distribution counts the ratings of each star value, index 0 being one
+	// star
+	Distribution [5]uint64
+}
+
+// GetServiceRating returns a provider's rating in a category. Average and
+// Count are read from the Reputation contract; the distribution is rebuilt
+// from ServiceRated events since Config.StartBlock.
+func (c *Client) GetServiceRating(ctx context.Context, provider common.Address, category string) (*RatingSummary, error) {
+	serviceType := CategoryID(category)
+
+	out, err := c.callContract(ctx, ContractReputation, "getServiceRating", provider, serviceType)
+	if err != nil {
+		return nil, err
+	}
+	data := *abi.ConvertType(out[0], new(serviceRatingData)).(*serviceRatingData)
+
+	summary := &RatingSummary{
+		Count: data.TotalRatings.Uint64(),
+	}
+	if summary.Count > 0 {
+		summary.Average = float64(data.SumRatings.Uint64()) / float64(summary.Count)
+	}
+
+	reputation, err := c.contractAddress(ContractReputation)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractReputation).Events["ServiceRated"]
+	logs, err := c.filterLogsChunked(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{reputation},
+		Topics:    [][]common.Hash{{event.ID}, {common.BytesToHash(provider.Bytes())}, {serviceType}},
+	}, c.config.StartBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, log := range logs {
+		fields, err := decodeEvent(event, log)
+		if err != nil {
+			return nil, err
+		}
+		if rating := fields["rating"].(uint8); rating >= 1 && rating <= 5 {
+			summary.Distribution[rating-1]++
+		}
+	}
+
+	return summary, nil
+}
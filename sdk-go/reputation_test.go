@@ -0,0 +1,262 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// logFeedBackend delivers the logs sent to feed to log subscriptions, and
+// reports agents with a reputation score of ten times the block number
+type logFeedBackend struct {
+	*mockBackend
+	feed chan types.Log
+}
+
+func (b *logFeedBackend) SubscribeFilterLogs(ctx context.Context, query ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case log := <-b.feed:
+				select {
+				case ch <- log:
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func (b *logFeedBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	getAgent := reputationABI.Methods["getAgent"]
+	if block == nil || !bytes.HasPrefix(msg.Data, getAgent.ID) {
+		return b.mockBackend.CallContract(ctx, msg, block)
+	}
+	args, _ := getAgent.Inputs.Unpack(msg.Data[4:])
+	agent := testAgent(args[0].(common.Address), 0, 0)
+	agent.ReputationScore = new(big.Int).Mul(block, big.NewInt(10))
+	agent.Tier = uint8(TierSilver)
+	return getAgent.Outputs.Pack(agent)
+}
+
+func TestSubscribeReputation(t *testing.T) {
+	backend := &logFeedBackend{mockBackend: newMockBackend(), feed: make(chan types.Log)}
+	c, err := NewClientWithBackend(backend, Config{Contracts: testContracts, WatchMode: WatchModeSubscribe})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	agent := testAddress(1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, errc, err := c.SubscribeReputation(ctx, agent)
+	if err != nil {
+		t.Fatalf("SubscribeReputation: %v", err)
+	}
+
+	agentTopic := common.BytesToHash(agent.Bytes())
+	at := func(log *types.Log, block uint64) types.Log {
+		log.BlockNumber = block
+		return *log
+	}
+	removed := at(eventLog(testContracts.Reputation, reputationABI, "AgentUpdated", []common.Hash{agentTopic}, big.NewInt(0), uint8(0)), 6)
+	removed.Removed = true
+	logs := []types.Log{
+		at(eventLog(testContracts.Reputation, reputationABI, "TransactionRecorded", []common.Hash{agentTopic, {1}}, true, big.NewInt(500)), 5),
+		removed,
+		at(eventLog(testContracts.Reputation, reputationABI, "ServiceRated", []common.Hash{agentTopic, {2}, common.BytesToHash(testAddress(2).Bytes())}, uint8(4)), 7),
+		at(eventLog(testContracts.Reputation, reputationABI, "AgentUpdated", []common.Hash{agentTopic}, big.NewInt(80), uint8(TierSilver)), 8),
+	}
+	go func() {
+		for _, log := range logs {
+			backend.feed <- log
+		}
+	}()
+
+	want := []struct {
+		reason ReputationReason
+		block  uint64
+	}{
+		{ReputationTransactionSucceeded, 5},
+		{ReputationRated, 7},
+		{ReputationTierChanged, 8},
+	}
+	for _, w := range want {
+		select {
+		case got := <-events:
+			if got.Agent != agent || got.Reason != w.reason || got.Log.BlockNumber != w.block {
+				t.Errorf("event = reason %d at block %d, want reason %d at block %d", got.Reason, got.Log.BlockNumber, w.reason, w.block)
+			}
+			if got.OldScore != (w.block-1)*10 || got.NewScore != w.block*10 || got.Tier != TierSilver {
+				t.Errorf("block %d: score %d -> %d tier %v, want %d -> %d", w.block, got.OldScore, got.NewScore, got.Tier, (w.block-1)*10, w.block*10)
+			}
+			if w.reason == ReputationTransactionSucceeded && (got.Amount == nil || got.Amount.Int64() != 500) {
+				t.Errorf("Amount = %v, want 500", got.Amount)
+			}
+			if w.reason == ReputationRated && got.Rating != 4 {
+				t.Errorf("Rating = %d, want 4", got.Rating)
+			}
+		case err := <-errc:
+			t.Fatalf("stream failed: %v", err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a reputation event")
+		}
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("events channel delivered after cancellation")
+	}
+}
+
+func TestEstimateReputationDelta(t *testing.T) {
+	threeSYNX := new(big.Int).Mul(big.NewInt(3), big.NewInt(1e18))
+
+	// The agent is one transaction short of Silver, which needs 50
+	// transactions at a 90% success rate
+	tests := []struct {
+		name      string
+		score     int64
+		success   bool
+		wantDelta int64
+		wantTier  Tier
+	}{
+		{"success reaches Silver", 500, true, 13, TierSilver},
+		{"failure stays Bronze", 500, false, -56, TierBronze},
+		{"failure floors the score at zero", 20, false, -20, TierBronze},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			agent := testAgent(testAddress(1), 49, 44)
+			agent.ReputationScore = big.NewInt(tt.score)
+			agent.Tier = uint8(TierBronze)
+			withAgents(backend, agent)
+			backend.handle(testContracts.Reputation, reputationABI, "getTierRequirements", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+				requirements := tierRequirementsData{MinTransactions: big.NewInt(1e9), MinSuccessRate: new(big.Int), MinStake: new(big.Int), FeeDiscount: new(big.Int)}
+				switch Tier(args[0].(uint8)) {
+				case TierBronze:
+					requirements.MinTransactions, requirements.MinSuccessRate = big.NewInt(10), big.NewInt(8000)
+				case TierSilver:
+					requirements.MinTransactions, requirements.MinSuccessRate = big.NewInt(50), big.NewInt(9000)
+				}
+				return []interface{}{requirements}, nil
+			})
+
+			delta, tier, err := c.EstimateReputationDelta(context.Background(), agent.Owner, tt.success, threeSYNX)
+			if err != nil {
+				t.Fatalf("EstimateReputationDelta: %v", err)
+			}
+			if delta != tt.wantDelta || tier != tt.wantTier {
+				t.Errorf("delta %d tier %d, want %d and %d", delta, tier, tt.wantDelta, tt.wantTier)
+			}
+			if n := len(backend.sentTxs()); n != 0 {
+				t.Errorf("sent %d transactions for a projection", n)
+			}
+		})
+	}
+
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	withAgents(backend)
+	if _, _, err := c.EstimateReputationDelta(context.Background(), testAddress(2), true, threeSYNX); err == nil {
+		t.Error("EstimateReputationDelta succeeded for an unregistered agent")
+	}
+}
+
+func TestBatchRateServices(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	ratings := []ServiceRating{
+		{testAddress(1), "inference", 5},
+		{testAddress(2), "storage", 3},
+		{testAddress(3), "inference", 1},
+	}
+
+	invalid := append(ratings[:2:2], ServiceRating{testAddress(3), "inference", 6})
+	var validation *ValidationError
+	if _, err := c.BatchRateServices(context.Background(), invalid); !errors.As(err, &validation) {
+		t.Fatalf("BatchRateServices with a rating of 6: err = %v, want a *ValidationError", err)
+	}
+	if n := len(backend.sentTxs()); n != 0 {
+		t.Fatalf("sent %d ratings from an invalid batch", n)
+	}
+
+	hashes, err := c.BatchRateServices(context.Background(), ratings)
+	if err != nil {
+		t.Fatalf("BatchRateServices: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(hashes) != len(ratings) || len(sent) != len(ratings) {
+		t.Fatalf("returned %d hashes for %d transactions, want %d", len(hashes), len(sent), len(ratings))
+	}
+	rateService := reputationABI.Methods["rateService"]
+	for i, tx := range sent {
+		if tx.Hash() != hashes[i] || tx.Nonce() != uint64(i) {
+			t.Errorf("transaction %d = %s nonce %d, want %s nonce %d", i, tx.Hash().Hex(), tx.Nonce(), hashes[i].Hex(), i)
+		}
+		args, err := rateService.Inputs.Unpack(tx.Data()[4:])
+		if err != nil {
+			t.Fatalf("unpack rateService: %v", err)
+		}
+		want := ratings[i]
+		if args[0] != want.Provider || args[1] != CategoryID(want.Category) || args[2] != want.Rating {
+			t.Errorf("rating %d = rateService(%v, %x, %v), want %+v", i, args[0], args[1], args[2], want)
+		}
+	}
+}
+
+func TestGetServiceRating(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	provider := testAddress(1)
+	rate := func(category string, rater int, rating uint8) {
+		backend.addLog(*eventLog(testContracts.Reputation, reputationABI, "ServiceRated",
+			[]common.Hash{common.BytesToHash(provider.Bytes()), CategoryID(category), common.BytesToHash(testAddress(rater).Bytes())},
+			rating,
+		))
+	}
+	rate("inference", 2, 5)
+	rate("inference", 3, 4)
+	rate("storage", 4, 1)
+	rate("inference", 5, 4)
+	rate("inference", 6, 2)
+	backend.handle(testContracts.Reputation, reputationABI, "getServiceRating", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		rating := serviceRatingData{TotalRatings: new(big.Int), SumRatings: new(big.Int), AverageRating: new(big.Int)}
+		if args[1].([32]byte) == CategoryID("inference") {
+			rating = serviceRatingData{TotalRatings: big.NewInt(4), SumRatings: big.NewInt(15), AverageRating: big.NewInt(375)}
+		}
+		return []interface{}{rating}, nil
+	})
+
+	summary, err := c.GetServiceRating(context.Background(), provider, "inference")
+	if err != nil {
+		t.Fatalf("GetServiceRating: %v", err)
+	}
+	if summary.Average != 3.75 || summary.Count != 4 {
+		t.Errorf("average %v over %d ratings, want 3.75 over 4", summary.Average, summary.Count)
+	}
+	if want := [5]uint64{0, 1, 0, 2, 1}; summary.Distribution != want {
+		t.Errorf("Distribution = %v, want %v", summary.Distribution, want)
+	}
+
+	unrated, err := c.GetServiceRating(context.Background(), provider, "translation")
+	if err != nil {
+		t.Fatalf("GetServiceRating: %v", err)
+	}
+	if unrated.Average != 0 || unrated.Count != 0 || unrated.Distribution != [5]uint64{} {
+		t.Errorf("unrated category = %+v, want an empty summary", unrated)
+	}
+}
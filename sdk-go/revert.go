@@ -0,0 +1,209 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// CustomError is a Solidity custom error decoded from revert data
+type CustomError struct {
+	Name string
+	Args map[string]interface{}
+}
+
+func (e *CustomError) String() string {
+	keys := make([]string, 0, len(e.Args))
+	for key := range e.Args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := make([]string, len(keys))
+	for i, key := range keys {
+		args[i] = fmt.Sprintf("%s=%v", key, e.Args[key])
+	}
+	return e.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// RevertError is returned when a contract call or transaction reverts with
+// revert data. Reason holds a require message and Custom a custom error
+// declared in one of the protocol contracts' ABIs; if neither matches, only
+// Data is set.
+//
+// Reverts that correspond to a sentinel error match it with errors.Is, e.g.
+// a ChannelNotOpen revert matches ErrChannelNotOpen.
+type RevertError struct {
+	Reason string
+	Custom *CustomError
+	Data   []byte
+	Err    error
+}
+
+func (e *RevertError) Error() string {
+	switch {
+	case e.Custom != nil:
+		return "execution reverted: " + e.Custom.String()
+	case e.Reason != "":
+		return "execution reverted: " + e.Reason
+	default:
+		return e.Err.Error()
+	}
+}
+
+// Unwrap returns the underlying RPC error
+func (e *RevertError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error the revert corresponds to
+func (e *RevertError) Is(target error) bool {
+	sentinel := e.sentinel()
+	return sentinel != nil && sentinel == target
+}
+
+// customErrorSentinels maps the protocol contracts' custom errors to
+// sentinel errors
+var customErrorSentinels = map[string]error{
+	"InsufficientBalance":        ErrInsufficientBalance,
+	"ERC20InsufficientBalance":   ErrInsufficientBalance,
+	"InsufficientStreamBalance":  ErrInsufficientBalance,
+	"ERC20InsufficientAllowance": ErrInsufficientAllowance,
+	"ChannelNotFound":            ErrChannelNotFound,
+	"ChannelNotOpen":             ErrChannelNotOpen,
+	"ChannelNotClosing":          ErrChannelNotClosing,
+	"AgentNotFound":              ErrNotRegistered,
+	"AgentAlreadyRegistered":     ErrAlreadyRegistered,
+	"InsufficientStake":          ErrInsufficientStake,
+	"ServiceNotFound":            ErrServiceNotFound,
+	"ServiceNotActive":           ErrServiceNotActive,
+	"QuoteExpired":               ErrQuoteExpired,
+	"DeadlineExpired":            ErrDeadlineExpired,
+	"StreamNotActive":            ErrStreamNotActive,
+	"InvalidSignature":           ErrInvalidSignature,
+	"Unauthorized":               ErrUnauthorized,
+	"NotParty":                   ErrUnauthorized,
+}
+
+// reasonSentinels maps substrings of lowercased require messages, such as
+// those of pre-5.0 OpenZeppelin tokens, to sentinel errors
+var reasonSentinels = []struct {
+	substr string
+	err    error
+}{
+	{"exceeds balance", ErrInsufficientBalance},
+	{"insufficient balance", ErrInsufficientBalance},
+	{"exceeds allowance", ErrInsufficientAllowance},
+	{"insufficient allowance", ErrInsufficientAllowance},
+}
+
+// sentinel returns the sentinel error the revert corresponds to, or nil
+func (e *RevertError) sentinel() error {
+	if e.Custom != nil {
+		return customErrorSentinels[e.Custom.Name]
+	}
+
+	reason := strings.ToLower(e.Reason)
+	for _, s := range reasonSentinels {
+		if strings.Contains(reason, s.substr) {
+			return s.err
+		}
+	}
+	return nil
+}
+
+// decodeRevert turns an RPC error carrying revert data into a *RevertError,
+// returning other errors unchanged
+func (c *Client) decodeRevert(err error) error {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return err
+	}
+	encoded, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return err
+	}
+	data, decodeErr := hexutil.Decode(encoded)
+	if decodeErr != nil || len(data) < 4 {
+		return err
+	}
+
+	revert := &RevertError{Data: data, Err: err}
+	if reason, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+		revert.Reason = reason
+		return revert
+	}
+	revert.Custom = c.decodeCustomError(data)
+
+	return revert
+}
+
+// isMissingMethod reports whether err is the revert without data that
+// calling a function a contract does not implement produces. Reverts with
+// data and errors other than reverts report false.
+func isMissingMethod(err error) bool {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) || dataErr.Error() != vm.ErrExecutionReverted.Error() {
+		return false
+	}
+	data, _ := dataErr.ErrorData().(string)
+	return data == "" || data == "0x"
+}
+
+// decodeCustomError matches revert data against the custom errors of the
+// protocol contracts' ABIs
+func (c *Client) decodeCustomError(data []byte) *CustomError {
+	for _, name := range []string{ContractPaymentRouter, ContractPaymentChannel, ContractReputation, ContractServiceRegistry, ContractToken} {
+		for _, abiErr := range c.contractABI(name).Errors {
+			if !bytes.Equal(abiErr.ID[:4], data[:4]) {
+				continue
+			}
+
+			args := make(map[string]interface{})
+			if err := abiErr.Inputs.UnpackIntoMap(args, data[4:]); err != nil {
+				continue
+			}
+			return &CustomError{Name: abiErr.Name, Args: args}
+		}
+	}
+
+	return nil
+}
+
+// revertedTxError returns the error for a mined transaction that reverted.
+// Receipts carry no revert data, so the transaction is replayed as a call on
+// the state before its block to recover it. The replay ignores transactions
+// earlier in the same block and needs the node to still have that state; if
+// it does not revert, only ErrTxFailed is returned.
+func (c *Client) revertedTxError(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) error {
+	from, err := types.Sender(types.LatestSignerForChainID(c.chainID), tx)
+	if err != nil || receipt.BlockNumber == nil || receipt.BlockNumber.Sign() == 0 {
+		return ErrTxFailed
+	}
+
+	_, err = c.client.CallContract(ctx, ethereum.CallMsg{
+		From:       from,
+		To:         tx.To(),
+		Gas:        tx.Gas(),
+		Value:      tx.Value(),
+		Data:       tx.Data(),
+		AccessList: tx.AccessList(),
+	}, new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1)))
+
+	var revert *RevertError
+	if errors.As(c.decodeRevert(err), &revert) {
+		return fmt.Errorf("%w: %w", ErrTxFailed, revert)
+	}
+	return ErrTxFailed
+}
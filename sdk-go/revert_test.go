@@ -0,0 +1,161 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestDecodeRevert(t *testing.T) {
+	sender := testAddress(0)
+
+	custom := tokenABI.Errors["ERC20InsufficientBalance"]
+	customArgs, err := custom.Inputs.Pack(sender, big.NewInt(5), big.NewInt(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stringType, _ := abi.NewType("string", "", nil)
+	reasonArgs, err := abi.Arguments{{Type: stringType}}.Pack("transfer amount exceeds balance")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		data       []byte
+		wantCustom string
+		wantArgs   map[string]interface{}
+		wantReason string
+	}{
+		{
+			name:       "custom error",
+			data:       append(custom.ID[:4:4], customArgs...),
+			wantCustom: "ERC20InsufficientBalance",
+			wantArgs:   map[string]interface{}{"sender": sender, "balance": big.NewInt(5), "needed": big.NewInt(10)},
+		},
+		{
+			name:       "require message",
+			data:       append([]byte{0x08, 0xc3, 0x79, 0xa0}, reasonArgs...),
+			wantReason: "transfer amount exceeds balance",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.estimateGas = func(ethereum.CallMsg) (uint64, error) { return 0, &mockRevertError{data: tt.data} }
+			c := newTestClient(t, backend, Config{})
+
+			_, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(10))
+			var revert *RevertError
+			if !errors.As(err, &revert) {
+				t.Fatalf("Transfer error = %v, want a *RevertError", err)
+			}
+			if !errors.Is(err, ErrInsufficientBalance) {
+				t.Errorf("Transfer error = %v, want it to match ErrInsufficientBalance", err)
+			}
+			if revert.Reason != tt.wantReason {
+				t.Errorf("Reason = %q, want %q", revert.Reason, tt.wantReason)
+			}
+			if tt.wantCustom == "" {
+				if revert.Custom != nil {
+					t.Errorf("Custom = %v for a require message", revert.Custom)
+				}
+				return
+			}
+			if revert.Custom == nil || revert.Custom.Name != tt.wantCustom {
+				t.Fatalf("Custom = %v, want %s", revert.Custom, tt.wantCustom)
+			}
+			for name, want := range tt.wantArgs {
+				got := revert.Custom.Args[name]
+				if wantInt, ok := want.(*big.Int); ok {
+					if gotInt, _ := got.(*big.Int); gotInt == nil || gotInt.Cmp(wantInt) != 0 {
+						t.Errorf("arg %s = %v, want %s", name, got, wantInt)
+					}
+				} else if got != want.(common.Address) {
+					t.Errorf("arg %s = %v, want %v", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestRevertSentinels(t *testing.T) {
+	ctx := context.Background()
+	getChannel := func(c *Client) error { _, err := c.GetChannelByID(ctx, [32]byte{1}); return err }
+
+	tests := []struct {
+		name        string
+		contract    common.Address
+		contractABI abi.ABI
+		method      string
+		revert      string
+		call        func(c *Client) error
+		want        error
+	}{
+		{"ChannelNotOpen", testContracts.PaymentChannel, paymentChannelABI, "getChannel", "ChannelNotOpen", getChannel, ErrChannelNotOpen},
+		{"NotParty", testContracts.PaymentChannel, paymentChannelABI, "getChannel", "NotParty", getChannel, ErrUnauthorized},
+		{"AgentNotFound", testContracts.Reputation, reputationABI, "getAgent", "AgentNotFound", func(c *Client) error {
+			_, err := c.GetAgent(ctx, testAddress(1))
+			return err
+		}, ErrNotRegistered},
+		{"QuoteExpired", testContracts.ServiceRegistry, serviceRegistryABI, "getQuote", "QuoteExpired", func(c *Client) error {
+			_, err := c.GetQuote(ctx, [32]byte{1})
+			return err
+		}, ErrQuoteExpired},
+		{"unmapped custom error", testContracts.PaymentChannel, paymentChannelABI, "getChannel", "InvalidNonce", getChannel, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			backend.handle(tt.contract, tt.contractABI, tt.method, func(common.Address, []interface{}) ([]interface{}, error) {
+				return nil, revertWith(tt.contractABI, tt.revert)
+			})
+
+			err := tt.call(c)
+			var revert *RevertError
+			if !errors.As(err, &revert) || revert.Custom == nil || revert.Custom.Name != tt.revert {
+				t.Fatalf("error = %v, want a %s revert", err, tt.revert)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want it to match %v", err, tt.want)
+			}
+			if tt.want == nil && revert.sentinel() != nil {
+				t.Errorf("unmapped revert %v matches a sentinel", err)
+			}
+		})
+	}
+}
+
+func TestRevertedTxError(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	backend.handle(testContracts.PaymentChannel, paymentChannelABI, "cooperativeClose", func(common.Address, []interface{}) ([]interface{}, error) {
+		return nil, revertWith(paymentChannelABI, "ChannelNotOpen")
+	})
+
+	data, err := paymentChannelABI.Pack("cooperativeClose", [32]byte{1}, big.NewInt(1), big.NewInt(1), big.NewInt(1), []byte{}, []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx, err := types.SignNewTx(testKey(0), types.LatestSignerForChainID(c.ChainID()), &types.DynamicFeeTx{
+		ChainID: c.ChainID(), Gas: 100_000, GasFeeCap: big.NewInt(3e9), GasTipCap: big.NewInt(1e9),
+		To: &testContracts.PaymentChannel, Data: data,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	receipt := &types.Receipt{Status: types.ReceiptStatusFailed, TxHash: tx.Hash(), BlockNumber: big.NewInt(5)}
+
+	// The failed transaction is replayed to recover its revert
+	err = c.revertedTxError(context.Background(), tx, receipt)
+	if !errors.Is(err, ErrTxFailed) || !errors.Is(err, ErrChannelNotOpen) {
+		t.Errorf("revertedTxError = %v, want ErrTxFailed matching ErrChannelNotOpen", err)
+	}
+}
@@ -0,0 +1,404 @@
+package synapse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// knownCategories lists the categories the ServiceRegistry is deployed with
+var knownCategories = []string{
+	"LANGUAGE_MODEL",
+	"IMAGE_GENERATION",
+	"CODE_GENERATION",
+	"TRANSLATION",
+	"DATA_ANALYSIS",
+	"REASONING",
+	"EMBEDDING",
+	"SPEECH",
+	"VISION",
+	"MULTIMODAL",
+	"AGENT",
+	"TOOL",
+	"CUSTOM",
+}
+
+// CategoryID returns the on-chain identifier of a service category. Names are
+// normalized to the registry's upper snake case, so "code generation",
+// "code-generation" and "CODE_GENERATION" all map to the same ID.
+func CategoryID(category string) [32]byte {
+	return crypto.Keccak256Hash([]byte(normalizeCategory(category)))
+}
+
+// normalizeCategory converts a category name to upper snake case
+func normalizeCategory(category string) string {
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToUpper(strings.TrimSpace(category)))
+}
+
+// categoryName maps a category ID back to its name, falling back to the hex
+// encoded ID for categories not known to the SDK
+func categoryName(id [32]byte) string {
+	for _, name := range knownCategories {
+		if CategoryID(name) == id {
+			return name
+		}
+	}
+	return common.Hash(id).Hex()
+}
+
+// GetCategories returns the names of the categories registered in the
+// ServiceRegistry. Categories not known to the SDK are returned as their hex
+// encoded ID.
+func (c *Client) GetCategories(ctx context.Context) ([]string, error) {
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getAllCategories")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := out[0].([][32]byte)
+	categories := make([]string, len(ids))
+	for i, id := range ids {
+		categories[i] = categoryName(id)
+	}
+
+	return categories, nil
+}
+
+// SuggestCategory returns the registered categories closest to query by edit
+// distance after normalization, for catching typos. An exact match is
+// returned on its own; otherwise all categories at the smallest distance are
+// returned, or none if even those differ in more than half of the query.
+func (c *Client) SuggestCategory(ctx context.Context, query string) ([]string, error) {
+	categories, err := c.GetCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := normalizeCategory(query)
+	best := utf8.RuneCountInString(normalized) / 2
+	var suggestions []string
+	for _, category := range categories {
+		distance := editDistance(normalized, category)
+		switch {
+		case distance == 0:
+			return []string{category}, nil
+		case distance > best:
+		case distance < best:
+			best = distance
+			suggestions = []string{category}
+		case distance == best:
+			suggestions = append(suggestions, category)
+		}
+	}
+
+	return suggestions, nil
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// toServiceInfo converts the raw registry struct
+func toServiceInfo(data serviceData) *ServiceInfo {
+	return &ServiceInfo{
+		Provider:     data.Provider,
+		Name:         data.Name,
+		Category:     categoryName(data.Category),
+		Description:  data.Description,
+		Endpoint:     data.Endpoint,
+		BasePrice:    data.BasePrice,
+		PricingModel: PricingModel(data.PricingModel),
+		Active:       data.Status == serviceStatusActive,
+		CreatedAt:    data.RegistrationTime.Uint64(),
+	}
+}
+
+// GetServicesByProvider returns every service registered by a provider,
+// including inactive ones so they can be reactivated
+func (c *Client) GetServicesByProvider(ctx context.Context, provider common.Address) ([]ServiceInfo, error) {
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getServicesByProvider", provider)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceIDs := out[0].([][32]byte)
+	services := make([]ServiceInfo, 0, len(serviceIDs))
+	for _, serviceID := range serviceIDs {
+		service, err := c.GetService(ctx, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, *service)
+	}
+
+	return services, nil
+}
+
+// findService looks up a provider's active service by name and category
+func (c *Client) findService(ctx context.Context, provider common.Address, name, category string) ([32]byte, bool, error) {
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getServicesByProvider", provider)
+	if err != nil {
+		return [32]byte{}, false, err
+	}
+
+	categoryID := CategoryID(category)
+	for _, serviceID := range out[0].([][32]byte) {
+		data, err := c.getServiceData(ctx, serviceID)
+		if err != nil {
+			return [32]byte{}, false, err
+		}
+		if data.Status == serviceStatusActive && data.Name == name && data.Category == categoryID {
+			return serviceID, true, nil
+		}
+	}
+
+	return [32]byte{}, false, nil
+}
+
+// getServiceData reads the raw registry struct for a service
+func (c *Client) getServiceData(ctx context.Context, serviceID [32]byte) (*serviceData, error) {
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getService", serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return abi.ConvertType(out[0], new(serviceData)).(*serviceData), nil
+}
+
+// RankingWeights controls how RankServices combines reputation and price
+type RankingWeights struct {
+	Reputation float64
+	Price      float64
+}
+
+// DefaultRankingWeights favours reliable providers over cheap ones
+var DefaultRankingWeights = RankingWeights{Reputation: 0.7, Price: 0.3}
+
+// RankedService is a service with its provider's reputation and ranking score
+type RankedService struct {
+	ServiceID [32]byte
+	Service   *ServiceInfo
+	Provider  *AgentInfo
+	Score     float64
+}
+
+// RankServices orders services by a composite score of the provider's
+// WeightedSuccessRate and the inverse of the service's base price, both
+// normalized to [0, 1]. The cheapest service scores 1 on price.
+func (c *Client) RankServices(ctx context.Context, serviceIDs [][32]byte, weights RankingWeights) ([]RankedService, error) {
+	ranked := make([]RankedService, len(serviceIDs))
+	providers := make([]common.Address, len(serviceIDs))
+	for i, serviceID := range serviceIDs {
+		service, err := c.GetService(ctx, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		ranked[i] = RankedService{ServiceID: serviceID, Service: service}
+		providers[i] = service.Provider
+	}
+
+	agents, err := c.GetAgents(ctx, providers)
+	if err != nil {
+		return nil, err
+	}
+
+	var cheapest *big.Int
+	for _, r := range ranked {
+		price := r.Service.BasePrice
+		if price.Sign() > 0 && (cheapest == nil || price.Cmp(cheapest) < 0) {
+			cheapest = price
+		}
+	}
+
+	for i := range ranked {
+		ranked[i].Provider = agents[i]
+
+		priceScore := 1.0
+		if price := ranked[i].Service.BasePrice; cheapest != nil && price.Sign() > 0 {
+			priceScore, _ = new(big.Float).Quo(new(big.Float).SetInt(cheapest), new(big.Float).SetInt(price)).Float64()
+		}
+
+		ranked[i].Score = weights.Reputation*agents[i].WeightedSuccessRate() + weights.Price*priceScore
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Score > ranked[j].Score
+	})
+
+	return ranked, nil
+}
+
+// ServiceRegistrationResult is the outcome of one entry of RegisterServices
+type ServiceRegistrationResult struct {
+	Params    RegisterServiceParams
+	ServiceID [32]byte
+	Err       error
+}
+
+// RegisterServices registers services one after another, each mined before
+// the next is submitted. Failed entries are reported in their result and
+// skipped; with failFast, registration stops at the first failure and the
+// results so far are returned with its error.
+func (c *Client) RegisterServices(ctx context.Context, params []RegisterServiceParams, failFast bool) ([]ServiceRegistrationResult, error) {
+	results := make([]ServiceRegistrationResult, 0, len(params))
+	for i, p := range params {
+		serviceID, err := c.RegisterService(ctx, p)
+		results = append(results, ServiceRegistrationResult{
+			Params:    p,
+			ServiceID: serviceID,
+			Err:       err,
+		})
+
+		if err != nil && failFast {
+			return results, fmt.Errorf("failed to register service %d (%s): %w", i, p.Name, err)
+		}
+	}
+
+	return results, nil
+}
+
+// livePriceTimeout bounds the request made by FetchLiveServicePrice
+const livePriceTimeout = 5 * time.Second
+
+// FetchLiveServicePrice returns the price a service's endpoint currently
+// quotes at GET <endpoint>/price, a JSON object {"price": "<amount>"} in
+// decimal or 0x hex. If the endpoint cannot be reached or does not answer with
+// 200 OK, the on-chain BasePrice is returned instead.
+func (c *Client) FetchLiveServicePrice(ctx context.Context, serviceID [32]byte) (*big.Int, error) {
+	service, err := c.GetService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, livePriceTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodGet, strings.TrimRight(service.Endpoint, "/")+"/price", nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service endpoint %q: %w", service.Endpoint, err)
+	}
+
+	httpClient := c.config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return service.BasePrice, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return service.BasePrice, nil
+	}
+
+	var body struct {
+		Price json.RawMessage `json:"price"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<16)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode price from %s: %w", service.Endpoint, err)
+	}
+
+	price, err := parseAmount(body.Price)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode price from %s: %w", service.Endpoint, err)
+	}
+
+	return price, nil
+}
+
+// SubscribeNewServices streams services registered in a category until ctx is
+// cancelled, resubscribing if the connection drops. Both channels are closed
+// when the stream ends.
+func (c *Client) SubscribeNewServices(ctx context.Context, category string) (<-chan ServiceInfo, <-chan error, error) {
+	registry, err := c.contractAddress(ContractServiceRegistry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	event := c.contractABI(ContractServiceRegistry).Events["ServiceRegistered"]
+	logs := make(chan types.Log)
+	sub, err := c.SubscribeLogs(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{registry},
+		Topics:    [][]common.Hash{{event.ID}, nil, nil, {CategoryID(category)}},
+	}, logs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	services := make(chan ServiceInfo)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(services)
+		defer close(errc)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case log := <-logs:
+				if log.Removed {
+					continue
+				}
+
+				fields, err := decodeEvent(event, log)
+				if err != nil {
+					errc <- err
+					return
+				}
+
+				service, err := c.GetService(ctx, fields["serviceId"].([32]byte))
+				if err != nil {
+					errc <- err
+					return
+				}
+
+				select {
+				case services <- *service:
+				case <-ctx.Done():
+					return
+				}
+			case err := <-sub.Err():
+				errc <- err
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return services, errc, nil
+}
@@ -0,0 +1,430 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// testService returns an active registry service with a base price
+func testService(id byte, provider common.Address, category, name string, basePrice int64) serviceData {
+	return serviceData{
+		ServiceId:        [32]byte{id},
+		Provider:         provider,
+		Category:         CategoryID(category),
+		Name:             name,
+		PricingModel:     uint8(PricingPerRequest),
+		BasePrice:        big.NewInt(basePrice),
+		MinAmount:        new(big.Int),
+		MaxAmount:        new(big.Int),
+		RegistrationTime: new(big.Int),
+		LastUpdateTime:   new(big.Int),
+		Status:           serviceStatusActive,
+		TotalRequests:    new(big.Int),
+		TotalVolume:      new(big.Int),
+	}
+}
+
+// withServices makes the ServiceRegistry mock serve services by ID and by
+// provider
+func withServices(backend *mockBackend, services ...serviceData) {
+	byID := make(map[[32]byte]serviceData)
+	byProvider := make(map[common.Address][][32]byte)
+	for _, service := range services {
+		byID[service.ServiceId] = service
+		byProvider[service.Provider] = append(byProvider[service.Provider], service.ServiceId)
+	}
+
+	backend.handle(testContracts.ServiceRegistry, serviceRegistryABI, "getService", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		service, ok := byID[args[0].([32]byte)]
+		if !ok {
+			return nil, revertWith(serviceRegistryABI, "ServiceNotFound")
+		}
+		return []interface{}{service}, nil
+	})
+	backend.handle(testContracts.ServiceRegistry, serviceRegistryABI, "getServicesByProvider", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		return []interface{}{byProvider[args[0].(common.Address)]}, nil
+	})
+}
+
+// addServiceRegistered logs the registration of a service
+func addServiceRegistered(backend *mockBackend, service serviceData) {
+	backend.addLog(*eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRegistered",
+		[]common.Hash{service.ServiceId, common.BytesToHash(service.Provider.Bytes()), service.Category},
+		service.Name, service.BasePrice,
+	))
+}
+
+func TestSuggestCategory(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	ids := make([][32]byte, len(knownCategories))
+	for i, name := range knownCategories {
+		ids[i] = CategoryID(name)
+	}
+	backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "getAllCategories", ids)
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"translation", []string{"TRANSLATION"}},
+		{"translaton", []string{"TRANSLATION"}},
+		{"code generaton", []string{"CODE_GENERATION"}},
+		{"vison", []string{"VISION"}},
+		{"tol", []string{"TOOL"}},
+		// AGENT is 3 edits away, more than half of the 5-letter query
+		{"agxxx", nil},
+		{"xyz", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := c.SuggestCategory(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("SuggestCategory: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SuggestCategory(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindServicesByCategory(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+
+	inactive := testService(2, testAddress(1), "TRANSLATION", "old", 10)
+	inactive.Status = 2
+	services := []serviceData{
+		testService(1, testAddress(1), "TRANSLATION", "fast", 10),
+		inactive,
+		testService(3, testAddress(2), "VISION", "ocr", 10),
+		testService(4, testAddress(2), "TRANSLATION", "cheap", 5),
+	}
+	withServices(backend, services...)
+	for _, service := range services {
+		addServiceRegistered(backend, service)
+	}
+
+	tests := []struct {
+		category string
+		want     [][32]byte
+	}{
+		{"TRANSLATION", [][32]byte{{1}, {4}}},
+		{"translation", [][32]byte{{1}, {4}}},
+		{"vision", [][32]byte{{3}}},
+		{"speech", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.category, func(t *testing.T) {
+			got, err := c.FindServicesByCategory(context.Background(), tt.category)
+			if err != nil {
+				t.Fatalf("FindServicesByCategory: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindServicesByCategory(%q) = %x, want %x", tt.category, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterServiceDetectsDuplicates(t *testing.T) {
+	agent := NewLocalSigner(testKey(2))
+	params := RegisterServiceParams{
+		Name:      "translate",
+		Category:  "translation",
+		Endpoint:  "https://translate.example",
+		BasePrice: big.NewInt(10),
+	}
+
+	tests := []struct {
+		name string
+		// owner already has an active "translate" TRANSLATION service
+		owner   common.Address
+		opts    []TxOption
+		wantDup bool
+	}{
+		{"duplicate of the default account", testAddress(0), nil, true},
+		{"other account registers", testAddress(0), []TxOption{WithFrom(agent.Address())}, false},
+		{"duplicate of the sending account", agent.Address(), []TxOption{WithFrom(agent.Address())}, true},
+		{"default account registers", agent.Address(), nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{Accounts: []Signer{agent}})
+			existing := testService(1, tt.owner, "TRANSLATION", "translate", 10)
+			withServices(backend, existing)
+			backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "registerService", [32]byte{})
+			backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+				return []*types.Log{eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRegistered",
+					[]common.Hash{{2}, {}, CategoryID("TRANSLATION")}, "translate", big.NewInt(10),
+				)}
+			}
+
+			serviceID, err := c.RegisterService(context.Background(), params, tt.opts...)
+			if tt.wantDup {
+				if !errors.Is(err, ErrServiceAlreadyExists) || serviceID != existing.ServiceId {
+					t.Errorf("RegisterService = %x, %v, want %x with ErrServiceAlreadyExists", serviceID, err, existing.ServiceId)
+				}
+				if sent := backend.sentTxs(); len(sent) != 0 {
+					t.Errorf("sent %d transactions for a duplicate", len(sent))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RegisterService: %v", err)
+			}
+			if serviceID != ([32]byte{2}) {
+				t.Errorf("RegisterService = %x, want the registered service", serviceID)
+			}
+		})
+	}
+}
+
+func TestRankServices(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	reliable, unreliable := testAddress(1), testAddress(2)
+	cheap := testService(1, reliable, "inference", "cheap", 100)
+	expensive := testService(2, unreliable, "inference", "expensive", 400)
+	withServices(backend, expensive, cheap)
+	withAgents(backend, testAgent(reliable, 200, 196), testAgent(unreliable, 200, 120))
+
+	ranked, err := c.RankServices(context.Background(), [][32]byte{expensive.ServiceId, cheap.ServiceId}, DefaultRankingWeights)
+	if err != nil {
+		t.Fatalf("RankServices: %v", err)
+	}
+	if len(ranked) != 2 {
+		t.Fatalf("RankServices returned %d services, want 2", len(ranked))
+	}
+	if ranked[0].ServiceID != cheap.ServiceId || ranked[0].Provider.TotalTransactions != 200 {
+		t.Errorf("ranked %q first, want the cheaper, more reliable provider's service", ranked[0].Service.Name)
+	}
+	if ranked[0].Score <= ranked[1].Score {
+		t.Errorf("scores = %v, %v, want descending", ranked[0].Score, ranked[1].Score)
+	}
+
+	// Weighting only price still puts the cheaper service first, and only
+	// reputation the more reliable provider
+	for _, weights := range []RankingWeights{{Price: 1}, {Reputation: 1}} {
+		ranked, err := c.RankServices(context.Background(), [][32]byte{expensive.ServiceId, cheap.ServiceId}, weights)
+		if err != nil {
+			t.Fatalf("RankServices(%+v): %v", weights, err)
+		}
+		if ranked[0].ServiceID != cheap.ServiceId {
+			t.Errorf("RankServices(%+v) ranked %q first", weights, ranked[0].Service.Name)
+		}
+	}
+}
+
+func TestGetServicesByProvider(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	provider := testAddress(1)
+	inactive := testService(3, provider, "storage", "archive", 50)
+	inactive.Status = 0
+	withServices(backend,
+		testService(1, provider, "inference", "llm", 100),
+		testService(2, provider, "inference", "embeddings", 20),
+		inactive,
+		testService(4, testAddress(2), "inference", "other provider", 100),
+	)
+
+	services, err := c.GetServicesByProvider(context.Background(), provider)
+	if err != nil {
+		t.Fatalf("GetServicesByProvider: %v", err)
+	}
+	var names []string
+	for _, service := range services {
+		if service.Provider != provider {
+			t.Errorf("service %q belongs to %s", service.Name, service.Provider.Hex())
+		}
+		names = append(names, service.Name)
+	}
+	if want := []string{"llm", "embeddings", "archive"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("services = %v, want %v", names, want)
+	}
+	if !services[0].Active || services[2].Active {
+		t.Errorf("Active = %v, %v, want the archive service inactive", services[0].Active, services[2].Active)
+	}
+}
+
+func TestRegisterServices(t *testing.T) {
+	params := []RegisterServiceParams{
+		{Name: "llm", Category: "inference", Endpoint: "https://llm.example", BasePrice: big.NewInt(10)},
+		{Name: "free", Category: "inference", Endpoint: "https://free.example"},
+		{Name: "embed", Category: "inference", Endpoint: "https://embed.example", BasePrice: big.NewInt(5)},
+	}
+
+	for _, failFast := range []bool{false, true} {
+		backend := newMockBackend()
+		c := newTestClient(t, backend, Config{})
+		withServices(backend)
+		backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "registerService", [32]byte{})
+		backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+			return []*types.Log{eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRegistered",
+				[]common.Hash{{byte(tx.Nonce() + 1)}, common.BytesToHash(c.Address().Bytes()), CategoryID("inference")}, "", big.NewInt(1),
+			)}
+		}
+
+		results, err := c.RegisterServices(context.Background(), params, failFast)
+		if failFast {
+			if !errors.Is(err, ErrZeroAmount) || len(results) != 2 {
+				t.Errorf("fail fast: %d results, err = %v, want 2 results and ErrZeroAmount", len(results), err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("RegisterServices: %v", err)
+		}
+		if len(results) != len(params) {
+			t.Fatalf("RegisterServices returned %d results, want %d", len(results), len(params))
+		}
+		if results[0].Err != nil || results[0].ServiceID != ([32]byte{1}) {
+			t.Errorf("result 0 = %x, %v, want the first service", results[0].ServiceID, results[0].Err)
+		}
+		if !errors.Is(results[1].Err, ErrZeroAmount) || results[1].Params.Name != "free" {
+			t.Errorf("result 1 = %q, %v, want the unpriced service rejected", results[1].Params.Name, results[1].Err)
+		}
+		if results[2].Err != nil || results[2].ServiceID != ([32]byte{2}) {
+			t.Errorf("result 2 = %x, %v, want the second registered service", results[2].ServiceID, results[2].Err)
+		}
+	}
+}
+
+func TestFetchLiveServicePrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    int64
+	}{
+		{"decimal quote", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/price" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(`{"price":"1500"}`))
+		}, 1500},
+		{"hex quote", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`{"price":"0x64"}`)) }, 100},
+		{"endpoint unavailable", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) }, 1000},
+		{"endpoint unreachable", nil, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			if tt.handler == nil {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{HTTPClient: server.Client()})
+			service := testService(1, testAddress(1), "inference", "llm", 1000)
+			service.Endpoint = server.URL + "/"
+			withServices(backend, service)
+
+			price, err := c.FetchLiveServicePrice(context.Background(), service.ServiceId)
+			if err != nil {
+				t.Fatalf("FetchLiveServicePrice: %v", err)
+			}
+			if price.Int64() != tt.want {
+				t.Errorf("price = %s, want %d", price, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubscribeNewServices(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{WatchMode: WatchModePoll, PollInterval: 10 * time.Millisecond})
+	inference := testService(1, testAddress(1), "inference", "llm", 1000)
+	storage := testService(2, testAddress(2), "storage", "blobs", 10)
+	withServices(backend, inference, storage)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	services, errc, err := c.SubscribeNewServices(ctx, "inference")
+	if err != nil {
+		t.Fatalf("SubscribeNewServices: %v", err)
+	}
+
+	for _, service := range []serviceData{storage, inference} {
+		backend.addLog(*eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRegistered",
+			[]common.Hash{service.ServiceId, common.BytesToHash(service.Provider.Bytes()), service.Category},
+			service.Name, service.BasePrice,
+		))
+	}
+
+	select {
+	case service := <-services:
+		if service.Provider != inference.Provider || service.Name != "llm" || service.BasePrice.Cmp(inference.BasePrice) != 0 {
+			t.Errorf("delivered %q by %s at %s, want the inference service", service.Name, service.Provider.Hex(), service.BasePrice)
+		}
+	case err := <-errc:
+		t.Fatalf("subscription failed: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("registration was not delivered")
+	}
+
+	select {
+	case service := <-services:
+		t.Errorf("delivered %q outside the category", service.Name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestRegisterServiceValidatesEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint string
+		insecure bool
+		wantErr  bool
+	}{
+		{"https", "https://llm.example/v1", false, false},
+		{"wss", "wss://llm.example/stream", false, false},
+		{"bare host", "llm.example", false, true},
+		{"http", "http://llm.example", false, true},
+		{"http allowed for local testing", "http://localhost:8080", true, false},
+		{"unsupported scheme", "ftp://llm.example", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{AllowInsecureEndpoints: tt.insecure})
+			withServices(backend)
+			backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "registerService", [32]byte{})
+			backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+				return []*types.Log{eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRegistered",
+					[]common.Hash{{2}, {}, CategoryID("inference")}, "llm", big.NewInt(10),
+				)}
+			}
+
+			_, err := c.RegisterService(context.Background(), RegisterServiceParams{
+				Name: "llm", Category: "inference", Endpoint: tt.endpoint, BasePrice: big.NewInt(10),
+			})
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidEndpoint) {
+					t.Errorf("RegisterService error = %v, want ErrInvalidEndpoint", err)
+				}
+				if n := len(backend.sentTxs()); n != 0 {
+					t.Errorf("sent %d transactions for an invalid endpoint", n)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("RegisterService: %v", err)
+			}
+		})
+	}
+}
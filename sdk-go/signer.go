@@ -0,0 +1,187 @@
+package synapse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Signer signs on behalf of the client's account, so the key itself can live
+// outside the process, e.g. in a KMS or HSM
+type Signer interface {
+	// Address returns the signing account
+	Address() common.Address
+	// SignHash signs a 32-byte digest, returning a 65-byte [R || S || V]
+	// signature with V 0 or 1, as crypto.Sign does
+	SignHash(ctx context.Context, hash []byte) ([]byte, error)
+}
+
+// TxSigner is implemented by signers that sign whole transactions rather
+// than their hashes, such as hardware wallets that display the transaction
+// for confirmation. The client prefers SignTx when it is available.
+type TxSigner interface {
+	SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+// MessageSigner is implemented by signers that sign EIP-191 personal
+// messages rather than their hashes, such as remote signers whose eth_sign
+// adds the prefix itself. The client prefers SignMessage for channel states,
+// profiles, ownership proofs and meta-transactions when it is available.
+type MessageSigner interface {
+	// SignMessage signs accounts.TextHash(message), returning a signature
+	// with V 0 or 1, as SignHash does
+	SignMessage(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// LocalSigner signs with a private key held in memory
+type LocalSigner struct {
+	key *ecdsa.PrivateKey
+}
+
+// NewLocalSigner returns a signer for a private key
+func NewLocalSigner(key *ecdsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{key: key}
+}
+
+// NewLocalSignerFromHex returns a signer for a hex encoded private key
+func NewLocalSignerFromHex(privateKeyHex string) (*LocalSigner, error) {
+	key, err := crypto.HexToECDSA(privateKeyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+	return NewLocalSigner(key), nil
+}
+
+// Address implements Signer
+func (s *LocalSigner) Address() common.Address {
+	return crypto.PubkeyToAddress(s.key.PublicKey)
+}
+
+// PublicKey returns the signer's public key
+func (s *LocalSigner) PublicKey() *ecdsa.PublicKey {
+	return &s.key.PublicKey
+}
+
+// SignHash implements Signer
+func (s *LocalSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return crypto.Sign(hash, s.key)
+}
+
+// DigestSignFunc signs a 32-byte digest with a secp256k1 key and returns the
+// ASN.1 DER encoded signature, as AWS KMS and Google Cloud KMS do
+type DigestSignFunc func(ctx context.Context, digest []byte) ([]byte, error)
+
+// derSigner adapts a DigestSignFunc to Signer
+type derSigner struct {
+	address common.Address
+	sign    DigestSignFunc
+}
+
+// NewDERSigner returns a signer for address backed by a remote key, such as
+// a KMS or HSM key, that produces DER signatures. The signatures are
+// normalized to low S as Ethereum requires, and the recovery ID is found by
+// matching the recovered address against address, so address must be the
+// key's Ethereum address.
+func NewDERSigner(address common.Address, sign DigestSignFunc) Signer {
+	return &derSigner{address: address, sign: sign}
+}
+
+// Address implements Signer
+func (s *derSigner) Address() common.Address {
+	return s.address
+}
+
+// secp256k1HalfN is half the order of the secp256k1 curve
+var secp256k1HalfN = new(big.Int).Rsh(crypto.S256().Params().N, 1)
+
+// SignHash implements Signer
+func (s *derSigner) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	der, err := s.sign(ctx, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign digest: %w", err)
+	}
+
+	var rs struct{ R, S *big.Int }
+	if _, err := asn1.Unmarshal(der, &rs); err != nil {
+		return nil, fmt.Errorf("failed to decode DER signature: %w", err)
+	}
+	if rs.S.Cmp(secp256k1HalfN) > 0 {
+		rs.S.Sub(crypto.S256().Params().N, rs.S)
+	}
+
+	signature := make([]byte, crypto.SignatureLength)
+	rs.R.FillBytes(signature[:32])
+	rs.S.FillBytes(signature[32:64])
+
+	for v := byte(0); v < 2; v++ {
+		signature[crypto.RecoveryIDOffset] = v
+		pub, err := crypto.SigToPub(hash, signature)
+		if err == nil && crypto.PubkeyToAddress(*pub) == s.address {
+			return signature, nil
+		}
+	}
+
+	return nil, fmt.Errorf("signature does not recover to %s", s.address.Hex())
+}
+
+// signHash signs a digest with the client's signer, bounded by the write
+// timeout
+func (c *Client) signHash(hash []byte) ([]byte, error) {
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+
+	return c.signHashWith(c.signer, hash)
+}
+
+// signHashWith signs a digest with signer, bounded by the write timeout
+func (c *Client) signHashWith(signer Signer, hash []byte) ([]byte, error) {
+	ctx, cancel := c.withTimeout(context.Background(), timeoutWrite)
+	defer cancel()
+
+	return signer.SignHash(ctx, hash)
+}
+
+// signMessage signs an EIP-191 personal message with the client's signer,
+// bounded by the write timeout
+func (c *Client) signMessage(message []byte) ([]byte, error) {
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+
+	return c.signMessageWith(c.signer, message)
+}
+
+// signMessageWith signs an EIP-191 personal message with signer, bounded by
+// the write timeout
+func (c *Client) signMessageWith(signer Signer, message []byte) ([]byte, error) {
+	if messageSigner, ok := signer.(MessageSigner); ok {
+		ctx, cancel := c.withTimeout(context.Background(), timeoutWrite)
+		defer cancel()
+
+		return messageSigner.SignMessage(ctx, message)
+	}
+
+	return c.signHashWith(signer, accounts.TextHash(message))
+}
+
+// signTx signs a transaction with signer
+func (c *Client) signTx(ctx context.Context, signer Signer, tx *types.Transaction) (*types.Transaction, error) {
+	if txSigner, ok := signer.(TxSigner); ok {
+		return txSigner.SignTx(ctx, tx, c.chainID)
+	}
+
+	txHasher := types.LatestSignerForChainID(c.chainID)
+	signature, err := signer.SignHash(ctx, txHasher.Hash(tx).Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return tx.WithSignature(txHasher, signature)
+}
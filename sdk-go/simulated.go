@@ -0,0 +1,202 @@
+package synapse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// DefaultSimulatedSeed is the seed NewSimulatedClient derives its key from
+// when SimulatedConfig.Seed is empty
+const DefaultSimulatedSeed = "synapse-simulated"
+
+// SimulatedConfig configures NewSimulatedClient
+type SimulatedConfig struct {
+	// ArtifactsDir is the hardhat artifacts directory the protocol contracts
+	// were compiled to with `npx hardhat compile`, e.g. "../artifacts"
+	ArtifactsDir string
+
+	// Seed derives the signer's key, so that addresses, contract addresses
+	// and payment IDs are the same on every run. Empty means
+	// DefaultSimulatedSeed.
+	Seed string
+
+	// Balance is the signer's ether balance at genesis. Nil means 1000 ETH.
+	Balance *big.Int
+
+	// RegistrationFee and MinStake configure the deployed ReputationRegistry.
+	// Nil means zero.
+	RegistrationFee *big.Int
+	MinStake        *big.Int
+
+	// Config is the client configuration. Its RPCURL, PrivateKey, Signer and
+	// Contracts are replaced.
+	Config Config
+}
+
+// NewSimulatedClient creates a client on an in-memory go-ethereum simulated
+// chain, with the protocol contracts deployed from config.ArtifactsDir and
+// the signer pre-funded with ether and the whole SYNX supply. Transactions
+// are mined as soon as they are sent. Close shuts the chain down.
+func NewSimulatedClient(config SimulatedConfig) (*Client, error) {
+	seed := config.Seed
+	if seed == "" {
+		seed = DefaultSimulatedSeed
+	}
+	key, err := crypto.ToECDSA(crypto.Keccak256([]byte(seed)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive simulated key: %w", err)
+	}
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	balance := config.Balance
+	if balance == nil {
+		balance = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+	}
+
+	sim := simulated.NewBackend(types.GenesisAlloc{address: {Balance: balance}})
+	backend := &simulatedBackend{Client: sim.Client(), sim: sim}
+
+	contracts, err := deploySimulatedContracts(backend, key, config)
+	if err != nil {
+		sim.Close()
+		return nil, err
+	}
+
+	clientConfig := config.Config
+	clientConfig.RPCURL = ""
+	clientConfig.PrivateKey = ""
+	clientConfig.Signer = NewLocalSigner(key)
+	clientConfig.Contracts = contracts
+
+	c, err := NewClientWithBackend(backend, clientConfig)
+	if err != nil {
+		sim.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// simulatedBackend mines every transaction as soon as it is sent and shuts
+// the simulated chain down on Close
+type simulatedBackend struct {
+	simulated.Client
+	sim *simulated.Backend
+}
+
+func (b *simulatedBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := b.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	b.sim.Commit()
+	return nil
+}
+
+func (b *simulatedBackend) Close() {
+	b.sim.Close()
+}
+
+// hardhatArtifact is the part of a hardhat artifact needed for deployment
+type hardhatArtifact struct {
+	ABI      json.RawMessage `json:"abi"`
+	Bytecode string          `json:"bytecode"`
+}
+
+// loadArtifact reads the ABI and creation bytecode of contract name
+func loadArtifact(dir, name string) (abi.ABI, []byte, error) {
+	path := filepath.Join(dir, "contracts", name+".sol", name+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return abi.ABI{}, nil, fmt.Errorf("failed to read %s artifact: %w", name, err)
+	}
+
+	var artifact hardhatArtifact
+	if err := json.Unmarshal(data, &artifact); err != nil {
+		return abi.ABI{}, nil, fmt.Errorf("failed to parse %s artifact: %w", name, err)
+	}
+
+	contractABI, err := abi.JSON(strings.NewReader(string(artifact.ABI)))
+	if err != nil {
+		return abi.ABI{}, nil, fmt.Errorf("failed to parse %s ABI: %w", name, err)
+	}
+
+	bytecode, err := hexutil.Decode(artifact.Bytecode)
+	if err != nil || len(bytecode) == 0 {
+		return abi.ABI{}, nil, fmt.Errorf("%s artifact has no bytecode", name)
+	}
+
+	return contractABI, bytecode, nil
+}
+
+// deploySimulatedContracts deploys the protocol contracts in dependency
+// order, with the deployer as fee collector and treasury
+func deploySimulatedContracts(backend *simulatedBackend, key *ecdsa.PrivateKey, config SimulatedConfig) (ContractAddresses, error) {
+	opts, err := bind.NewKeyedTransactorWithChainID(key, big.NewInt(1337))
+	if err != nil {
+		return ContractAddresses{}, err
+	}
+	deployer := opts.From
+
+	registrationFee := config.RegistrationFee
+	if registrationFee == nil {
+		registrationFee = new(big.Int)
+	}
+	minStake := config.MinStake
+	if minStake == nil {
+		minStake = new(big.Int)
+	}
+
+	deploy := func(name string, params ...interface{}) (common.Address, *bind.BoundContract, error) {
+		contractABI, bytecode, err := loadArtifact(config.ArtifactsDir, name)
+		if err != nil {
+			return common.Address{}, nil, err
+		}
+		address, _, contract, err := bind.DeployContract(opts, contractABI, bytecode, backend, params...)
+		if err != nil {
+			return common.Address{}, nil, fmt.Errorf("failed to deploy %s: %w", name, err)
+		}
+		return address, contract, nil
+	}
+
+	var contracts ContractAddresses
+	var token *bind.BoundContract
+	if contracts.Token, token, err = deploy("SynapseToken", deployer, []common.Address{}, []*big.Int{}); err != nil {
+		return ContractAddresses{}, err
+	}
+	if contracts.Reputation, _, err = deploy("ReputationRegistry", contracts.Token, deployer, registrationFee, minStake); err != nil {
+		return ContractAddresses{}, err
+	}
+	if contracts.PaymentRouter, _, err = deploy("PaymentRouter", contracts.Token, deployer, contracts.Reputation); err != nil {
+		return ContractAddresses{}, err
+	}
+	if contracts.PaymentChannel, _, err = deploy("PaymentChannel", contracts.Token); err != nil {
+		return ContractAddresses{}, err
+	}
+	if contracts.ServiceRegistry, _, err = deploy("ServiceRegistry", contracts.Token, deployer, registrationFee); err != nil {
+		return ContractAddresses{}, err
+	}
+
+	// Protocol contracts move tokens on behalf of agents, which would
+	// otherwise be charged the token's transfer fee
+	for _, exempt := range []common.Address{contracts.PaymentRouter, contracts.PaymentChannel, contracts.Reputation, contracts.ServiceRegistry} {
+		if _, err := token.Transact(opts, "setFeeExemption", exempt, true); err != nil {
+			return ContractAddresses{}, fmt.Errorf("failed to exempt %s from transfer fees: %w", exempt.Hex(), err)
+		}
+	}
+
+	return contracts, nil
+}
@@ -0,0 +1,198 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+)
+
+// testArtifactsDir holds the contracts compiled by `npx hardhat compile`
+const testArtifactsDir = "../artifacts"
+
+func TestNewSimulatedClient(t *testing.T) {
+	if _, err := os.Stat(filepath.Join(testArtifactsDir, "contracts")); err != nil {
+		t.Skip("contracts not compiled, run `npx hardhat compile` in the repository root")
+	}
+
+	c, err := NewSimulatedClient(SimulatedConfig{ArtifactsDir: testArtifactsDir})
+	if err != nil {
+		t.Fatalf("NewSimulatedClient: %v", err)
+	}
+	defer c.Close()
+
+	again, err := NewSimulatedClient(SimulatedConfig{ArtifactsDir: testArtifactsDir})
+	if err != nil {
+		t.Fatalf("NewSimulatedClient: %v", err)
+	}
+	defer again.Close()
+	if c.Address() != again.Address() || c.Contracts() != again.Contracts() {
+		t.Errorf("simulated clients with the same seed differ")
+	}
+
+	balance, err := c.GetBalance(context.Background(), c.Address())
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	if balance.Sign() == 0 {
+		t.Errorf("signer holds no SYNX")
+	}
+}
+
+func TestNewSimulatedClientMissingArtifacts(t *testing.T) {
+	_, err := NewSimulatedClient(SimulatedConfig{ArtifactsDir: t.TempDir()})
+	if err == nil {
+		t.Fatal("NewSimulatedClient succeeded without artifacts")
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("err = %v, want a missing file error", err)
+	}
+}
+
+func TestSimulatedClientCloseStopsBackend(t *testing.T) {
+	sim := simulated.NewBackend(types.GenesisAlloc{})
+	backend := &simulatedBackend{Client: sim.Client(), sim: sim}
+	c, err := NewClientWithBackend(backend, Config{})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+
+	c.Close()
+
+	if _, err := backend.ChainID(context.Background()); err == nil {
+		t.Error("simulated backend still serves requests after Close")
+	}
+}
+
+func TestCancelledWaitReturnsTxHash(t *testing.T) {
+	// Every contract answers every call with 2^128, an allowance covering
+	// any payment, and transactions stay pending until committed
+	code := append([]byte{0x7f}, common.LeftPadBytes(new(big.Int).Lsh(big.NewInt(1), 128).Bytes(), 32)...)
+	code = append(code, 0x60, 0x00, 0x52, 0x60, 0x20, 0x60, 0x00, 0xf3)
+	alloc := types.GenesisAlloc{testAddress(0): {Balance: big.NewInt(1e18)}}
+	for _, contract := range []common.Address{testContracts.Token, testContracts.PaymentRouter} {
+		alloc[contract] = types.Account{Code: code, Balance: new(big.Int)}
+	}
+	sim := simulated.NewBackend(alloc)
+	defer sim.Close()
+	c, err := NewClientWithBackend(sim.Client(), Config{Signer: NewLocalSigner(testKey(0)), Contracts: testContracts})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = c.Pay(ctx, testAddress(1), big.NewInt(1), nil)
+
+	var waitErr *TxWaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("Pay with a cancelled wait: err = %v, want a *TxWaitError", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want it to wrap the context error", err)
+	}
+	tx, pending, err := sim.Client().TransactionByHash(context.Background(), waitErr.TxHash)
+	if err != nil || !pending {
+		t.Fatalf("TransactionByHash(%s) = pending %v, %v, want the submitted transaction", waitErr.TxHash.Hex(), pending, err)
+	}
+
+	// The hash is enough to resume waiting
+	sim.Commit()
+	receipt, err := c.WaitForConfirmations(context.Background(), tx.Hash(), 1)
+	if err != nil {
+		t.Fatalf("WaitForConfirmations: %v", err)
+	}
+	if receipt.TxHash != waitErr.TxHash {
+		t.Errorf("receipt for %s, want %s", receipt.TxHash.Hex(), waitErr.TxHash.Hex())
+	}
+}
+
+// receiptSeenBackend signals the first receipt it returns
+type receiptSeenBackend struct {
+	simulated.Client
+	seen     chan struct{}
+	seenOnce sync.Once
+}
+
+func (b *receiptSeenBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := b.Client.TransactionReceipt(ctx, hash)
+	if err == nil {
+		b.seenOnce.Do(func() { close(b.seen) })
+	}
+	return receipt, err
+}
+
+func TestWaitForConfirmationsDetectsReorg(t *testing.T) {
+	sim := simulated.NewBackend(types.GenesisAlloc{testAddress(0): {Balance: big.NewInt(1e18)}})
+	defer sim.Close()
+	backend := &receiptSeenBackend{Client: sim.Client(), seen: make(chan struct{})}
+	c, err := NewClientWithBackend(backend, Config{})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	ctx := context.Background()
+
+	genesis, err := sim.Client().HeaderByNumber(ctx, big.NewInt(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recipient := testAddress(1)
+	tx, err := types.SignNewTx(testKey(0), types.LatestSignerForChainID(c.ChainID()), &types.DynamicFeeTx{
+		ChainID:   c.ChainID(),
+		Gas:       21000,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(1e11),
+		To:        &recipient,
+		Value:     big.NewInt(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sim.Client().SendTransaction(ctx, tx); err != nil {
+		t.Fatalf("SendTransaction: %v", err)
+	}
+	sim.Commit()
+	mined, err := sim.Client().TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.WaitForConfirmations(ctx, tx.Hash(), 3)
+		done <- err
+	}()
+	<-backend.seen
+
+	// A longer fork from genesis, whose first block differs by its
+	// timestamp, re-includes the transaction from the pool
+	if err := sim.Fork(genesis.Hash()); err != nil {
+		t.Fatalf("Fork: %v", err)
+	}
+	if err := sim.AdjustTime(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	sim.Commit()
+	sim.Commit()
+	relocated, err := sim.Client().TransactionReceipt(ctx, tx.Hash())
+	if err != nil || relocated.BlockHash == mined.BlockHash {
+		t.Fatalf("transaction not relocated by the fork: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrReorgDetected) {
+			t.Fatalf("WaitForConfirmations: err = %v, want ErrReorgDetected", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("WaitForConfirmations did not notice the reorg")
+	}
+}
@@ -0,0 +1,150 @@
+package synapse
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// channelStateLister is a ChannelStateStore that can list its contents, as
+// MemoryChannelStateStore does
+type channelStateLister interface {
+	States() ([]SignedChannelState, error)
+}
+
+// ClientState is the client's local state captured by Snapshot. It
+// marshals to JSON.
+type ClientState struct {
+	Address common.Address
+	ChainID uint64
+
+	// NextNonce is the nonce of the account's next transaction: the client's
+	// nonce counter or one past the highest journaled nonce of Address,
+	// whichever is higher, or zero if neither is known. LastTx is the
+	// transaction sent with the nonce before it. RestoreState hands both to
+	// the nonce manager, which keeps counting from NextNonce unless the
+	// node's pending nonce is ahead or the node no longer knows LastTx.
+	NextNonce uint64
+	LastTx    common.Hash
+
+	Transactions  []JournalEntry
+	ChannelStates []SignedChannelState
+}
+
+// Snapshot captures the default account's nonce counter, the transactions
+// in Config.Journal and the channel states in Config.ChannelStates. Channel
+// states are only captured if the store can list its contents, as
+// MemoryChannelStateStore can.
+func (c *Client) Snapshot() (ClientState, error) {
+	state := ClientState{
+		Address: c.address,
+		ChainID: c.chainID.Uint64(),
+	}
+
+	if acct, err := c.sender(common.Address{}); err == nil {
+		m := &acct.nonces
+		m.mu.Lock()
+		if m.synced {
+			state.NextNonce, state.LastTx = m.next, m.last
+		}
+		m.mu.Unlock()
+	}
+
+	if c.config.Journal != nil {
+		entries, err := c.config.Journal.Entries()
+		if err != nil {
+			return ClientState{}, fmt.Errorf("failed to read journal: %w", err)
+		}
+		state.Transactions = entries
+
+		for _, entry := range entries {
+			if entry.From != c.address && entry.From != (common.Address{}) {
+				continue
+			}
+			if entry.Nonce+1 > state.NextNonce {
+				state.NextNonce, state.LastTx = entry.Nonce+1, entry.TxHash
+			}
+		}
+	}
+
+	if c.config.ChannelStates != nil {
+		lister, ok := c.config.ChannelStates.(channelStateLister)
+		if !ok {
+			return ClientState{}, fmt.Errorf("channel state store cannot list its states")
+		}
+
+		channelStates, err := lister.States()
+		if err != nil {
+			return ClientState{}, fmt.Errorf("failed to read channel states: %w", err)
+		}
+		state.ChannelStates = channelStates
+	}
+
+	return state, nil
+}
+
+// RestoreState loads a snapshot into the default account's nonce manager,
+// Config.Journal and Config.ChannelStates. Transactions already journaled
+// are skipped, a channel state only replaces a stored one with a lower
+// nonce, and the nonce counter only moves forward, so restoring into a
+// client that has moved on never rolls it back. The snapshot must be of the
+// same account and chain.
+func (c *Client) RestoreState(state ClientState) error {
+	if state.Address != c.address {
+		return fmt.Errorf("snapshot is for %s, client is %s", state.Address.Hex(), c.address.Hex())
+	}
+	if state.ChainID != c.chainID.Uint64() {
+		return fmt.Errorf("%w: snapshot is for chain %d, client is on %s", ErrChainIDChanged, state.ChainID, c.chainID)
+	}
+
+	if state.NextNonce > 0 && c.signer != nil {
+		acct, err := c.sender(common.Address{})
+		if err != nil {
+			return err
+		}
+
+		m := &acct.nonces
+		m.mu.Lock()
+		if !m.synced || state.NextNonce > m.next {
+			m.next, m.last, m.synced = state.NextNonce, state.LastTx, true
+		}
+		m.mu.Unlock()
+	}
+
+	if c.config.Journal != nil && len(state.Transactions) > 0 {
+		entries, err := c.config.Journal.Entries()
+		if err != nil {
+			return fmt.Errorf("failed to read journal: %w", err)
+		}
+		journaled := make(map[common.Hash]bool, len(entries))
+		for _, entry := range entries {
+			journaled[entry.TxHash] = true
+		}
+
+		for _, entry := range state.Transactions {
+			if journaled[entry.TxHash] {
+				continue
+			}
+			if err := c.config.Journal.Record(entry); err != nil {
+				return fmt.Errorf("failed to journal transaction %s: %w", entry.TxHash.Hex(), err)
+			}
+		}
+	}
+
+	if c.config.ChannelStates != nil {
+		for _, channelState := range state.ChannelStates {
+			latest, err := c.config.ChannelStates.Latest(channelState.ChannelID)
+			if err != nil {
+				return fmt.Errorf("failed to load channel state: %w", err)
+			}
+			if latest != nil && latest.Nonce >= channelState.Nonce {
+				continue
+			}
+			if err := c.config.ChannelStates.Save(channelState); err != nil {
+				return fmt.Errorf("failed to save channel state: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
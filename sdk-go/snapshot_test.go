@@ -0,0 +1,77 @@
+package synapse
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestRestoreStateKeepsNonceContinuity(t *testing.T) {
+	tests := []struct {
+		name string
+		// nodeKnowsTxs is whether the node after the restart still knows
+		// the transactions sent before it, while reporting a lagging
+		// pending nonce
+		nodeKnowsTxs bool
+		wantNonce    uint64
+	}{
+		{"node lags behind", true, 3},
+		{"node lost the transactions", false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.autoMine = false
+			c := newTestClient(t, backend, Config{Journal: NewMemoryJournal()})
+			ctx := context.Background()
+
+			for i := 0; i < 3; i++ {
+				if _, err := c.Transfer(ctx, testAddress(1), big.NewInt(1)); err != nil {
+					t.Fatalf("Transfer: %v", err)
+				}
+			}
+
+			snapshot, err := c.Snapshot()
+			if err != nil {
+				t.Fatalf("Snapshot: %v", err)
+			}
+			if snapshot.NextNonce != 3 {
+				t.Fatalf("NextNonce = %d, want 3", snapshot.NextNonce)
+			}
+			data, err := json.Marshal(snapshot)
+			if err != nil {
+				t.Fatalf("failed to marshal snapshot: %v", err)
+			}
+
+			// Restart against a node whose pending nonce is still 0
+			restarted := newMockBackend()
+			restarted.autoMine = false
+			if tt.nodeKnowsTxs {
+				for _, tx := range backend.sentTxs() {
+					restarted.txs[tx.Hash()] = tx
+				}
+			}
+			restored := newTestClient(t, restarted, Config{Journal: NewMemoryJournal()})
+
+			var state ClientState
+			if err := json.Unmarshal(data, &state); err != nil {
+				t.Fatalf("failed to unmarshal snapshot: %v", err)
+			}
+			if err := restored.RestoreState(state); err != nil {
+				t.Fatalf("RestoreState: %v", err)
+			}
+
+			if _, err := restored.Transfer(ctx, testAddress(1), big.NewInt(1)); err != nil {
+				t.Fatalf("Transfer after restore: %v", err)
+			}
+			sent := restarted.sentTxs()
+			if len(sent) != 1 {
+				t.Fatalf("sent %d transactions after restore, want 1", len(sent))
+			}
+			if sent[0].Nonce() != tt.wantNonce {
+				t.Fatalf("transfer after restore used nonce %d, want %d", sent[0].Nonce(), tt.wantNonce)
+			}
+		})
+	}
+}
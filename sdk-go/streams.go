@@ -0,0 +1,142 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// StreamInfo represents a payment stream
+type StreamInfo struct {
+	StreamID    [32]byte
+	Sender      common.Address
+	Recipient   common.Address
+	TotalAmount *big.Int
+	Withdrawn   *big.Int
+	StartTime   uint64
+	EndTime     uint64
+	Active      bool
+}
+
+// GetStream returns stream information
+func (c *Client) GetStream(ctx context.Context, streamID [32]byte) (*StreamInfo, error) {
+	out, err := c.callContract(ctx, ContractPaymentRouter, "getStream", streamID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *abi.ConvertType(out[0], new(streamData)).(*streamData)
+
+	return &StreamInfo{
+		StreamID:    data.StreamId,
+		Sender:      data.Sender,
+		Recipient:   data.Recipient,
+		TotalAmount: data.TotalAmount,
+		Withdrawn:   data.Withdrawn,
+		StartTime:   data.StartTime.Uint64(),
+		EndTime:     data.EndTime.Uint64(),
+		Active:      data.Active,
+	}, nil
+}
+
+// StreamSchedule returns the amount the recipient could withdraw at now, when
+// the whole stream is vested, and the amount still to vest after now. The
+// withdrawable amount follows the router's linear accrual and is before fees.
+func StreamSchedule(stream *StreamInfo, now time.Time) (withdrawableNow *big.Int, fullyVestedAt time.Time, remaining *big.Int) {
+	fullyVestedAt = time.Unix(int64(stream.EndTime), 0)
+	if !stream.Active || stream.EndTime <= stream.StartTime {
+		return new(big.Int), fullyVestedAt, new(big.Int)
+	}
+
+	duration := stream.EndTime - stream.StartTime
+	var elapsed uint64
+	if t := now.Unix(); t > int64(stream.StartTime) {
+		elapsed = uint64(t) - stream.StartTime
+	}
+	if elapsed > duration {
+		elapsed = duration
+	}
+
+	accrued := new(big.Int).Mul(stream.TotalAmount, new(big.Int).SetUint64(elapsed))
+	accrued.Quo(accrued, new(big.Int).SetUint64(duration))
+
+	withdrawableNow = new(big.Int).Sub(accrued, stream.Withdrawn)
+	if withdrawableNow.Sign() < 0 {
+		withdrawableNow.SetInt64(0)
+	}
+
+	return withdrawableNow, fullyVestedAt, new(big.Int).Sub(stream.TotalAmount, accrued)
+}
+
+// CancelStreamResult is the settlement of a cancelled stream
+type CancelStreamResult struct {
+	TxHash common.Hash
+	// RecipientKept is the gross amount vested but not yet withdrawn at
+	// cancellation. The recipient receives it net of the protocol fee,
+	// which the router deducts from it.
+	RecipientKept *big.Int
+	// SenderRefunded is the unvested amount returned to the sender
+	SenderRefunded *big.Int
+}
+
+// CancelStream cancels a stream created by the client and waits for the
+// settlement: the router pays the recipient what has vested and refunds the
+// rest to the sender. The settlement is read after the cancellation is
+// mined, so withdrawals mined before it are accounted for.
+func (c *Client) CancelStream(ctx context.Context, streamID [32]byte, opts ...TxOption) (*CancelStreamResult, error) {
+	stream, err := c.GetStream(ctx, streamID)
+	if err != nil {
+		return nil, err
+	}
+	if stream.Sender != c.address {
+		return nil, fmt.Errorf("%w: stream %x was created by %s", ErrNotStreamSender, streamID, stream.Sender.Hex())
+	}
+	if !stream.Active {
+		return nil, fmt.Errorf("%w: %x", ErrStreamNotActive, streamID)
+	}
+
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "cancelStream", []interface{}{streamID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["StreamCancelled"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.PaymentRouter || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return nil, err
+		}
+
+		// The recipient may have withdrawn since the stream was read above,
+		// and cancelling leaves Withdrawn as it was when the cancellation ran
+		settled, err := c.GetStream(ctx, streamID)
+		if err != nil {
+			return nil, err
+		}
+
+		refund := fields["refundAmount"].(*big.Int)
+		kept := new(big.Int).Sub(settled.TotalAmount, settled.Withdrawn)
+		kept.Sub(kept, refund)
+
+		return &CancelStreamResult{
+			TxHash:         tx.Hash(),
+			RecipientKept:  kept,
+			SenderRefunded: refund,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
@@ -0,0 +1,94 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestStreamSchedule(t *testing.T) {
+	stream := func(total, withdrawn int64, active bool) *StreamInfo {
+		return &StreamInfo{
+			TotalAmount: big.NewInt(total),
+			Withdrawn:   big.NewInt(withdrawn),
+			StartTime:   1000,
+			EndTime:     2000,
+			Active:      active,
+		}
+	}
+
+	tests := []struct {
+		name         string
+		stream       *StreamInfo
+		now          int64
+		withdrawable int64
+		remaining    int64
+	}{
+		{name: "before start", stream: stream(1000, 0, true), now: 500, withdrawable: 0, remaining: 1000},
+		{name: "at start", stream: stream(1000, 0, true), now: 1000, withdrawable: 0, remaining: 1000},
+		{name: "halfway", stream: stream(1000, 0, true), now: 1500, withdrawable: 500, remaining: 500},
+		{name: "halfway after a withdrawal", stream: stream(1000, 200, true), now: 1500, withdrawable: 300, remaining: 500},
+		{name: "accrual rounds down", stream: stream(999, 0, true), now: 1001, withdrawable: 0, remaining: 999},
+		{name: "after end", stream: stream(1000, 0, true), now: 5000, withdrawable: 1000, remaining: 0},
+		{name: "fully withdrawn", stream: stream(1000, 1000, true), now: 5000, withdrawable: 0, remaining: 0},
+		{name: "inactive", stream: stream(1000, 0, false), now: 1500, withdrawable: 0, remaining: 0},
+		{name: "empty schedule", stream: &StreamInfo{TotalAmount: big.NewInt(1000), Withdrawn: new(big.Int), StartTime: 2000, EndTime: 2000, Active: true}, now: 1500, withdrawable: 0, remaining: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withdrawable, vestedAt, remaining := StreamSchedule(tt.stream, time.Unix(tt.now, 0))
+			if withdrawable.Int64() != tt.withdrawable || remaining.Int64() != tt.remaining {
+				t.Errorf("StreamSchedule = %s, %s, want %d, %d", withdrawable, remaining, tt.withdrawable, tt.remaining)
+			}
+			if want := time.Unix(int64(tt.stream.EndTime), 0); !vestedAt.Equal(want) {
+				t.Errorf("fullyVestedAt = %v, want %v", vestedAt, want)
+			}
+		})
+	}
+}
+
+func TestCancelStreamReadsSettlementAfterReceipt(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	streamID := [32]byte{1}
+
+	// The recipient withdraws 300 between CancelStream reading the stream
+	// and the cancellation being mined
+	backend.handle(testContracts.PaymentRouter, paymentRouterABI, "getStream", func(common.Address, []interface{}) ([]interface{}, error) {
+		withdrawn := big.NewInt(0)
+		if len(backend.sentTxs()) > 0 {
+			withdrawn = big.NewInt(300)
+		}
+		return []interface{}{streamData{
+			StreamId:    streamID,
+			Sender:      c.Address(),
+			Recipient:   testAddress(1),
+			TotalAmount: big.NewInt(1000),
+			Withdrawn:   withdrawn,
+			StartTime:   big.NewInt(1000),
+			EndTime:     big.NewInt(2000),
+			Active:      len(backend.sentTxs()) == 0,
+		}}, nil
+	})
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		return []*types.Log{eventLog(testContracts.PaymentRouter, paymentRouterABI, "StreamCancelled",
+			[]common.Hash{streamID}, big.NewInt(500),
+		)}
+	}
+
+	result, err := c.CancelStream(context.Background(), streamID)
+	if err != nil {
+		t.Fatalf("CancelStream: %v", err)
+	}
+	if result.SenderRefunded.Int64() != 500 {
+		t.Errorf("SenderRefunded = %s, want 500", result.SenderRefunded)
+	}
+	if result.RecipientKept.Int64() != 200 {
+		t.Errorf("RecipientKept = %s, want 200", result.RecipientKept)
+	}
+}
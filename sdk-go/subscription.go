@@ -0,0 +1,364 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// resubscribeMinBackoff is the delay before the first resubscription attempt
+	resubscribeMinBackoff = time.Second
+	// resubscribeMaxBackoff caps the delay between resubscription attempts
+	resubscribeMaxBackoff = time.Minute
+)
+
+// DefaultPollInterval is the FilterLogs interval used in polling mode
+const DefaultPollInterval = 4 * time.Second
+
+// WatchMode selects how event watchers receive logs
+type WatchMode uint8
+
+const (
+	// WatchModeAuto polls for http(s) RPC URLs and subscribes otherwise
+	WatchModeAuto WatchMode = iota
+	// WatchModeSubscribe uses eth_subscribe, which requires a WebSocket or IPC connection
+	WatchModeSubscribe
+	// WatchModePoll calls FilterLogs on an interval
+	WatchModePoll
+)
+
+// SubscriptionStats reports the health of a resubscribing subscription
+type SubscriptionStats struct {
+	Reconnects    uint64
+	LastError     error
+	LastReconnect time.Time
+}
+
+// Subscription is a log subscription that transparently resubscribes with
+// exponential backoff when the underlying connection drops
+type Subscription struct {
+	errc     chan error
+	quit     chan struct{}
+	quitOnce sync.Once
+	log      func(ctx context.Context, msg string, attrs ...slog.Attr)
+	// minBackoff is resubscribeMinBackoff, shortened in tests
+	minBackoff time.Duration
+
+	mu    sync.Mutex
+	stats SubscriptionStats
+}
+
+// newSubscription returns a subscription logging to Config.Logger
+func (c *Client) newSubscription() *Subscription {
+	return &Subscription{
+		errc:       make(chan error, 1),
+		quit:       make(chan struct{}),
+		log:        c.logDebug,
+		minBackoff: resubscribeMinBackoff,
+	}
+}
+
+// Err returns a channel that receives the terminal error of the subscription.
+// Dropped connections are retried and are reported through Stats instead.
+// A polled subscription with a ToBlock closes it, without an error, once the
+// logs through ToBlock are delivered.
+func (s *Subscription) Err() <-chan error {
+	return s.errc
+}
+
+// Unsubscribe stops the subscription
+func (s *Subscription) Unsubscribe() {
+	s.quitOnce.Do(func() { close(s.quit) })
+}
+
+// Stats returns the reconnect count and last error of the subscription
+func (s *Subscription) Stats() SubscriptionStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// SubscribeLogs streams logs matching the query into the given channel,
+// resubscribing automatically if the connection drops. Over HTTP, or with
+// WatchModePoll, logs are polled with FilterLogs instead, and the
+// subscription ends once the logs through query.ToBlock, if set, are
+// delivered.
+func (c *Client) SubscribeLogs(ctx context.Context, query ethereum.FilterQuery, logs chan<- types.Log) (*Subscription, error) {
+	if c.pollingMode() {
+		return c.pollLogs(ctx, query, logs)
+	}
+
+	subscribe := func(ctx context.Context) (ethereum.Subscription, error) {
+		return c.client.SubscribeFilterLogs(ctx, query, logs)
+	}
+
+	sub, err := subscribe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to logs: %w", err)
+	}
+
+	s := c.newSubscription()
+	go s.loop(ctx, sub, subscribe)
+
+	return s, nil
+}
+
+// pollingMode reports whether watchers should poll rather than subscribe
+func (c *Client) pollingMode() bool {
+	switch c.config.WatchMode {
+	case WatchModePoll:
+		return true
+	case WatchModeSubscribe:
+		return false
+	}
+
+	scheme := rpcScheme(c.config.RPCURL)
+	return scheme == "http" || scheme == "https"
+}
+
+// rpcScheme returns the lower-case scheme of an RPC URL
+func rpcScheme(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Scheme)
+}
+
+// pollLogs starts a subscription that calls FilterLogs on an interval,
+// starting at the query's FromBlock or the next block
+func (c *Client) pollLogs(ctx context.Context, query ethereum.FilterQuery, logs chan<- types.Log) (*Subscription, error) {
+	var next uint64
+	if query.FromBlock != nil {
+		next = query.FromBlock.Uint64()
+	} else {
+		latest, err := c.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block number: %w", err)
+		}
+		next = latest + 1
+	}
+
+	interval := c.config.PollInterval
+	if interval == 0 {
+		interval = DefaultPollInterval
+	}
+
+	s := c.newSubscription()
+	go s.pollLoop(ctx, c.client, query, logs, next, interval)
+
+	return s, nil
+}
+
+// pollLoop delivers logs from new blocks every interval. Failed polls are
+// recorded and retried on the next tick. Past query.ToBlock, it closes the
+// error channel and returns.
+func (s *Subscription) pollLoop(ctx context.Context, backend Backend, query ethereum.FilterQuery, logs chan<- types.Log, next uint64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	end := query.ToBlock
+
+	for {
+		if end != nil && next > end.Uint64() {
+			close(s.errc)
+			return
+		}
+
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		case <-ctx.Done():
+			s.errc <- ctx.Err()
+			return
+		}
+
+		latest, err := backend.BlockNumber(ctx)
+		if err != nil {
+			s.recordError(err)
+			continue
+		}
+		if end != nil && latest > end.Uint64() {
+			latest = end.Uint64()
+		}
+		if latest < next {
+			continue
+		}
+
+		query.FromBlock = new(big.Int).SetUint64(next)
+		query.ToBlock = new(big.Int).SetUint64(latest)
+		found, err := backend.FilterLogs(ctx, query)
+		if err != nil {
+			s.recordError(err)
+			continue
+		}
+
+		for _, log := range found {
+			select {
+			case logs <- log:
+			case <-s.quit:
+				return
+			case <-ctx.Done():
+				s.errc <- ctx.Err()
+				return
+			}
+		}
+		next = latest + 1
+	}
+}
+
+// loop watches the active subscription and resubscribes when it fails
+func (s *Subscription) loop(ctx context.Context, sub ethereum.Subscription, subscribe func(context.Context) (ethereum.Subscription, error)) {
+	for {
+		select {
+		case err := <-sub.Err():
+			s.recordError(err)
+			s.log(ctx, "subscription dropped, resubscribing", slog.Any("error", err))
+
+			sub = s.resubscribe(ctx, subscribe)
+			if sub == nil {
+				return
+			}
+		case <-s.quit:
+			sub.Unsubscribe()
+			return
+		case <-ctx.Done():
+			sub.Unsubscribe()
+			s.errc <- ctx.Err()
+			return
+		}
+	}
+}
+
+// resubscribe retries the subscription with exponential backoff, returning
+// nil if the subscription was stopped first
+func (s *Subscription) resubscribe(ctx context.Context, subscribe func(context.Context) (ethereum.Subscription, error)) ethereum.Subscription {
+	backoff := s.minBackoff
+	for {
+		select {
+		case <-time.After(backoff):
+		case <-s.quit:
+			return nil
+		case <-ctx.Done():
+			s.errc <- ctx.Err()
+			return nil
+		}
+
+		sub, err := subscribe(ctx)
+		if err == nil {
+			s.mu.Lock()
+			s.stats.Reconnects++
+			s.stats.LastReconnect = time.Now()
+			reconnects := s.stats.Reconnects
+			s.mu.Unlock()
+
+			s.log(ctx, "resubscribed", slog.Uint64("reconnects", reconnects))
+			return sub
+		}
+
+		s.recordError(err)
+		backoff *= 2
+		if backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
+		s.log(ctx, "resubscribe failed", slog.Any("error", err), slog.Duration("retry_in", backoff))
+	}
+}
+
+// recordError stores the most recent subscription error
+func (s *Subscription) recordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.stats.LastError = err
+	s.mu.Unlock()
+}
+
+// SubscribeBlocks streams new block headers until ctx is cancelled. Like
+// SubscribeLogs, it resubscribes if the connection drops, and polls instead of
+// subscribing over HTTP or with WatchModePoll. The error channel receives the
+// context error when the stream ends.
+func (c *Client) SubscribeBlocks(ctx context.Context) (<-chan *types.Header, <-chan error, error) {
+	heads := make(chan *types.Header)
+	s := c.newSubscription()
+
+	if c.pollingMode() {
+		latest, err := c.client.BlockNumber(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get block number: %w", err)
+		}
+
+		interval := c.config.PollInterval
+		if interval == 0 {
+			interval = DefaultPollInterval
+		}
+
+		go s.pollHeadsLoop(ctx, c.client, heads, latest+1, interval)
+		return heads, s.Err(), nil
+	}
+
+	subscribe := func(ctx context.Context) (ethereum.Subscription, error) {
+		return c.client.SubscribeNewHead(ctx, heads)
+	}
+
+	sub, err := subscribe(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to subscribe to new heads: %w", err)
+	}
+	go s.loop(ctx, sub, subscribe)
+
+	return heads, s.Err(), nil
+}
+
+// pollHeadsLoop delivers the headers of new blocks every interval. Failed
+// polls are recorded and retried on the next tick.
+func (s *Subscription) pollHeadsLoop(ctx context.Context, backend Backend, heads chan<- *types.Header, next uint64, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-s.quit:
+			return
+		case <-ctx.Done():
+			s.errc <- ctx.Err()
+			return
+		}
+
+		latest, err := backend.BlockNumber(ctx)
+		if err != nil {
+			s.recordError(err)
+			continue
+		}
+
+		for ; next <= latest; next++ {
+			header, err := backend.HeaderByNumber(ctx, new(big.Int).SetUint64(next))
+			if err != nil {
+				s.recordError(err)
+				break
+			}
+
+			select {
+			case heads <- header:
+			case <-s.quit:
+				return
+			case <-ctx.Done():
+				s.errc <- ctx.Err()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+func TestPolledSubscriptionEndsAtToBlock(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{WatchMode: WatchModePoll, PollInterval: 10 * time.Millisecond})
+	router := testContracts.PaymentRouter
+	for i := 0; i < 3; i++ {
+		backend.addLog(*eventLog(router, paymentRouterABI, "PaymentExecuted",
+			[]common.Hash{{byte(i + 1)}, common.BytesToHash(testAddress(1).Bytes()), common.BytesToHash(c.Address().Bytes())},
+			big.NewInt(1000), big.NewInt(0), [32]byte{},
+		))
+	}
+
+	logs := make(chan types.Log, 3)
+	sub, err := c.SubscribeLogs(context.Background(), ethereum.FilterQuery{
+		Addresses: []common.Address{router},
+		FromBlock: big.NewInt(1),
+		ToBlock:   big.NewInt(2),
+	}, logs)
+	if err != nil {
+		t.Fatalf("SubscribeLogs: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	select {
+	case err, ok := <-sub.Err():
+		if ok {
+			t.Fatalf("Err received %v, want it closed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription did not end at ToBlock")
+	}
+	if len(logs) != 2 {
+		t.Errorf("delivered %d logs, want the 2 through ToBlock", len(logs))
+	}
+}
+
+func TestSubscriptionCountsReconnects(t *testing.T) {
+	logger, logs := captureLogs()
+	c := newTestClient(t, newMockBackend(), Config{Logger: logger})
+
+	// Each subscription fails when drop fires, and the first resubscription
+	// attempt after each drop fails too
+	drop := make(chan struct{})
+	attempts := 0
+	subscribe := func(ctx context.Context) (ethereum.Subscription, error) {
+		attempts++
+		if attempts%2 == 0 {
+			return nil, errors.New("dial tcp: connection refused")
+		}
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			select {
+			case <-drop:
+				return errors.New("websocket: close 1006")
+			case <-quit:
+				return nil
+			}
+		}), nil
+	}
+
+	s := c.newSubscription()
+	s.minBackoff = time.Millisecond
+	first, _ := subscribe(context.Background())
+	go s.loop(context.Background(), first, subscribe)
+	defer s.Unsubscribe()
+
+	const drops = 3
+	for i := 1; i <= drops; i++ {
+		drop <- struct{}{}
+		deadline := time.Now().Add(5 * time.Second)
+		for s.Stats().Reconnects < uint64(i) && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	stats := s.Stats()
+	if stats.Reconnects != drops {
+		t.Fatalf("Reconnects = %d, want %d", stats.Reconnects, drops)
+	}
+	if stats.LastError == nil || !strings.Contains(stats.LastError.Error(), "connection refused") {
+		t.Errorf("LastError = %v, want the failed resubscription", stats.LastError)
+	}
+	if stats.LastReconnect.IsZero() {
+		t.Error("LastReconnect not set")
+	}
+	if got := strings.Count(logs.String(), "resubscribed"); got != drops {
+		t.Errorf("logged %d resubscriptions, want %d", got, drops)
+	}
+}
+
+// headFeedBackend delivers the headers sent to feed to head subscriptions
+type headFeedBackend struct {
+	*mockBackend
+	feed chan *types.Header
+}
+
+func (b *headFeedBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case header := <-b.feed:
+				select {
+				case ch <- header:
+				case <-quit:
+					return nil
+				}
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func TestSubscribeBlocks(t *testing.T) {
+	for _, mode := range []WatchMode{WatchModeSubscribe, WatchModePoll} {
+		backend := &headFeedBackend{mockBackend: newMockBackend(), feed: make(chan *types.Header)}
+		c, err := NewClientWithBackend(backend, Config{WatchMode: mode, PollInterval: 10 * time.Millisecond})
+		if err != nil {
+			t.Fatalf("NewClientWithBackend: %v", err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		heads, errc, err := c.SubscribeBlocks(ctx)
+		if err != nil {
+			t.Fatalf("mode %d: SubscribeBlocks: %v", mode, err)
+		}
+
+		// Two new blocks, pushed to subscribers or found by polling
+		backend.mine()
+		backend.mine()
+		if mode == WatchModeSubscribe {
+			go func() {
+				for n := int64(1); n <= 2; n++ {
+					header, _ := backend.HeaderByNumber(ctx, big.NewInt(n))
+					backend.feed <- header
+				}
+			}()
+		}
+
+		for n := uint64(1); n <= 2; n++ {
+			select {
+			case header := <-heads:
+				if header.Number.Uint64() != n {
+					t.Errorf("mode %d: header %d, want %d", mode, header.Number, n)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("mode %d: timed out waiting for block %d", mode, n)
+			}
+		}
+
+		cancel()
+		select {
+		case err := <-errc:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("mode %d: stream ended with %v, want the context error", mode, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("mode %d: stream did not end on cancellation", mode)
+		}
+	}
+}
@@ -5,15 +5,23 @@ package synapse
 import (
 	"context"
 	"crypto/ecdsa"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/big"
+	"net/http"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 // Tier represents reputation tier levels
@@ -52,40 +60,193 @@ const (
 
 // Config holds SDK configuration
 type Config struct {
-	RPCURL     string
+	RPCURL string
+
+	// PrivateKey is the hex encoded key the client signs with. If empty,
+	// and Signer is nil, the client is read-only: view methods work, while
+	// methods that sign or submit transactions return ErrReadOnly.
 	PrivateKey string
-	Contracts  ContractAddresses
+
+	// Signer, if set, signs instead of PrivateKey, e.g. with a key held in
+	// a KMS or HSM, see NewDERSigner
+	Signer Signer
+
+	// Accounts are additional signers write methods can send from with
+	// WithFrom, e.g. agent identities derived with DeriveMnemonicSigners.
+	// Each account has its own nonces.
+	Accounts []Signer
+
+	// Address is the account a read-only client queries as its own, e.g. in
+	// GetOpenChannels. It is ignored when the client has a signer.
+	Address common.Address
+
+	Contracts ContractAddresses
+
+	// MaxMetadataBytes limits the metadata accepted by Pay. Zero means
+	// DefaultMaxMetadataBytes.
+	MaxMetadataBytes int
+
+	// StartBlock is the block the protocol contracts were deployed at. Event
+	// scans start here instead of at genesis.
+	StartBlock uint64
+
+	// WatchMode selects how event watchers receive logs. The zero value,
+	// WatchModeAuto, polls for http(s) RPC URLs and subscribes otherwise.
+	WatchMode WatchMode
+
+	// PollInterval is the FilterLogs interval used in polling mode. Zero means
+	// DefaultPollInterval.
+	PollInterval time.Duration
+
+	// SafeApprove makes Approve reset a nonzero allowance to zero before
+	// changing it to another nonzero value, see SafeApprove
+	SafeApprove bool
+
+	// HTTPClient is used for off-chain requests to service endpoints. Nil
+	// means http.DefaultClient.
+	HTTPClient *http.Client
+
+	// Journal, if set, records every transaction the client submits. A
+	// transaction that fails to be recorded is still submitted, and the
+	// failure is logged as a warning.
+	Journal TxJournal
+
+	// ChannelStates, if set, is the store of the latest state of each
+	// channel. RoutePayment and OpenChannelAndPay save the states they sign
+	// and RoutePayment builds on them. Snapshot captures it and RestoreState
+	// refills it.
+	ChannelStates ChannelStateStore
+
+	// ABIOverrides replaces the embedded ABI of a contract, keyed by contract
+	// name (ContractPaymentRouter, ...), e.g. after a contract upgrade
+	ABIOverrides map[string]abi.ABI
+
+	// DefaultTimeout bounds every RPC operation whose context has no
+	// deadline. Zero means no timeout.
+	DefaultTimeout time.Duration
+
+	// ReadTimeout, WriteTimeout and WaitTimeout override DefaultTimeout for
+	// contract reads, transaction submission and waiting for a transaction
+	// to be mined. Zero falls back to DefaultTimeout.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	WaitTimeout  time.Duration
+
+	// AllowInsecureEndpoints lets RegisterService accept http:// and ws://
+	// service endpoints, e.g. for local testing
+	AllowInsecureEndpoints bool
+
+	// Logger, if set, receives debug logs of submitted transactions and
+	// payments. The private key is never logged.
+	Logger *slog.Logger
+
+	// LogSensitivity controls how much of addresses and payloads Logger sees
+	LogSensitivity LogSensitivity
+
+	// GasEstimateMultiplier scales estimated gas limits before submission
+	// to leave headroom for state changes between estimation and execution.
+	// Zero means DefaultGasEstimateMultiplier.
+	GasEstimateMultiplier float64
+
+	// Confirmations is how many blocks deep write methods that wait for
+	// their transaction wait for it to be. Zero means 1, i.e. mined.
+	Confirmations uint64
+
+	// FeeStrategy prices EIP-1559 transactions on chains with a base fee.
+	// Nil means FeeStandard. Chains without a base fee get legacy
+	// transactions at the node's suggested gas price.
+	FeeStrategy FeeStrategy
+
+	// GasLimits sets fixed gas limits by contract method name (e.g.
+	// "batchPay"), used instead of estimation. WithGasLimit takes
+	// precedence.
+	GasLimits map[string]uint64
+
+	// PaymentHandlerAttempts is the number of times OnPaymentReceived calls
+	// its handler for a payment before giving up. Zero means
+	// DefaultPaymentHandlerAttempts.
+	PaymentHandlerAttempts int
+
+	// PaymentDeadLetter, if set, receives the payments OnPaymentReceived
+	// gave up on with the handler's last error
+	PaymentDeadLetter func(PaymentEvent, error)
+
+	// PaymentConfirmations is how many blocks deep OnPaymentReceived waits
+	// for a payment to be before handling it. Zero means
+	// DefaultPaymentConfirmations.
+	PaymentConfirmations uint64
+
+	// AppNamespace separates the payment IDs of applications sharing a
+	// wallet, see AppPaymentID. It does not change on-chain payment IDs.
+	AppNamespace []byte
 }
 
 // ContractAddresses holds all contract addresses
 type ContractAddresses struct {
-	Token          common.Address
-	PaymentRouter  common.Address
-	Reputation     common.Address
+	Token           common.Address
+	PaymentRouter   common.Address
+	Reputation      common.Address
 	ServiceRegistry common.Address
-	PaymentChannel common.Address
+	PaymentChannel  common.Address
+
+	// Multicall is an optional Multicall3 deployment used to batch reads.
+	// Without it, batched reads fall back to one call each.
+	Multicall common.Address
+}
+
+// Backend is the chain connection used by the client. Both *ethclient.Client
+// and the client of go-ethereum's simulated backend satisfy it.
+type Backend interface {
+	ethereum.BlockNumberReader
+	ethereum.ChainReader
+	ethereum.ChainStateReader
+	ethereum.ContractCaller
+	ethereum.GasEstimator
+	ethereum.GasPricer
+	ethereum.GasPricer1559
+	ethereum.FeeHistoryReader
+	ethereum.LogFilterer
+	ethereum.PendingStateReader
+	ethereum.PendingContractCaller
+	ethereum.TransactionReader
+	ethereum.TransactionSender
+	ethereum.ChainIDReader
 }
 
 // Client is the main SYNAPSE SDK client
 type Client struct {
-	config     Config
-	client     *ethclient.Client
-	privateKey *ecdsa.PrivateKey
-	address    common.Address
-	chainID    *big.Int
+	config  Config
+	client  Backend
+	signer  Signer
+	address common.Address
+	chainID *big.Int
+
+	chainMu          sync.Mutex
+	chainIDCheckedAt time.Time
+
+	accountsMu sync.Mutex
+	accounts   map[common.Address]*account
+
+	channelMu sync.Mutex
+
+	cacheMu           sync.Mutex
+	stakeRequirements map[Tier]*big.Int
+	challengePeriod   time.Duration
+	tokenMetadata     *TokenInfo
 }
 
 // AgentInfo represents an AI agent's information
 type AgentInfo struct {
-	Registered            bool
-	Name                  string
-	Stake                 *big.Int
-	ReputationScore       uint64
-	TotalTransactions     uint64
+	Registered             bool
+	Name                   string
+	Stake                  *big.Int
+	ReputationScore        uint64
+	TotalTransactions      uint64
 	SuccessfulTransactions uint64
-	RegisteredAt          uint64
-	Tier                  Tier
-	SuccessRate           float64
+	RegisteredAt           uint64
+	Tier                   Tier
+	SuccessRate            float64
+	MetadataURI            string
 }
 
 // ServiceInfo represents a registered service
@@ -106,6 +267,8 @@ type ChannelInfo struct {
 	ChannelID    [32]byte
 	Participant1 common.Address
 	Participant2 common.Address
+	Deposit1     *big.Int
+	Deposit2     *big.Int
 	Balance1     *big.Int
 	Balance2     *big.Int
 	Nonce        uint64
@@ -119,43 +282,111 @@ type PaymentResult struct {
 	PaymentID [32]byte
 	Amount    *big.Int
 	Fee       *big.Int
+
+	// AppPaymentID is PaymentID under Config.AppNamespace
+	AppPaymentID [32]byte
 }
 
-// NewClient creates a new SYNAPSE SDK client
+// NewClient creates a new SYNAPSE SDK client. Over HTTP(S), calls that fail
+// with a connection error re-dial the endpoint and are retried once, unless
+// the new connection reports a different chain ID. See Dial for configuring
+// a client with options.
 func NewClient(config Config) (*Client, error) {
 	// Connect to RPC
-	client, err := ethclient.Dial(config.RPCURL)
+	var backend interface {
+		Backend
+		Close()
+	}
+	var err error
+	if scheme := rpcScheme(config.RPCURL); scheme == "http" || scheme == "https" {
+		backend, err = dialRedialBackend(config.RPCURL)
+	} else {
+		backend, err = ethclient.Dial(config.RPCURL)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to RPC: %w", err)
 	}
 
-	// Parse private key
-	privateKey, err := crypto.HexToECDSA(config.PrivateKey)
+	c, err := NewClientWithBackend(backend, config)
 	if err != nil {
-		return nil, fmt.Errorf("invalid private key: %w", err)
+		backend.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// NewClientWithBackend creates a client on an existing chain connection, such
+// as a go-ethereum simulated backend. config.RPCURL is ignored. Without
+// config.PrivateKey or config.Signer the client is read-only, see
+// Config.PrivateKey.
+func NewClientWithBackend(backend Backend, config Config) (*Client, error) {
+	// Use the configured signer or parse the private key, if any
+	signer := config.Signer
+	if signer == nil && config.PrivateKey != "" {
+		local, err := NewLocalSignerFromHex(config.PrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		signer = local
 	}
 
-	// Get address from private key
-	publicKey := privateKey.Public()
-	publicKeyECDSA, ok := publicKey.(*ecdsa.PublicKey)
-	if !ok {
-		return nil, fmt.Errorf("error casting public key")
+	address := config.Address
+	if signer != nil {
+		address = signer.Address()
 	}
-	address := crypto.PubkeyToAddress(*publicKeyECDSA)
 
 	// Get chain ID
-	chainID, err := client.ChainID(context.Background())
+	chainID, err := backend.ChainID(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chain ID: %w", err)
 	}
 
+	c := &Client{
+		config:           config,
+		client:           backend,
+		signer:           signer,
+		address:          address,
+		accounts:         newAccounts(signer, config.Accounts),
+		chainID:          chainID,
+		chainIDCheckedAt: time.Now(),
+	}
+	if redial, ok := backend.(*redialBackend); ok {
+		redial.onRedial = c.afterRedial
+	}
+
+	return c, nil
+}
+
+// CloneWithKey returns a client for a different private key that shares this
+// client's connection and chain ID. Nonces are tracked per address, so the
+// clones can transact independently. The connection is shared, so Close
+// should only be called once all clones are done.
+func (c *Client) CloneWithKey(privateKeyHex string) (*Client, error) {
+	signer, err := NewLocalSignerFromHex(privateKeyHex)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.CloneWithSigner(signer), nil
+}
+
+// CloneWithSigner is CloneWithKey for an arbitrary Signer. The clone has no
+// additional accounts.
+func (c *Client) CloneWithSigner(signer Signer) *Client {
+	config := c.config
+	config.PrivateKey = ""
+	config.Signer = signer
+	config.Accounts = nil
+
 	return &Client{
-		config:     config,
-		client:     client,
-		privateKey: privateKey,
-		address:    address,
-		chainID:    chainID,
-	}, nil
+		config:   config,
+		client:   c.client,
+		signer:   signer,
+		address:  signer.Address(),
+		accounts: newAccounts(signer, nil),
+		chainID:  c.chainID,
+	}
 }
 
 // Address returns the client's address
@@ -163,311 +394,1672 @@ func (c *Client) Address() common.Address {
 	return c.address
 }
 
+// PublicKey returns the public key of the client's signing key, or nil for
+// a read-only client or a signer that does not expose its key
+func (c *Client) PublicKey() *ecdsa.PublicKey {
+	if signer, ok := c.signer.(interface{ PublicKey() *ecdsa.PublicKey }); ok {
+		return signer.PublicKey()
+	}
+	return nil
+}
+
+// Signer returns the client's signer, or nil for a read-only client
+func (c *Client) Signer() Signer {
+	return c.signer
+}
+
+// ReadOnly reports whether the client was created without a signer
+func (c *Client) ReadOnly() bool {
+	return c.signer == nil
+}
+
+// requireSigner returns ErrReadOnly if the client has no signer
+func (c *Client) requireSigner() error {
+	if c.signer == nil {
+		return ErrReadOnly
+	}
+	return nil
+}
+
 // ChainID returns the chain ID
 func (c *Client) ChainID() *big.Int {
 	return c.chainID
 }
 
+// Contracts returns the protocol contract addresses the client uses
+func (c *Client) Contracts() ContractAddresses {
+	return c.config.Contracts
+}
+
 // Close closes the client connection
 func (c *Client) Close() {
-	c.client.Close()
+	if closer, ok := c.client.(interface{ Close() }); ok {
+		closer.Close()
+	}
 }
 
-// getTransactOpts returns transaction options for signing
-func (c *Client) getTransactOpts(ctx context.Context) (*bind.TransactOpts, error) {
-	nonce, err := c.client.PendingNonceAt(ctx, c.address)
+// chainIDCheckInterval is how often the node's chain ID is re-verified
+// before signing transactions
+const chainIDCheckInterval = time.Minute
+
+// VerifyChainID checks that the node still reports the chain ID the client
+// was created with, returning ErrChainIDChanged otherwise. Transactions are
+// never signed for a different chain than the original one.
+func (c *Client) VerifyChainID(ctx context.Context) error {
+	// chainMu is not held during the query, since a re-dial while it runs
+	// verifies the new connection through checkChainID
+	chainID, err := c.client.ChainID(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get nonce: %w", err)
+		return fmt.Errorf("failed to get chain ID: %w", err)
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	if chainID.Cmp(c.chainID) != 0 {
+		return fmt.Errorf("%w: expected %s, node reports %s", ErrChainIDChanged, c.chainID, chainID)
+	}
+
+	c.chainMu.Lock()
+	c.chainIDCheckedAt = time.Now()
+	c.chainMu.Unlock()
+	return nil
+}
+
+// checkChainID re-verifies the chain ID if the last check is stale
+func (c *Client) checkChainID(ctx context.Context) error {
+	c.chainMu.Lock()
+	stale := time.Since(c.chainIDCheckedAt) >= chainIDCheckInterval
+	c.chainMu.Unlock()
+
+	if !stale {
+		return nil
 	}
+	return c.VerifyChainID(ctx)
+}
+
+// getTransactOpts returns transaction options for signing as the account
+// selected with WithFrom
+func (c *Client) getTransactOpts(ctx context.Context, opts ...TxOption) (*bind.TransactOpts, error) {
+	o := applyTxOptions(opts)
 
-	auth, err := bind.NewKeyedTransactorWithChainID(c.privateKey, c.chainID)
+	acct, err := c.sender(o.from)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create transactor: %w", err)
+		return nil, err
+	}
+
+	if err := c.checkChainID(ctx); err != nil {
+		return nil, err
+	}
+
+	var nonce uint64
+	if o.nonce != nil {
+		nonce = *o.nonce
+	} else {
+		pending, err := c.client.PendingNonceAt(ctx, acct.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get nonce: %w", err)
+		}
+		nonce = pending
+	}
+
+	// An explicit gas price forces a legacy transaction; otherwise EIP-1559
+	// fees are used wherever the chain has a base fee
+	gasPrice := o.gasPrice
+	var feeCap, tip *big.Int
+	if gasPrice == nil {
+		var dynamic bool
+		var err error
+		feeCap, tip, dynamic, err = c.suggestDynamicFees(ctx, o.feeStrategy)
+		if err != nil {
+			return nil, err
+		}
+
+		if !dynamic {
+			gasPrice, err = c.client.SuggestGasPrice(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get gas price: %w", err)
+			}
+		}
+	}
+
+	// Without a limit the transactor estimates one itself at submission, so
+	// the balance can only be checked when the limit is known
+	if o.gasLimit != 0 {
+		maxPrice := gasPrice
+		if maxPrice == nil {
+			maxPrice = feeCap
+		}
+		if err := c.checkGasBalance(ctx, acct.address, o.gasLimit, maxPrice, nil); err != nil {
+			return nil, err
+		}
 	}
 
-	auth.Nonce = big.NewInt(int64(nonce))
+	auth := &bind.TransactOpts{
+		From: acct.address,
+		Signer: func(from common.Address, tx *types.Transaction) (*types.Transaction, error) {
+			if from != acct.address {
+				return nil, bind.ErrNotAuthorized
+			}
+			return c.signTx(ctx, acct.signer, tx)
+		},
+	}
+
+	auth.Nonce = new(big.Int).SetUint64(nonce)
 	auth.Value = big.NewInt(0)
-	auth.GasLimit = uint64(500000)
+	auth.GasLimit = o.gasLimit
 	auth.GasPrice = gasPrice
+	auth.GasFeeCap = feeCap
+	auth.GasTipCap = tip
 	auth.Context = ctx
 
 	return auth, nil
 }
 
-// waitForTx waits for a transaction to be mined
-func (c *Client) waitForTx(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
-	receipt, err := bind.WaitMined(ctx, c.client, tx)
+// CheckGasBalance verifies the client's native balance covers gasLimit × gasPrice
+// plus any value sent with the transaction
+func (c *Client) CheckGasBalance(ctx context.Context, gasLimit uint64, gasPrice, value *big.Int) error {
+	return c.checkGasBalance(ctx, c.address, gasLimit, gasPrice, value)
+}
+
+// checkGasBalance is CheckGasBalance for the account from
+func (c *Client) checkGasBalance(ctx context.Context, from common.Address, gasLimit uint64, gasPrice, value *big.Int) error {
+	required := new(big.Int).Mul(new(big.Int).SetUint64(gasLimit), gasPrice)
+	if value != nil {
+		required.Add(required, value)
+	}
+
+	balance, err := c.client.BalanceAt(ctx, from, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to wait for transaction: %w", err)
+		return fmt.Errorf("failed to get native balance: %w", err)
+	}
+
+	if balance.Cmp(required) < 0 {
+		shortfall := new(big.Int).Sub(required, balance)
+		return fmt.Errorf("%w: need %s wei, have %s wei (short %s wei)", ErrInsufficientGasFunds, required, balance, shortfall)
 	}
 
+	return nil
+}
+
+// waitForHash is waitForTx for a transaction known only by its hash
+func (c *Client) waitForHash(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.WaitForConfirmations(ctx, hash, c.config.Confirmations)
+	if err != nil {
+		return nil, &TxWaitError{TxHash: hash, Err: err}
+	}
 	if receipt.Status != types.ReceiptStatusSuccessful {
-		return nil, fmt.Errorf("transaction failed")
+		tx, _, err := c.client.TransactionByHash(ctx, hash)
+		if err != nil {
+			return nil, &TxWaitError{TxHash: hash, Err: ErrTxFailed}
+		}
+		return nil, &TxWaitError{TxHash: hash, Err: c.revertedTxError(ctx, tx, receipt)}
 	}
 
 	return receipt, nil
 }
 
-// ==================== Token Functions ====================
-
-// GetBalance returns the SYNX balance for an address
-func (c *Client) GetBalance(ctx context.Context, address common.Address) (*big.Int, error) {
-	// This would use the generated contract bindings
-	// For demonstration, returning placeholder
-	return big.NewInt(0), nil
+// TxWaitError is returned by write methods that wait for their transaction
+// when the wait fails after submission. TxHash identifies the transaction,
+// which may still be pending if the context was cancelled; pass it to
+// WaitForConfirmations to resume waiting.
+type TxWaitError struct {
+	TxHash common.Hash
+	Err    error
 }
 
-// Transfer transfers SYNX tokens
-func (c *Client) Transfer(ctx context.Context, to common.Address, amount *big.Int) (common.Hash, error) {
-	// Implementation would use contract bindings
-	return common.Hash{}, nil
+func (e *TxWaitError) Error() string {
+	return fmt.Sprintf("transaction %s: %v", e.TxHash.Hex(), e.Err)
 }
 
-// Approve approves token spending
-func (c *Client) Approve(ctx context.Context, spender common.Address, amount *big.Int) (common.Hash, error) {
-	// Implementation would use contract bindings
-	return common.Hash{}, nil
+func (e *TxWaitError) Unwrap() error {
+	return e.Err
 }
 
-// ApproveAll approves all protocol contracts
-func (c *Client) ApproveAll(ctx context.Context) ([]common.Hash, error) {
-	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
-	var hashes []common.Hash
+// waitForTx waits for a transaction to be mined and Config.Confirmations
+// deep. Errors are returned as *TxWaitError. If the transaction reverted, the
+// error wraps ErrTxFailed and, when the revert can be replayed, its
+// *RevertError.
+func (c *Client) waitForTx(ctx context.Context, tx *types.Transaction) (*types.Receipt, error) {
+	ctx, cancel := c.withTimeout(ctx, timeoutWait)
+	defer cancel()
 
-	contracts := []common.Address{
-		c.config.Contracts.PaymentRouter,
-		c.config.Contracts.Reputation,
-		c.config.Contracts.ServiceRegistry,
-		c.config.Contracts.PaymentChannel,
+	receipt, err := bind.WaitMined(ctx, c.client, tx)
+	if err != nil {
+		return nil, &TxWaitError{TxHash: tx.Hash(), Err: fmt.Errorf("failed to wait for transaction: %w", err)}
 	}
 
-	for _, contract := range contracts {
-		if contract != (common.Address{}) {
-			hash, err := c.Approve(ctx, contract, maxUint256)
-			if err != nil {
-				return hashes, err
-			}
-			hashes = append(hashes, hash)
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, &TxWaitError{TxHash: tx.Hash(), Err: c.revertedTxError(ctx, tx, receipt)}
+	}
+
+	if c.config.Confirmations > 1 {
+		receipt, err = c.WaitForConfirmations(ctx, tx.Hash(), c.config.Confirmations)
+		if err != nil {
+			return nil, &TxWaitError{TxHash: tx.Hash(), Err: err}
 		}
 	}
 
-	return hashes, nil
+	return receipt, nil
 }
 
-// ==================== Payment Functions ====================
+// confirmationPollInterval is how often WaitForConfirmations polls the chain
+const confirmationPollInterval = time.Second
 
-// Pay sends a direct payment
-func (c *Client) Pay(ctx context.Context, recipient common.Address, amount *big.Int, metadata []byte) (*PaymentResult, error) {
-	// Generate payment ID
-	paymentID := crypto.Keccak256Hash(
-		[]byte(fmt.Sprintf("pay-%d-%s", time.Now().UnixNano(), recipient.Hex())),
-	)
+// WaitForConfirmations waits until a transaction is confirmations blocks deep
+// (1 means mined) and returns its receipt. If the transaction is no longer in
+// the block it was first seen in, it returns ErrReorgDetected.
+func (c *Client) WaitForConfirmations(ctx context.Context, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	if confirmations == 0 {
+		confirmations = 1
+	}
 
-	// Implementation would call the PaymentRouter contract
-	// For demonstration:
-	return &PaymentResult{
-		TxHash:    common.Hash{},
-		PaymentID: paymentID,
-		Amount:    amount,
-		Fee:       big.NewInt(0),
-	}, nil
-}
+	ctx, cancel := c.withTimeout(ctx, timeoutWait)
+	defer cancel()
 
-// BatchPayment represents a single payment in a batch
-type BatchPayment struct {
-	Recipient common.Address
-	Amount    *big.Int
-}
+	ticker := time.NewTicker(confirmationPollInterval)
+	defer ticker.Stop()
 
-// BatchPay sends multiple payments in one transaction
-func (c *Client) BatchPay(ctx context.Context, payments []BatchPayment) (common.Hash, error) {
-	// Implementation would call batchPay on PaymentRouter
-	return common.Hash{}, nil
-}
+	var first *types.Receipt
+	for {
+		receipt, err := c.client.TransactionReceipt(ctx, txHash)
+		switch {
+		case err == nil:
+			if first == nil {
+				first = receipt
+			}
+			if receipt.BlockHash != first.BlockHash {
+				return nil, fmt.Errorf("%w: %s moved from block %s to %s", ErrReorgDetected, txHash.Hex(), first.BlockHash.Hex(), receipt.BlockHash.Hex())
+			}
+		case errors.Is(err, ethereum.NotFound):
+			if first != nil {
+				return nil, fmt.Errorf("%w: %s dropped from block %s", ErrReorgDetected, txHash.Hex(), first.BlockHash.Hex())
+			}
+		default:
+			return nil, fmt.Errorf("failed to get receipt: %w", err)
+		}
 
-// CreateEscrow creates an escrow payment
-func (c *Client) CreateEscrow(ctx context.Context, recipient, arbiter common.Address, amount *big.Int, deadline uint64) ([32]byte, error) {
-	// Implementation
-	return [32]byte{}, nil
-}
+		if first != nil {
+			head, err := c.client.BlockNumber(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get block number: %w", err)
+			}
 
-// ReleaseEscrow releases an escrow payment
-func (c *Client) ReleaseEscrow(ctx context.Context, escrowID [32]byte) (common.Hash, error) {
-	return common.Hash{}, nil
-}
+			if head+1 >= first.BlockNumber.Uint64()+confirmations {
+				header, err := c.client.HeaderByNumber(ctx, first.BlockNumber)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get header: %w", err)
+				}
+				if header.Hash() != first.BlockHash {
+					return nil, fmt.Errorf("%w: block %d of %s is no longer canonical", ErrReorgDetected, first.BlockNumber, txHash.Hex())
+				}
+
+				return first, nil
+			}
+		}
 
-// CreateStream creates a payment stream
-func (c *Client) CreateStream(ctx context.Context, recipient common.Address, totalAmount *big.Int, startTime, endTime uint64) ([32]byte, error) {
-	return [32]byte{}, nil
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
 
-// ==================== Agent Functions ====================
+// ==================== Token Functions ====================
 
-// RegisterAgentParams holds parameters for agent registration
-type RegisterAgentParams struct {
-	Name        string
-	MetadataURI string
-	Stake       *big.Int
-}
+// GetBalance returns the confirmed SYNX balance for an address as of the
+// latest block
+func (c *Client) GetBalance(ctx context.Context, address common.Address) (*big.Int, error) {
+	out, err := c.callContract(ctx, ContractToken, "balanceOf", address)
+	if err != nil {
+		return nil, err
+	}
 
-// RegisterAgent registers as an AI agent
-func (c *Client) RegisterAgent(ctx context.Context, params RegisterAgentParams) (common.Hash, error) {
-	// Implementation
-	return common.Hash{}, nil
+	return out[0].(*big.Int), nil
 }
 
-// GetAgent returns agent information
-func (c *Client) GetAgent(ctx context.Context, address common.Address) (*AgentInfo, error) {
-	// Implementation
-	return &AgentInfo{}, nil
-}
+// GetPendingBalance returns the SYNX balance for an address at the pending
+// block, i.e. including the effect of transactions still in the mempool.
+// Unlike GetBalance it reflects payments that have been sent but not mined,
+// which helps avoid double-spending in rapid sequences of payments.
+func (c *Client) GetPendingBalance(ctx context.Context, address common.Address) (*big.Int, error) {
+	out, err := c.callContractOpts(&bind.CallOpts{Context: ctx, Pending: true}, ContractToken, "balanceOf", address)
+	if err != nil {
+		return nil, err
+	}
 
-// IncreaseStake increases agent stake
-func (c *Client) IncreaseStake(ctx context.Context, amount *big.Int) (common.Hash, error) {
-	return common.Hash{}, nil
+	return out[0].(*big.Int), nil
 }
 
-// CreateDispute creates a dispute against another agent
-func (c *Client) CreateDispute(ctx context.Context, defendant common.Address, reason string, txID [32]byte) ([32]byte, error) {
-	return [32]byte{}, nil
-}
+// WaitForBalance polls GetBalance every poll interval until address holds at
+// least minimum SYNX or ctx is done, and returns the last balance read. A
+// poll of zero uses DefaultPollInterval.
+func (c *Client) WaitForBalance(ctx context.Context, address common.Address, minimum *big.Int, poll time.Duration) (*big.Int, error) {
+	if poll <= 0 {
+		poll = DefaultPollInterval
+	}
 
-// RateService rates a service provider
-func (c *Client) RateService(ctx context.Context, provider common.Address, category string, rating uint8) (common.Hash, error) {
-	if rating < 1 || rating > 5 {
-		return common.Hash{}, fmt.Errorf("rating must be between 1 and 5")
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		balance, err := c.GetBalance(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		if minimum == nil || balance.Cmp(minimum) >= 0 {
+			return balance, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return balance, ctx.Err()
+		case <-ticker.C:
+		}
 	}
-	return common.Hash{}, nil
 }
 
-// ==================== Service Functions ====================
+// Transfer transfers SYNX tokens. Transfers to the sender itself are
+// allowed. A zero amount returns ErrZeroAmount.
+func (c *Client) Transfer(ctx context.Context, to common.Address, amount *big.Int, opts ...TxOption) (common.Hash, error) {
+	var v validator
+	v.check(requireAddress("to", to))
+	v.check(requireAmount("amount", amount))
+	if err := v.err(); err != nil {
+		return common.Hash{}, err
+	}
 
-// RegisterServiceParams holds parameters for service registration
-type RegisterServiceParams struct {
-	Name         string
-	Category     string
-	Description  string
-	Endpoint     string
-	BasePrice    *big.Int
-	PricingModel PricingModel
-}
+	tx, err := c.transactContract(ctx, ContractToken, "transfer", []interface{}{to, amount}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
 
-// RegisterService registers a new service
-func (c *Client) RegisterService(ctx context.Context, params RegisterServiceParams) ([32]byte, error) {
-	return [32]byte{}, nil
+	return tx.Hash(), nil
 }
 
-// GetService returns service information
-func (c *Client) GetService(ctx context.Context, serviceID [32]byte) (*ServiceInfo, error) {
-	return &ServiceInfo{}, nil
-}
+// Approve approves token spending. A zero amount is allowed and revokes the
+// spender's allowance; a nil amount returns ErrZeroAmount. With
+// Config.SafeApprove set, it goes through SafeApprove and resetTx is the hash
+// of the allowance reset, if one was needed; otherwise resetTx is zero.
+func (c *Client) Approve(ctx context.Context, spender common.Address, amount *big.Int, opts ...TxOption) (resetTx, approveTx common.Hash, err error) {
+	if c.config.SafeApprove {
+		return c.SafeApprove(ctx, spender, amount, opts...)
+	}
 
-// FindServicesByCategory finds services by category
-func (c *Client) FindServicesByCategory(ctx context.Context, category string) ([][32]byte, error) {
-	return nil, nil
-}
+	if err := requireAllowance("amount", amount); err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
 
-// CalculatePrice calculates price for a service
-func (c *Client) CalculatePrice(ctx context.Context, serviceID [32]byte, quantity uint64) (*big.Int, error) {
-	return big.NewInt(0), nil
+	approveTx, err = c.approve(ctx, spender, amount, opts...)
+	return common.Hash{}, approveTx, err
 }
 
-// RequestQuote requests a quote for a service
-func (c *Client) RequestQuote(ctx context.Context, serviceID [32]byte, quantity uint64, specs []byte) ([32]byte, error) {
-	return [32]byte{}, nil
-}
+// approve submits a single approve call
+func (c *Client) approve(ctx context.Context, spender common.Address, amount *big.Int, opts ...TxOption) (common.Hash, error) {
+	tx, err := c.transactContract(ctx, ContractToken, "approve", []interface{}{spender, amount}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
 
-// AcceptQuote accepts a quote and makes payment
-func (c *Client) AcceptQuote(ctx context.Context, quoteID [32]byte) (common.Hash, error) {
-	return common.Hash{}, nil
+	return tx.Hash(), nil
 }
 
-// ==================== Channel Functions ====================
+// SafeApprove changes an allowance without exposing it to the ERC-20 approve
+// race, in which a spender watching the mempool spends the old allowance
+// before the change and the new one after it. If the allowance is being
+// changed from one nonzero value to another, it is first reset to zero and
+// the reset is waited on; resetTx is zero if no reset was needed. WithNonce
+// cannot be used when a reset is needed. A nil amount returns ErrZeroAmount.
+func (c *Client) SafeApprove(ctx context.Context, spender common.Address, amount *big.Int, opts ...TxOption) (resetTx, approveTx common.Hash, err error) {
+	if err := requireAllowance("amount", amount); err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
 
-// OpenChannel opens a payment channel
-func (c *Client) OpenChannel(ctx context.Context, counterparty common.Address, myDeposit, theirDeposit *big.Int) ([32]byte, error) {
-	return [32]byte{}, nil
-}
+	current, err := c.GetAllowance(ctx, c.senderAddress(applyTxOptions(opts)), spender)
+	if err != nil {
+		return common.Hash{}, common.Hash{}, err
+	}
 
-// GetChannel returns channel information
-func (c *Client) GetChannel(ctx context.Context, party1, party2 common.Address) (*ChannelInfo, error) {
-	return &ChannelInfo{}, nil
-}
+	if current.Sign() > 0 && amount.Sign() > 0 && current.Cmp(amount) != 0 {
+		if applyTxOptions(opts).nonce != nil {
+			return common.Hash{}, common.Hash{}, fmt.Errorf("cannot use WithNonce when resetting a nonzero allowance")
+		}
 
-// SignChannelState signs a channel state update
-func (c *Client) SignChannelState(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) ([]byte, error) {
-	// Create message hash
-	message := crypto.Keccak256(
-		channelID[:],
-		common.LeftPadBytes(balance1.Bytes(), 32),
-		common.LeftPadBytes(balance2.Bytes(), 32),
-		common.LeftPadBytes(big.NewInt(int64(nonce)).Bytes(), 32),
-	)
+		tx, err := c.transactContract(ctx, ContractToken, "approve", []interface{}{spender, new(big.Int)}, opts...)
+		if err != nil {
+			return common.Hash{}, common.Hash{}, fmt.Errorf("failed to reset allowance: %w", err)
+		}
+		resetTx = tx.Hash()
 
-	// Sign the message
-	signature, err := crypto.Sign(message, c.privateKey)
+		if _, err := c.waitForTx(ctx, tx); err != nil {
+			return resetTx, common.Hash{}, err
+		}
+	}
+
+	approveTx, err = c.approve(ctx, spender, amount, opts...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to sign message: %w", err)
+		return resetTx, common.Hash{}, err
 	}
 
-	return signature, nil
+	return resetTx, approveTx, nil
 }
 
-// CooperativeClose cooperatively closes a channel
-func (c *Client) CooperativeClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte) (common.Hash, error) {
-	return common.Hash{}, nil
-}
+// GetAllowance returns the SYNX amount spender may transfer on behalf of owner
+func (c *Client) GetAllowance(ctx context.Context, owner, spender common.Address) (*big.Int, error) {
+	out, err := c.callContract(ctx, ContractToken, "allowance", owner, spender)
+	if err != nil {
+		return nil, err
+	}
 
-// InitiateClose initiates unilateral channel close
-func (c *Client) InitiateClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte) (common.Hash, error) {
-	return common.Hash{}, nil
+	return out[0].(*big.Int), nil
 }
 
-// ChallengeClose challenges a channel close with newer state
-func (c *Client) ChallengeClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte) (common.Hash, error) {
-	return common.Hash{}, nil
+// ApprovalResult is the outcome of one approval made by ApproveAll
+type ApprovalResult struct {
+	Contract common.Address
+	Hash     common.Hash
+	// ResetHash is the allowance reset made first with Config.SafeApprove,
+	// or zero
+	ResetHash common.Hash
+	Err       error
 }
 
-// FinalizeClose finalizes channel close after challenge period
-func (c *Client) FinalizeClose(ctx context.Context, counterparty common.Address) (common.Hash, error) {
-	return common.Hash{}, nil
+// ApproveAll approves all protocol contracts for the maximum amount. Every
+// configured contract is attempted even if an earlier approval fails; the
+// returned error joins the individual failures.
+func (c *Client) ApproveAll(ctx context.Context) ([]ApprovalResult, error) {
+	maxUint256 := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+	contracts := []common.Address{
+		c.config.Contracts.PaymentRouter,
+		c.config.Contracts.Reputation,
+		c.config.Contracts.ServiceRegistry,
+		c.config.Contracts.PaymentChannel,
+	}
+
+	var results []ApprovalResult
+	var errs []error
+	for _, contract := range contracts {
+		if contract == (common.Address{}) {
+			continue
+		}
+
+		reset, hash, err := c.Approve(ctx, contract, maxUint256)
+		if err != nil {
+			err = fmt.Errorf("failed to approve %s: %w", contract.Hex(), err)
+			errs = append(errs, err)
+		}
+		results = append(results, ApprovalResult{Contract: contract, Hash: hash, ResetHash: reset, Err: err})
+	}
+
+	return results, errors.Join(errs...)
 }
 
-// ==================== Utility Functions ====================
+// ApproveExact sets spender's allowance to exactly amount, skipping the
+// transaction if it already is; the returned hash is then zero. Approving only
+// what an upcoming operation needs limits the loss if a spender is ever
+// compromised, at the cost of an approval transaction per operation, whereas
+// ApproveAll pays for one approval per contract up front.
+func (c *Client) ApproveExact(ctx context.Context, spender common.Address, amount *big.Int) (common.Hash, error) {
+	if err := requireAllowance("amount", amount); err != nil {
+		return common.Hash{}, err
+	}
 
-// NetworkInfo contains network information
-type NetworkInfo struct {
-	ChainID     *big.Int
-	BlockNumber uint64
-	GasPrice    *big.Int
+	current, err := c.GetAllowance(ctx, c.address, spender)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if current.Cmp(amount) == 0 {
+		return common.Hash{}, nil
+	}
+
+	_, hash, err := c.Approve(ctx, spender, amount)
+	return hash, err
 }
 
-// GetNetworkInfo returns network information
-func (c *Client) GetNetworkInfo(ctx context.Context) (*NetworkInfo, error) {
-	blockNumber, err := c.client.BlockNumber(ctx)
+// ensureAllowance approves a protocol contract for amount plus the buffer set
+// with WithExactApproval, if requested and the allowance is below that, and
+// waits for the approval
+func (c *Client) ensureAllowance(ctx context.Context, contract string, amount *big.Int, o *txOptions) error {
+	if !o.exactApproval || amount == nil || amount.Sign() == 0 {
+		return nil
+	}
+
+	spender, err := c.contractAddress(contract)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	required := new(big.Int).Set(amount)
+	if o.approvalBuffer != nil {
+		required.Add(required, o.approvalBuffer)
+	}
+
+	current, err := c.GetAllowance(ctx, c.senderAddress(o), spender)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	if current.Cmp(required) >= 0 {
+		return nil
 	}
 
-	return &NetworkInfo{
-		ChainID:     c.chainID,
-		BlockNumber: blockNumber,
-		GasPrice:    gasPrice,
-	}, nil
+	_, hash, err := c.Approve(ctx, spender, required, WithCorrelationID(o.correlationID), WithFrom(o.from))
+	if err != nil {
+		return err
+	}
+
+	_, err = c.waitForHash(ctx, hash)
+	return err
+}
+
+// SpenderApproval is an allowance to grant with ApproveMany
+type SpenderApproval struct {
+	Spender common.Address
+	Amount  *big.Int
+}
+
+// ApproveMany sets the given allowances, submitting the approvals back to
+// back without waiting for them to be mined. With Config.SafeApprove set,
+// approvals that need an allowance reset are made one at a time instead,
+// and each reset's hash precedes that of the approval it makes way for. On
+// failure it returns the hashes of the transactions already submitted.
+func (c *Client) ApproveMany(ctx context.Context, approvals []SpenderApproval) ([]common.Hash, error) {
+	hashes := make([]common.Hash, 0, len(approvals))
+	for _, approval := range approvals {
+		reset, hash, err := c.Approve(ctx, approval.Spender, approval.Amount)
+		if reset != (common.Hash{}) {
+			hashes = append(hashes, reset)
+		}
+		if err != nil {
+			return hashes, fmt.Errorf("failed to approve %s: %w", approval.Spender.Hex(), err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// ==================== Payment Functions ====================
+
+// ComputePaymentID derives a payment ID exactly as PaymentRouter does.
+//
+// The preimage is abi.encodePacked(payer, recipient, amount, timestamp, nonce):
+// the two 20-byte addresses followed by amount, timestamp and nonce, each
+// left-padded to 32 bytes. timestamp is the block timestamp of the block that
+// includes the payment and nonce is the router's nonces(payer) value at that
+// point, so an ID computed before mining is only final if both are known.
+func ComputePaymentID(payer, recipient common.Address, amount *big.Int, timestamp, nonce uint64) [32]byte {
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	return crypto.Keccak256Hash(
+		payer.Bytes(),
+		recipient.Bytes(),
+		common.LeftPadBytes(amount.Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(timestamp).Bytes(), 32),
+		common.LeftPadBytes(new(big.Int).SetUint64(nonce).Bytes(), 32),
+	)
+}
+
+// NamespacedPaymentID derives an application-level ID from a payment ID, so
+// that applications sharing a wallet can tell their payments apart. The
+// router derives on-chain payment IDs itself and has no notion of a
+// namespace, so the result is only meaningful off-chain, e.g. as a key in an
+// application's records or inside payment metadata. An empty namespace
+// returns paymentID unchanged.
+func NamespacedPaymentID(namespace []byte, paymentID [32]byte) [32]byte {
+	if len(namespace) == 0 {
+		return paymentID
+	}
+	return crypto.Keccak256Hash(namespace, paymentID[:])
+}
+
+// AppPaymentID returns the ID of a payment under Config.AppNamespace
+func (c *Client) AppPaymentID(paymentID [32]byte) [32]byte {
+	return NamespacedPaymentID(c.config.AppNamespace, paymentID)
+}
+
+// GetPaymentNonce returns the PaymentRouter nonce for a payer
+func (c *Client) GetPaymentNonce(ctx context.Context, payer common.Address) (uint64, error) {
+	out, err := c.callContract(ctx, ContractPaymentRouter, "nonces", payer)
+	if err != nil {
+		return 0, err
+	}
+
+	return out[0].(*big.Int).Uint64(), nil
+}
+
+// DefaultMaxMetadataBytes is the default limit on payment metadata size
+const DefaultMaxMetadataBytes = 1024
+
+// HashMetadata returns the keccak256 content hash of a metadata payload.
+// Large payloads should be stored off-chain (e.g. IPFS or the service's own
+// storage) with only this hash passed to Pay, keeping calldata small.
+func HashMetadata(data []byte) [32]byte {
+	return crypto.Keccak256Hash(data)
+}
+
+// validateMetadata checks payment metadata against the configured size limit
+func (c *Client) validateMetadata(metadata []byte) error {
+	limit := c.config.MaxMetadataBytes
+	if limit == 0 {
+		limit = DefaultMaxMetadataBytes
+	}
+
+	if len(metadata) > limit {
+		return fmt.Errorf("%w: %d bytes exceeds limit of %d; store the payload off-chain and pass HashMetadata instead", ErrMetadataTooLarge, len(metadata), limit)
+	}
+
+	return nil
+}
+
+// requireAmount returns ErrZeroAmount if amount is nil or not positive
+func requireAmount(name string, amount *big.Int) error {
+	if amount == nil || amount.Sign() <= 0 {
+		return fmt.Errorf("%w: %s is %v", ErrZeroAmount, name, amount)
+	}
+	return nil
+}
+
+// Pay sends a direct payment, waits for it to be mined and returns the
+// payment ID and protocol fee from the router's PaymentExecuted event.
+// Metadata is emitted on-chain and must not exceed Config.MaxMetadataBytes.
+// A zero amount returns ErrZeroAmount.
+func (c *Client) Pay(ctx context.Context, recipient common.Address, amount *big.Int, metadata []byte, opts ...TxOption) (*PaymentResult, error) {
+	var v validator
+	v.check(requireRecipient("recipient", recipient, c.senderAddress(applyTxOptions(opts))))
+	v.check(requireAmount("amount", amount))
+	v.check(c.validateMetadata(metadata))
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	o := applyTxOptions(opts)
+	if err := c.checkMaxFee(ctx, c.senderAddress(o), []*big.Int{amount}, o); err != nil {
+		return nil, err
+	}
+
+	if err := c.ensureAllowance(ctx, ContractPaymentRouter, amount, o); err != nil {
+		return nil, err
+	}
+
+	c.logDebug(ctx, "paying",
+		c.logAddress("recipient", recipient),
+		slog.String("amount", amount.String()),
+		logMetadata("metadata", metadata),
+		slog.String("correlation_id", o.correlationID),
+	)
+
+	opts = append(opts[:len(opts):len(opts)], maxFeeChecked())
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "pay", []interface{}{recipient, amount, [32]byte{}, string(metadata)}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["PaymentExecuted"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.PaymentRouter || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return nil, err
+		}
+
+		paymentID := fields["paymentId"].([32]byte)
+		return &PaymentResult{
+			TxHash:       tx.Hash(),
+			PaymentID:    paymentID,
+			Amount:       fields["amount"].(*big.Int),
+			Fee:          fields["fee"].(*big.Int),
+			AppPaymentID: c.AppPaymentID(paymentID),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// BatchPayment represents a single payment in a batch
+type BatchPayment struct {
+	Recipient common.Address
+	Amount    *big.Int
+}
+
+// BatchPay sends multiple payments in one transaction. Any zero amount
+// returns ErrZeroAmount. WithMaxFee caps the total protocol fee of the batch.
+func (c *Client) BatchPay(ctx context.Context, payments []BatchPayment, opts ...TxOption) (common.Hash, error) {
+	var v validator
+	if len(payments) == 0 {
+		v.check(fmt.Errorf("no payments"))
+	}
+	o := applyTxOptions(opts)
+	sender := c.senderAddress(o)
+	for i, payment := range payments {
+		v.check(requireRecipient(fmt.Sprintf("payments[%d].Recipient", i), payment.Recipient, sender))
+		v.check(requireAmount(fmt.Sprintf("payments[%d].Amount", i), payment.Amount))
+	}
+	if err := v.err(); err != nil {
+		return common.Hash{}, err
+	}
+
+	recipients := make([]common.Address, len(payments))
+	amounts := make([]*big.Int, len(payments))
+	serviceTypes := make([][32]byte, len(payments))
+	total := new(big.Int)
+	for i, payment := range payments {
+		recipients[i] = payment.Recipient
+		amounts[i] = payment.Amount
+		total.Add(total, payment.Amount)
+	}
+
+	if err := c.checkMaxFee(ctx, sender, amounts, o); err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := c.ensureAllowance(ctx, ContractPaymentRouter, total, o); err != nil {
+		return common.Hash{}, err
+	}
+
+	opts = append(opts[:len(opts):len(opts)], maxFeeChecked())
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "batchPay", []interface{}{recipients, amounts, serviceTypes}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// CreateEscrow creates an escrow payment. A zero amount returns ErrZeroAmount.
+// The router fixes the protocol fee, deducted on release, at creation.
+func (c *Client) CreateEscrow(ctx context.Context, recipient, arbiter common.Address, amount *big.Int, deadline uint64, opts ...TxOption) ([32]byte, error) {
+	o := applyTxOptions(opts)
+
+	var v validator
+	v.check(requireRecipient("recipient", recipient, c.senderAddress(o)))
+	v.check(requireAmount("amount", amount))
+	if deadline == 0 {
+		v.check(fmt.Errorf("deadline must be set"))
+	}
+	if err := v.err(); err != nil {
+		return [32]byte{}, err
+	}
+
+	if err := c.checkMaxFee(ctx, c.senderAddress(o), []*big.Int{amount}, o); err != nil {
+		return [32]byte{}, err
+	}
+
+	if err := c.ensureAllowance(ctx, ContractPaymentRouter, amount, o); err != nil {
+		return [32]byte{}, err
+	}
+
+	opts = append(opts[:len(opts):len(opts)], maxFeeChecked())
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "createEscrow", []interface{}{recipient, arbiter, amount, new(big.Int).SetUint64(deadline), [32]byte{}}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["EscrowCreated"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.PaymentRouter || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return fields["escrowId"].([32]byte), nil
+	}
+
+	return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// ReleaseEscrow releases an escrow payment to its recipient. Only the sender
+// or the arbiter can release an escrow created by CreateEscrow, which sets no
+// release condition.
+func (c *Client) ReleaseEscrow(ctx context.Context, escrowID [32]byte, opts ...TxOption) (common.Hash, error) {
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "releaseEscrow", []interface{}{escrowID, []byte{}}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// CreateStream creates a payment stream. The router starts streams when the
+// transaction is mined, so only the length endTime - startTime is used. A
+// zero total amount returns ErrZeroAmount. The router deducts the protocol
+// fee from each withdrawal at the rate then current; WithMaxFee is checked
+// against the fee on totalAmount at the current rate.
+func (c *Client) CreateStream(ctx context.Context, recipient common.Address, totalAmount *big.Int, startTime, endTime uint64, opts ...TxOption) ([32]byte, error) {
+	o := applyTxOptions(opts)
+
+	var v validator
+	v.check(requireRecipient("recipient", recipient, c.senderAddress(o)))
+	v.check(requireAmount("totalAmount", totalAmount))
+	if endTime <= startTime {
+		v.check(fmt.Errorf("endTime %d must be after startTime %d", endTime, startTime))
+	}
+	if err := v.err(); err != nil {
+		return [32]byte{}, err
+	}
+
+	if err := c.checkMaxFee(ctx, c.senderAddress(o), []*big.Int{totalAmount}, o); err != nil {
+		return [32]byte{}, err
+	}
+
+	if err := c.ensureAllowance(ctx, ContractPaymentRouter, totalAmount, o); err != nil {
+		return [32]byte{}, err
+	}
+
+	duration := new(big.Int).SetUint64(endTime - startTime)
+	opts = append(opts[:len(opts):len(opts)], maxFeeChecked())
+	tx, err := c.transactContract(ctx, ContractPaymentRouter, "createStream", []interface{}{recipient, totalAmount, duration}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	event := c.contractABI(ContractPaymentRouter).Events["StreamCreated"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.PaymentRouter || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return fields["streamId"].([32]byte), nil
+	}
+
+	return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// ==================== Agent Functions ====================
+
+// RegisterAgentParams holds parameters for agent registration
+type RegisterAgentParams struct {
+	Name        string
+	MetadataURI string
+	Stake       *big.Int
+}
+
+// RegisterAgent registers as an AI agent
+// The contract stores no name, so Name is expected to be part of the document
+// at MetadataURI. The stake and any registration fee must already be approved.
+// A zero stake returns ErrZeroAmount.
+func (c *Client) RegisterAgent(ctx context.Context, params RegisterAgentParams, opts ...TxOption) (common.Hash, error) {
+	if err := requireAmount("stake", params.Stake); err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractReputation, "registerAgent", []interface{}{params.MetadataURI, params.Stake}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// GetAgent returns agent information
+func (c *Client) GetAgent(ctx context.Context, address common.Address) (*AgentInfo, error) {
+	out, err := c.callContract(ctx, ContractReputation, "getAgent", address)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *abi.ConvertType(out[0], new(agentData)).(*agentData)
+
+	info := &AgentInfo{
+		Registered:             data.Status != agentStatusUnregistered,
+		Stake:                  data.StakedAmount,
+		ReputationScore:        data.ReputationScore.Uint64(),
+		TotalTransactions:      data.TotalTransactions.Uint64(),
+		SuccessfulTransactions: data.SuccessfulTransactions.Uint64(),
+		RegisteredAt:           data.RegistrationTime.Uint64(),
+		Tier:                   Tier(data.Tier),
+		MetadataURI:            data.MetadataURI,
+	}
+	if info.TotalTransactions > 0 {
+		info.SuccessRate = float64(info.SuccessfulTransactions) / float64(info.TotalTransactions)
+	}
+
+	return info, nil
+}
+
+// GetAgents returns agent information for several addresses, in input order
+func (c *Client) GetAgents(ctx context.Context, addresses []common.Address) ([]*AgentInfo, error) {
+	agents := make([]*AgentInfo, len(addresses))
+	for i, address := range addresses {
+		agent, err := c.GetAgent(ctx, address)
+		if err != nil {
+			return nil, err
+		}
+		agents[i] = agent
+	}
+
+	return agents, nil
+}
+
+// successRatePrior is the number of virtual transactions blended into
+// WeightedSuccessRate, so that a handful of successes does not outrank a
+// long track record
+const successRatePrior = 10
+
+// WeightedSuccessRate returns the agent's success rate smoothed towards 50%
+// for agents with few transactions
+func (a *AgentInfo) WeightedSuccessRate() float64 {
+	return (float64(a.SuccessfulTransactions) + successRatePrior*0.5) / (float64(a.TotalTransactions) + successRatePrior)
+}
+
+// GetStakeRequirements returns the minimum stake for each tier above Unverified.
+// The requirements are read from the Reputation contract once and cached.
+func (c *Client) GetStakeRequirements(ctx context.Context) (map[Tier]*big.Int, error) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+
+	if c.stakeRequirements == nil {
+		requirements := make(map[Tier]*big.Int)
+		for tier := TierBronze; tier <= TierDiamond; tier++ {
+			out, err := c.callContract(ctx, ContractReputation, "getTierRequirements", uint8(tier))
+			if err != nil {
+				return nil, err
+			}
+
+			data := *abi.ConvertType(out[0], new(tierRequirementsData)).(*tierRequirementsData)
+			requirements[tier] = data.MinStake
+		}
+		c.stakeRequirements = requirements
+	}
+
+	result := make(map[Tier]*big.Int, len(c.stakeRequirements))
+	for tier, stake := range c.stakeRequirements {
+		result[tier] = new(big.Int).Set(stake)
+	}
+
+	return result, nil
+}
+
+// IncreaseStake increases agent stake. A zero amount returns ErrZeroAmount.
+func (c *Client) IncreaseStake(ctx context.Context, amount *big.Int, opts ...TxOption) (common.Hash, error) {
+	if err := requireAmount("amount", amount); err != nil {
+		return common.Hash{}, err
+	}
+
+	if err := c.ensureAllowance(ctx, ContractReputation, amount, applyTxOptions(opts)); err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractReputation, "addStake", []interface{}{amount}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// CreateDispute creates a dispute against another agent over the transaction
+// txID, disputing amount, and returns the dispute ID. reason is stored as the
+// dispute's evidence. A nil amount disputes no particular amount.
+func (c *Client) CreateDispute(ctx context.Context, defendant common.Address, reason string, txID [32]byte, amount *big.Int, opts ...TxOption) ([32]byte, error) {
+	var v validator
+	v.check(requireRecipient("defendant", defendant, c.senderAddress(applyTxOptions(opts))))
+	v.check(requireOptionalAmount("amount", amount))
+	if err := v.err(); err != nil {
+		return [32]byte{}, err
+	}
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	tx, err := c.transactContract(ctx, ContractReputation, "createDispute", []interface{}{defendant, txID, amount, reason}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	event := c.contractABI(ContractReputation).Events["DisputeCreated"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.Reputation || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return fields["disputeId"].([32]byte), nil
+	}
+
+	return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// RateService rates a service provider in a category from 1 to 5
+func (c *Client) RateService(ctx context.Context, provider common.Address, category string, rating uint8, opts ...TxOption) (common.Hash, error) {
+	var v validator
+	checkRating(&v, "", provider, rating)
+	if err := v.err(); err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractReputation, "rateService", []interface{}{provider, CategoryID(category), rating}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// ServiceRating is one rating submitted by BatchRateServices
+type ServiceRating struct {
+	Provider common.Address
+	Category string
+	Rating   uint8
+}
+
+// BatchRateServices submits several ratings back to back without waiting for
+// them to be mined. The Reputation contract has no batch
+// method, so each rating is its own transaction. Every rating is validated
+// before any is submitted; on a later failure the hashes of the ratings
+// already submitted are returned.
+func (c *Client) BatchRateServices(ctx context.Context, ratings []ServiceRating) ([]common.Hash, error) {
+	var v validator
+	for i, rating := range ratings {
+		checkRating(&v, fmt.Sprintf("ratings[%d].", i), rating.Provider, rating.Rating)
+	}
+	if err := v.err(); err != nil {
+		return nil, err
+	}
+
+	hashes := make([]common.Hash, 0, len(ratings))
+	for _, rating := range ratings {
+		hash, err := c.RateService(ctx, rating.Provider, rating.Category, rating.Rating)
+		if err != nil {
+			return hashes, fmt.Errorf("failed to rate %s: %w", rating.Provider.Hex(), err)
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, nil
+}
+
+// checkRating records problems with a rating's provider and value, naming the
+// parameters with prefix
+func checkRating(v *validator, prefix string, provider common.Address, rating uint8) {
+	if provider == (common.Address{}) {
+		v.check(fmt.Errorf("%w: %sprovider is the zero address", ErrInvalidRecipient, prefix))
+	}
+	if rating < 1 || rating > 5 {
+		v.check(fmt.Errorf("%srating must be between 1 and 5", prefix))
+	}
+}
+
+// ==================== Service Functions ====================
+
+// RegisterServiceParams holds parameters for service registration
+type RegisterServiceParams struct {
+	Name         string
+	Category     string
+	Description  string
+	Endpoint     string
+	BasePrice    *big.Int
+	PricingModel PricingModel
+	MetadataURI  string
+	// MinAmount and MaxAmount bound the request amount. Nil means zero.
+	MinAmount *big.Int
+	MaxAmount *big.Int
+
+	// SkipDuplicateCheck skips the lookup of the provider's existing services
+	SkipDuplicateCheck bool
+}
+
+// RegisterService registers a new service, waits for it to be mined and
+// returns the service ID. The registry's registration fee, if any, must
+// already be approved. If the sending account, see WithFrom, already has an
+// active service with the same name and category, its ID is returned with
+// ErrServiceAlreadyExists.
+// The endpoint must be an https or wss URL unless
+// Config.AllowInsecureEndpoints is set.
+func (c *Client) RegisterService(ctx context.Context, params RegisterServiceParams, opts ...TxOption) ([32]byte, error) {
+	var v validator
+	v.check(requireAmount("base price", params.BasePrice))
+	v.check(c.validateEndpoint(params.Endpoint))
+	if err := v.err(); err != nil {
+		return [32]byte{}, err
+	}
+
+	acct, err := c.sender(applyTxOptions(opts).from)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	if !params.SkipDuplicateCheck {
+		existing, found, err := c.findService(ctx, acct.address, params.Name, params.Category)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		if found {
+			return existing, fmt.Errorf("%w: %q in %s as %x", ErrServiceAlreadyExists, params.Name, normalizeCategory(params.Category), existing)
+		}
+	}
+
+	minAmount, maxAmount := params.MinAmount, params.MaxAmount
+	if minAmount == nil {
+		minAmount = new(big.Int)
+	}
+	if maxAmount == nil {
+		maxAmount = new(big.Int)
+	}
+
+	tx, err := c.transactContract(ctx, ContractServiceRegistry, "registerService", []interface{}{
+		CategoryID(params.Category),
+		params.Name,
+		params.Description,
+		params.MetadataURI,
+		params.Endpoint,
+		uint8(params.PricingModel),
+		params.BasePrice,
+		minAmount,
+		maxAmount,
+	}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	event := c.contractABI(ContractServiceRegistry).Events["ServiceRegistered"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.ServiceRegistry || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return fields["serviceId"].([32]byte), nil
+	}
+
+	return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// GetService returns service information
+func (c *Client) GetService(ctx context.Context, serviceID [32]byte) (*ServiceInfo, error) {
+	data, err := c.getServiceData(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toServiceInfo(*data), nil
+}
+
+// FindServicesByCategory returns the IDs of the active services in a
+// category, oldest first. The category is normalized as by CategoryID, and
+// services are found through the ServiceRegistered events since
+// Config.StartBlock. An empty result for a category not in the registry may
+// be a typo, see SuggestCategory.
+func (c *Client) FindServicesByCategory(ctx context.Context, category string) ([][32]byte, error) {
+	registry, err := c.contractAddress(ContractServiceRegistry)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractServiceRegistry).Events["ServiceRegistered"]
+	logs, err := c.filterLogsChunked(ctx, ethereum.FilterQuery{
+		Addresses: []common.Address{registry},
+		Topics:    [][]common.Hash{{event.ID}, nil, nil, {CategoryID(category)}},
+	}, c.config.StartBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceIDs [][32]byte
+	for _, log := range logs {
+		fields, err := decodeEvent(event, log)
+		if err != nil {
+			return nil, err
+		}
+
+		serviceID := fields["serviceId"].([32]byte)
+		data, err := c.getServiceData(ctx, serviceID)
+		if err != nil {
+			return nil, err
+		}
+		if data.Status == serviceStatusActive {
+			serviceIDs = append(serviceIDs, serviceID)
+		}
+	}
+
+	return serviceIDs, nil
+}
+
+// CalculatePrice calculates the price of quantity units of a service: the base
+// price times quantity, after the registry's volume discounts and the
+// service's min/max amount
+func (c *Client) CalculatePrice(ctx context.Context, serviceID [32]byte, quantity uint64) (*big.Int, error) {
+	data, err := c.getServiceData(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	baseAmount := new(big.Int).Mul(data.BasePrice, new(big.Int).SetUint64(quantity))
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getEstimatedPrice", serviceID, baseAmount)
+	if err != nil {
+		return nil, err
+	}
+
+	return out[0].(*big.Int), nil
+}
+
+// QuoteInfo represents a service quote
+type QuoteInfo struct {
+	QuoteID    [32]byte
+	ServiceID  [32]byte
+	Requester  common.Address
+	Amount     *big.Int
+	ValidUntil uint64
+	Accepted   bool
+	ParamsHash [32]byte
+}
+
+// GetQuote returns quote information
+func (c *Client) GetQuote(ctx context.Context, quoteID [32]byte) (*QuoteInfo, error) {
+	out, err := c.callContract(ctx, ContractServiceRegistry, "getQuote", quoteID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := *abi.ConvertType(out[0], new(quoteData)).(*quoteData)
+
+	return &QuoteInfo{
+		QuoteID:    data.QuoteId,
+		ServiceID:  data.ServiceId,
+		Requester:  data.Requester,
+		Amount:     data.EstimatedAmount,
+		ValidUntil: data.ValidUntil.Uint64(),
+		Accepted:   data.Accepted,
+		ParamsHash: data.Params,
+	}, nil
+}
+
+// RequestQuote requests a quote for quantity units of a service, waits for it
+// to be mined and returns the quote ID. specs is committed to by its keccak256
+// hash. If minValidity is nonzero and the quote expires sooner than that after
+// the latest block, the quote ID is returned with ErrQuoteValidityTooShort.
+func (c *Client) RequestQuote(ctx context.Context, serviceID [32]byte, quantity uint64, specs []byte, minValidity time.Duration, opts ...TxOption) ([32]byte, error) {
+	data, err := c.getServiceData(ctx, serviceID)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	estimatedAmount := new(big.Int).Mul(data.BasePrice, new(big.Int).SetUint64(quantity))
+
+	tx, err := c.transactContract(ctx, ContractServiceRegistry, "requestQuote", []interface{}{serviceID, crypto.Keccak256Hash(specs), estimatedAmount}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	var quoteID [32]byte
+	event := c.contractABI(ContractServiceRegistry).Events["QuoteCreated"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.ServiceRegistry || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+		quoteID = fields["quoteId"].([32]byte)
+		break
+	}
+	if quoteID == ([32]byte{}) {
+		return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+	}
+
+	if minValidity > 0 {
+		quote, err := c.GetQuote(ctx, quoteID)
+		if err != nil {
+			return quoteID, err
+		}
+
+		head, err := c.client.HeaderByNumber(ctx, nil)
+		if err != nil {
+			return quoteID, fmt.Errorf("failed to get latest header: %w", err)
+		}
+
+		deadline := head.Time + uint64(minValidity/time.Second)
+		if quote.ValidUntil < deadline {
+			return quoteID, fmt.Errorf("%w: quote expires at %d, need at least %s from %d", ErrQuoteValidityTooShort, quote.ValidUntil, minValidity, head.Time)
+		}
+	}
+
+	return quoteID, nil
+}
+
+// AcceptQuote accepts a quote and makes payment
+func (c *Client) AcceptQuote(ctx context.Context, quoteID [32]byte, opts ...TxOption) (common.Hash, error) {
+	tx, err := c.transactContract(ctx, ContractServiceRegistry, "acceptQuote", []interface{}{quoteID}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// AcceptQuoteAndWait accepts a quote, waits for it to be mined and returns the
+// resulting payment. Quote payments go straight to the provider, so the
+// PaymentID is the quote ID and no protocol fee is charged.
+func (c *Client) AcceptQuoteAndWait(ctx context.Context, quoteID [32]byte, opts ...TxOption) (*PaymentResult, error) {
+	tx, err := c.transactContract(ctx, ContractServiceRegistry, "acceptQuote", []interface{}{quoteID}, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	event := c.contractABI(ContractServiceRegistry).Events["ServiceRequest"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.ServiceRegistry || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return nil, err
+		}
+
+		return &PaymentResult{
+			TxHash:       tx.Hash(),
+			PaymentID:    quoteID,
+			Amount:       fields["amount"].(*big.Int),
+			Fee:          big.NewInt(0),
+			AppPaymentID: c.AppPaymentID(quoteID),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// ==================== Channel Functions ====================
+
+// OpenChannel opens a payment channel, waits for it to be mined and returns
+// the channel ID. Either deposit may be zero (or nil) for a one-way channel,
+// but not both, which returns ErrZeroAmount. Both parties must have approved
+// the PaymentChannel contract for their deposit.
+func (c *Client) OpenChannel(ctx context.Context, counterparty common.Address, myDeposit, theirDeposit *big.Int, opts ...TxOption) ([32]byte, error) {
+	var v validator
+	v.check(requireRecipient("counterparty", counterparty, c.senderAddress(applyTxOptions(opts))))
+	v.check(requireOptionalAmount("myDeposit", myDeposit))
+	v.check(requireOptionalAmount("theirDeposit", theirDeposit))
+	if requireAmount("myDeposit", myDeposit) != nil && requireAmount("theirDeposit", theirDeposit) != nil {
+		v.check(fmt.Errorf("%w: both deposits are zero", ErrZeroAmount))
+	}
+	if err := v.err(); err != nil {
+		return [32]byte{}, err
+	}
+
+	if myDeposit == nil {
+		myDeposit = new(big.Int)
+	}
+	if theirDeposit == nil {
+		theirDeposit = new(big.Int)
+	}
+
+	if err := c.ensureAllowance(ctx, ContractPaymentChannel, myDeposit, applyTxOptions(opts)); err != nil {
+		return [32]byte{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractPaymentChannel, "openChannel", []interface{}{counterparty, myDeposit, theirDeposit}, opts...)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	receipt, err := c.waitForTx(ctx, tx)
+	if err != nil {
+		return [32]byte{}, err
+	}
+
+	event := c.contractABI(ContractPaymentChannel).Events["ChannelOpened"]
+	for _, log := range receipt.Logs {
+		if log.Address != c.config.Contracts.PaymentChannel || len(log.Topics) == 0 || log.Topics[0] != event.ID {
+			continue
+		}
+
+		fields, err := decodeEvent(event, *log)
+		if err != nil {
+			return [32]byte{}, err
+		}
+
+		return fields["channelId"].([32]byte), nil
+	}
+
+	return [32]byte{}, fmt.Errorf("no %s event in transaction %s", event.Name, tx.Hash().Hex())
+}
+
+// GetChannel returns the channel between party1 and party2, preferring an
+// open channel to a closing one and a closing one to a closed one. It
+// returns ErrChannelNotFound if the parties never had a channel. Neither
+// party needs to be the client, so a read-only client can look up any
+// channel.
+func (c *Client) GetChannel(ctx context.Context, party1, party2 common.Address) (*ChannelInfo, error) {
+	channels, err := c.allChannelsWith(ctx, party1, party2)
+	if err != nil {
+		return nil, err
+	}
+
+	var found *ChannelInfo
+	for _, channel := range channels {
+		if channel.Status == ChannelNone {
+			continue
+		}
+		if found == nil || channel.Status < found.Status {
+			found = channel
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("%w: no channel between %s and %s", ErrChannelNotFound, party1.Hex(), party2.Hex())
+	}
+
+	return found, nil
+}
+
+// SignChannelState signs a channel state update for the PaymentChannel
+// contract of Config.Contracts on the client's chain
+func (c *Client) SignChannelState(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) ([]byte, error) {
+	if err := c.requireSigner(); err != nil {
+		return nil, err
+	}
+
+	return c.signChannelState(c.signer, channelID, balance1, balance2, nonce)
+}
+
+// signChannelState signs a channel state update with signer
+func (c *Client) signChannelState(signer Signer, channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) ([]byte, error) {
+	// Sign the message
+	signature, err := c.signMessageWith(signer, c.channelStateMessage(channelID, balance1, balance2, nonce))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	// Use the 27/28 recovery ID expected by ECDSA.recover
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
+
+// SignCooperativeClose signs the final balances of a channel for
+// CooperativeClose. Both participants sign the same balances and nonce.
+func (c *Client) SignCooperativeClose(channelID [32]byte, balance1, balance2 *big.Int, nonce uint64) ([]byte, error) {
+	signature, err := c.signMessage(cooperativeCloseMessage(channelID, balance1, balance2, nonce))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign message: %w", err)
+	}
+	// Use the 27/28 recovery ID expected by ECDSA.recover
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
+
+// CooperativeClose cooperatively closes the client's open channel with
+// counterparty, paying out the final balances immediately. balance1, balance2
+// and the signatures are in the channel's participant order, and the
+// signatures are made with SignCooperativeClose.
+func (c *Client) CooperativeClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte, opts ...TxOption) (common.Hash, error) {
+	return c.closeChannel(ctx, "cooperativeClose", ChannelOpen, counterparty, balance1, balance2, nonce, sig1, sig2, opts...)
+}
+
+// InitiateClose starts a unilateral close of the client's open channel with
+// counterparty with a state signed by both participants with
+// SignChannelState, in the channel's participant order. The counterparty can
+// challenge it with a newer state until the challenge period ends.
+func (c *Client) InitiateClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte, opts ...TxOption) (common.Hash, error) {
+	return c.closeChannel(ctx, "initiateClose", ChannelOpen, counterparty, balance1, balance2, nonce, sig1, sig2, opts...)
+}
+
+// ChallengeClose challenges the close of the client's closing channel with
+// counterparty with a state of a higher nonce, signed by both participants
+func (c *Client) ChallengeClose(ctx context.Context, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte, opts ...TxOption) (common.Hash, error) {
+	return c.closeChannel(ctx, "challenge", ChannelClosing, counterparty, balance1, balance2, nonce, sig1, sig2, opts...)
+}
+
+// closeChannel submits method with a signed state of the channel with
+// counterparty in status
+func (c *Client) closeChannel(ctx context.Context, method string, status ChannelStatus, counterparty common.Address, balance1, balance2 *big.Int, nonce uint64, sig1, sig2 []byte, opts ...TxOption) (common.Hash, error) {
+	acct, err := c.sender(applyTxOptions(opts).from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	channel, err := c.channelWith(ctx, acct.address, counterparty, status)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if err := checkStateSum(channel, balance1, balance2); err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractPaymentChannel, method, []interface{}{channel.ChannelID, balance1, balance2, new(big.Int).SetUint64(nonce), sig1, sig2}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// FinalizeClose pays out the client's closing channel with counterparty once
+// its challenge period is over
+func (c *Client) FinalizeClose(ctx context.Context, counterparty common.Address, opts ...TxOption) (common.Hash, error) {
+	acct, err := c.sender(applyTxOptions(opts).from)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	channel, err := c.channelWith(ctx, acct.address, counterparty, ChannelClosing)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := c.transactContract(ctx, ContractPaymentChannel, "finalizeClose", []interface{}{channel.ChannelID}, opts...)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	return tx.Hash(), nil
+}
+
+// ==================== Utility Functions ====================
+
+// NetworkInfo contains network information
+type NetworkInfo struct {
+	ChainID     *big.Int
+	BlockNumber uint64
+	GasPrice    *big.Int
+
+	// The fields below are only set on EIP-1559 chains. BaseFee is the base
+	// fee of the latest block, SuggestedTip the node's priority fee
+	// suggestion and PendingBlockGasUsedRatio the fill of the pending block.
+	BaseFee                  *big.Int
+	SuggestedTip             *big.Int
+	PendingBlockGasUsedRatio float64
+}
+
+// GetNetworkInfo returns network information
+func (c *Client) GetNetworkInfo(ctx context.Context) (*NetworkInfo, error) {
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NetworkInfo{
+		ChainID:     c.chainID,
+		BlockNumber: head.Number.Uint64(),
+		GasPrice:    gasPrice,
+	}
+	if head.BaseFee == nil {
+		return info, nil
+	}
+
+	info.BaseFee = head.BaseFee
+	info.SuggestedTip, err = c.client.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas tip: %w", err)
+	}
+
+	history, err := c.client.FeeHistory(ctx, 1, big.NewInt(int64(rpc.PendingBlockNumber)), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee history: %w", err)
+	}
+	if len(history.GasUsedRatio) > 0 {
+		info.PendingBlockGasUsedRatio = history.GasUsedRatio[0]
+	}
+
+	return info, nil
 }
 
 // WaitForTransaction waits for a transaction to be confirmed
 func (c *Client) WaitForTransaction(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := c.withTimeout(ctx, timeoutWait)
+	defer cancel()
+
 	for {
 		receipt, err := c.client.TransactionReceipt(ctx, txHash)
 		if err == nil {
@@ -483,33 +2075,68 @@ func (c *Client) WaitForTransaction(ctx context.Context, txHash common.Hash) (*t
 	}
 }
 
-// ParseSYNX parses a SYNX amount string to wei
+// ParseSYNX parses a SYNX amount string to wei, assuming the token's 18
+// decimals. Use ParseSYNXCtx for a token deployed with other decimals.
 func ParseSYNX(amount string) (*big.Int, error) {
-	// Parse decimal string to big.Int with 18 decimals
-	f, ok := new(big.Float).SetString(amount)
-	if !ok {
-		return nil, fmt.Errorf("invalid amount: %s", amount)
-	}
+	return parseUnits(amount, 18)
+}
+
+// FormatSYNX formats wei amount to SYNX string, assuming the token's 18
+// decimals. Use FormatSYNXCtx for a token deployed with other decimals.
+func FormatSYNX(amount *big.Int) string {
+	return formatUnits(amount, 18)
+}
 
-	// Multiply by 10^18
-	multiplier := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-	f.Mul(f, multiplier)
+// parseUnits parses a decimal amount string such as "1.5" or "-0.25" to base
+// units of a token with the given decimals, exactly. Amounts with more
+// fractional digits than decimals, exponents and other notations are
+// rejected.
+func parseUnits(amount string, decimals uint8) (*big.Int, error) {
+	digits := strings.TrimPrefix(amount, "-")
+	whole, fraction, _ := strings.Cut(digits, ".")
+	if whole+fraction == "" || !isDecimalDigits(whole) || !isDecimalDigits(fraction) {
+		return nil, fmt.Errorf("invalid amount: %q", amount)
+	}
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("invalid amount %s: more than %d decimals", amount, decimals)
+	}
 
-	// Convert to big.Int
-	result, _ := f.Int(nil)
+	result, _ := new(big.Int).SetString(whole+fraction+strings.Repeat("0", int(decimals)-len(fraction)), 10)
+	if len(digits) < len(amount) {
+		result.Neg(result)
+	}
 	return result, nil
 }
 
-// FormatSYNX formats wei amount to SYNX string
-func FormatSYNX(amount *big.Int) string {
+// isDecimalDigits reports whether s consists of ASCII digits only
+func isDecimalDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// formatUnits formats base units of a token with the given decimals as an
+// exact decimal string, without trailing fractional zeros, e.g. "1.5"
+func formatUnits(amount *big.Int, decimals uint8) string {
 	if amount == nil {
 		return "0"
 	}
 
-	// Divide by 10^18
-	f := new(big.Float).SetInt(amount)
-	divisor := new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
-	f.Quo(f, divisor)
+	text := new(big.Int).Abs(amount).String()
+	if len(text) <= int(decimals) {
+		text = strings.Repeat("0", int(decimals)-len(text)+1) + text
+	}
 
-	return f.Text('f', 6)
+	split := len(text) - int(decimals)
+	result := text[:split]
+	if fraction := strings.TrimRight(text[split:], "0"); fraction != "" {
+		result += "." + fraction
+	}
+	if amount.Sign() < 0 {
+		result = "-" + result
+	}
+	return result
 }
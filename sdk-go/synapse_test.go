@@ -0,0 +1,819 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestComputePaymentID(t *testing.T) {
+	payer := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	recipient := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	oneSYNX := new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
+	tests := []struct {
+		name      string
+		amount    *big.Int
+		timestamp uint64
+		nonce     uint64
+		want      string
+	}{
+		{
+			// keccak256(abi.encodePacked(payer, recipient, 1e18, 1700000000, 7))
+			name:      "known vector",
+			amount:    oneSYNX,
+			timestamp: 1700000000,
+			nonce:     7,
+			want:      "0x612c7570158f67f0975f6948f9ad8069d8f5e1155b6ec71ca307985e1a3c5140",
+		},
+		{
+			name:      "nil amount hashes as zero",
+			amount:    nil,
+			timestamp: 1700000000,
+			nonce:     7,
+			want:      common.Hash(ComputePaymentID(payer, recipient, new(big.Int), 1700000000, 7)).Hex(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := common.Hash(ComputePaymentID(payer, recipient, tt.amount, tt.timestamp, tt.nonce)).Hex()
+			if got != tt.want {
+				t.Errorf("ComputePaymentID = %s, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if ComputePaymentID(payer, recipient, oneSYNX, 1700000000, 7) == ComputePaymentID(payer, recipient, oneSYNX, 1700000000, 8) {
+		t.Error("payment IDs of consecutive nonces collide")
+	}
+}
+
+func TestPayReturnsRouterPaymentID(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	recipient := testAddress(1)
+	amount := big.NewInt(1_000_000)
+	fee := big.NewInt(100)
+
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "pay", [32]byte{})
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		paymentID := ComputePaymentID(c.Address(), recipient, amount, header.Time, tx.Nonce())
+		return []*types.Log{eventLog(testContracts.PaymentRouter, paymentRouterABI, "PaymentExecuted",
+			[]common.Hash{paymentID, common.BytesToHash(c.Address().Bytes()), common.BytesToHash(recipient.Bytes())},
+			amount, fee, [32]byte{},
+		)}
+	}
+
+	result, err := c.Pay(context.Background(), recipient, amount, nil)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+
+	want := ComputePaymentID(c.Address(), recipient, amount, backend.head().Time, 0)
+	if result.PaymentID != want {
+		t.Errorf("PaymentID = %x, want %x", result.PaymentID, want)
+	}
+	if result.Fee.Cmp(fee) != 0 {
+		t.Errorf("Fee = %s, want %s", result.Fee, fee)
+	}
+}
+
+func TestWriteMethodsReadOnly(t *testing.T) {
+	c, err := NewClientWithBackend(newMockBackend(), Config{Contracts: testContracts, Address: testAddress(0)})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	ctx := context.Background()
+	other := testAddress(1)
+	amount := big.NewInt(100)
+	sig := make([]byte, 65)
+
+	tests := []struct {
+		name string
+		call func() error
+	}{
+		{"Transfer", func() error { _, err := c.Transfer(ctx, other, amount); return err }},
+		{"BatchPay", func() error {
+			_, err := c.BatchPay(ctx, []BatchPayment{{Recipient: other, Amount: amount}})
+			return err
+		}},
+		{"CreateEscrow", func() error { _, err := c.CreateEscrow(ctx, other, other, amount, 1); return err }},
+		{"ReleaseEscrow", func() error { _, err := c.ReleaseEscrow(ctx, [32]byte{1}); return err }},
+		{"CreateStream", func() error { _, err := c.CreateStream(ctx, other, amount, 0, 10); return err }},
+		{"IncreaseStake", func() error { _, err := c.IncreaseStake(ctx, amount); return err }},
+		{"CreateDispute", func() error { _, err := c.CreateDispute(ctx, other, "reason", [32]byte{1}, amount); return err }},
+		{"CooperativeClose", func() error {
+			_, err := c.CooperativeClose(ctx, other, amount, amount, 1, sig, sig)
+			return err
+		}},
+		{"InitiateClose", func() error {
+			_, err := c.InitiateClose(ctx, other, amount, amount, 1, sig, sig)
+			return err
+		}},
+		{"ChallengeClose", func() error {
+			_, err := c.ChallengeClose(ctx, other, amount, amount, 1, sig, sig)
+			return err
+		}},
+		{"FinalizeClose", func() error { _, err := c.FinalizeClose(ctx, other); return err }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(); !errors.Is(err, ErrReadOnly) {
+				t.Errorf("err = %v, want ErrReadOnly", err)
+			}
+		})
+	}
+}
+
+func TestReadOnlyGetChannel(t *testing.T) {
+	backend := newMockBackend()
+	party1, party2 := testAddress(2), testAddress(3)
+	c, err := NewClientWithBackend(backend, Config{Contracts: testContracts})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	withOpenChannels(backend, party1, party2,
+		openChannel{id: [32]byte{1}, deposit: 100, status: ChannelClosed},
+		openChannel{id: [32]byte{2}, deposit: 200},
+	)
+
+	channel, err := c.GetChannel(context.Background(), party1, party2)
+	if err != nil {
+		t.Fatalf("GetChannel: %v", err)
+	}
+	if channel.ChannelID != ([32]byte{2}) || channel.Deposit1.Int64() != 200 {
+		t.Errorf("GetChannel = %+v, want the open channel", channel)
+	}
+
+	if _, err := c.GetChannel(context.Background(), party1, testAddress(4)); !errors.Is(err, ErrChannelNotFound) {
+		t.Errorf("GetChannel without a channel: err = %v, want ErrChannelNotFound", err)
+	}
+}
+
+func TestTransferSubmitsTokenTransfer(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	recipient := testAddress(1)
+
+	hash, err := c.Transfer(context.Background(), recipient, big.NewInt(500))
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	sent := backend.sentTxs()
+	if len(sent) != 1 || sent[0].Hash() != hash {
+		t.Fatalf("sent %d transactions, want the transfer %s", len(sent), hash.Hex())
+	}
+	if *sent[0].To() != testContracts.Token {
+		t.Errorf("transfer sent to %s, want the token", sent[0].To().Hex())
+	}
+
+	want, _ := tokenABI.Pack("transfer", recipient, big.NewInt(500))
+	if !bytes.Equal(sent[0].Data(), want) {
+		t.Errorf("calldata = %x, want %x", sent[0].Data(), want)
+	}
+}
+
+func TestRecipientValidationUsesSender(t *testing.T) {
+	backend := newMockBackend()
+	agent := NewLocalSigner(testKey(2))
+	c := newTestClient(t, backend, Config{Accounts: []Signer{agent}})
+	backend.returns(testContracts.Token, tokenABI, "allowance", new(big.Int).Lsh(big.NewInt(1), 255))
+	ctx := context.Background()
+	amount := big.NewInt(100)
+
+	batchPay := func(recipient common.Address, opts ...TxOption) error {
+		_, err := c.BatchPay(ctx, []BatchPayment{{Recipient: recipient, Amount: amount}}, opts...)
+		return err
+	}
+
+	tests := []struct {
+		name    string
+		call    func() error
+		invalid bool
+	}{
+		{"pay the client's account", func() error { return batchPay(c.Address()) }, true},
+		{"pay the WithFrom account from itself", func() error { return batchPay(agent.Address(), WithFrom(agent.Address())) }, true},
+		{"pay the client's account from WithFrom", func() error { return batchPay(c.Address(), WithFrom(agent.Address())) }, false},
+		{"transfer to self", func() error { _, err := c.Transfer(ctx, c.Address(), amount); return err }, false},
+		{"transfer to the zero address", func() error { _, err := c.Transfer(ctx, common.Address{}, amount); return err }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if tt.invalid && !errors.Is(err, ErrInvalidRecipient) {
+				t.Errorf("error = %v, want ErrInvalidRecipient", err)
+			}
+			if !tt.invalid && err != nil {
+				t.Errorf("error = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestMaxFeeIsEnforcedOrRejected(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "baseFee", big.NewInt(100))
+	ctx := context.Background()
+	recipient := testAddress(1)
+	amount := big.NewInt(1000)
+
+	batchPay := func(opts ...TxOption) error {
+		_, err := c.BatchPay(ctx, []BatchPayment{{recipient, amount}, {recipient, amount}}, opts...)
+		return err
+	}
+
+	tests := []struct {
+		name string
+		call func() error
+		want error
+	}{
+		{"batch total over max", func() error { return batchPay(WithMaxFee(big.NewInt(19))) }, ErrFeeExceedsMax},
+		{"batch total within max", func() error { return batchPay(WithMaxFee(big.NewInt(20))) }, nil},
+		{"escrow over bps", func() error {
+			_, err := c.CreateEscrow(ctx, recipient, testAddress(2), amount, 1, WithMaxFeeBps(50))
+			return err
+		}, ErrFeeExceedsMax},
+		{"stream over bps", func() error {
+			_, err := c.CreateStream(ctx, recipient, amount, 0, 60, WithMaxFeeBps(50))
+			return err
+		}, ErrFeeExceedsMax},
+		{"transfer", func() error {
+			_, err := c.Transfer(ctx, recipient, amount, WithMaxFee(big.NewInt(1)))
+			return err
+		}, ErrMaxFeeUnsupported},
+		{"release escrow", func() error {
+			_, err := c.ReleaseEscrow(ctx, [32]byte{1}, WithMaxFeeBps(50))
+			return err
+		}, ErrMaxFeeUnsupported},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent := len(backend.sentTxs())
+			err := tt.call()
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+			if tt.want != nil && len(backend.sentTxs()) != sent {
+				t.Error("a transaction was sent despite the error")
+			}
+		})
+	}
+}
+
+func TestSafeApproveResetsNonzeroAllowance(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowance int64
+		wantReset bool
+	}{
+		{"zero allowance", 0, false},
+		{"nonzero allowance", 50, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{SafeApprove: true})
+			backend.returns(testContracts.Token, tokenABI, "allowance", big.NewInt(tt.allowance))
+			ctx := context.Background()
+
+			reset, approve, err := c.Approve(ctx, testAddress(1), big.NewInt(100))
+			if err != nil {
+				t.Fatalf("Approve: %v", err)
+			}
+			if (reset != common.Hash{}) != tt.wantReset {
+				t.Errorf("reset hash = %s, want a reset: %v", reset.Hex(), tt.wantReset)
+			}
+
+			hashes, err := c.ApproveMany(ctx, []SpenderApproval{{Spender: testAddress(1), Amount: big.NewInt(100)}})
+			if err != nil {
+				t.Fatalf("ApproveMany: %v", err)
+			}
+
+			var want []common.Hash
+			for _, tx := range backend.sentTxs() {
+				want = append(want, tx.Hash())
+			}
+			got := append([]common.Hash{reset, approve}, hashes...)
+			if !tt.wantReset {
+				got = append(got[1:2], hashes...)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("returned hashes %v, want the sent transactions %v", got, want)
+			}
+		})
+	}
+
+	c := newTestClient(t, newMockBackend(), Config{SafeApprove: true})
+	if _, _, err := c.Approve(context.Background(), testAddress(1), nil); !errors.Is(err, ErrZeroAmount) {
+		t.Errorf("Approve(nil) error = %v, want ErrZeroAmount", err)
+	}
+}
+
+func TestExactApproval(t *testing.T) {
+	recipient := testAddress(1)
+	amount, buffer := big.NewInt(100), big.NewInt(50)
+
+	tests := []struct {
+		name      string
+		allowance int64
+		// want is the approved allowance, or zero for no approval
+		want int64
+	}{
+		{"no allowance", 0, 150},
+		{"allowance covers the amount but not the buffer", 120, 150},
+		{"allowance covers the buffer", 150, 0},
+		{"larger allowance", 200, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			backend.returns(testContracts.Token, tokenABI, "allowance", big.NewInt(tt.allowance))
+			backend.returns(testContracts.PaymentRouter, paymentRouterABI, "pay", [32]byte{})
+			backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+				if *tx.To() != testContracts.PaymentRouter {
+					return nil
+				}
+				return []*types.Log{eventLog(testContracts.PaymentRouter, paymentRouterABI, "PaymentExecuted",
+					[]common.Hash{{1}, common.BytesToHash(c.Address().Bytes()), common.BytesToHash(recipient.Bytes())},
+					amount, big.NewInt(0), [32]byte{},
+				)}
+			}
+
+			if _, err := c.Pay(context.Background(), recipient, amount, nil, WithExactApproval(buffer)); err != nil {
+				t.Fatalf("Pay: %v", err)
+			}
+
+			sent := backend.sentTxs()
+			var approvals [][]byte
+			for _, tx := range sent {
+				if *tx.To() == testContracts.Token {
+					approvals = append(approvals, tx.Data())
+				}
+			}
+			if tt.want == 0 {
+				if len(approvals) != 0 {
+					t.Errorf("approved %d times, want no approval", len(approvals))
+				}
+				return
+			}
+			want, _ := tokenABI.Pack("approve", testContracts.PaymentRouter, big.NewInt(tt.want))
+			if len(approvals) != 1 || !bytes.Equal(approvals[0], want) {
+				t.Errorf("approvals = %x, want %x", approvals, want)
+			}
+			if *sent[len(sent)-1].To() != testContracts.PaymentRouter {
+				t.Error("payment was not submitted after the approval")
+			}
+		})
+	}
+}
+
+func TestApproveExactSetsAllowance(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	spender := testAddress(1)
+	allowance := big.NewInt(50)
+	backend.handle(testContracts.Token, tokenABI, "allowance", func(common.Address, []interface{}) ([]interface{}, error) {
+		return []interface{}{allowance}, nil
+	})
+
+	hash, err := c.ApproveExact(context.Background(), spender, big.NewInt(100))
+	if err != nil {
+		t.Fatalf("ApproveExact: %v", err)
+	}
+	sent := backend.sentTxs()
+	want, _ := tokenABI.Pack("approve", spender, big.NewInt(100))
+	if len(sent) != 1 || sent[0].Hash() != hash || !bytes.Equal(sent[0].Data(), want) {
+		t.Fatalf("ApproveExact sent %d transactions, want one approving exactly 100", len(sent))
+	}
+
+	allowance = big.NewInt(100)
+	if hash, err := c.ApproveExact(context.Background(), spender, big.NewInt(100)); err != nil || hash != (common.Hash{}) {
+		t.Errorf("ApproveExact at the exact allowance = %s, %v, want no transaction", hash.Hex(), err)
+	}
+}
+
+func TestGetStakeRequirements(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	calls := 0
+	backend.handle(testContracts.Reputation, reputationABI, "getTierRequirements", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		calls++
+		tier := int64(args[0].(uint8))
+		return []interface{}{tierRequirementsData{
+			MinTransactions: new(big.Int),
+			MinSuccessRate:  new(big.Int),
+			MinStake:        big.NewInt(tier * 1000),
+			FeeDiscount:     new(big.Int),
+		}}, nil
+	})
+
+	requirements, err := c.GetStakeRequirements(context.Background())
+	if err != nil {
+		t.Fatalf("GetStakeRequirements: %v", err)
+	}
+	if len(requirements) != int(TierDiamond) {
+		t.Errorf("GetStakeRequirements returned %d tiers, want %d", len(requirements), TierDiamond)
+	}
+	for tier := TierBronze; tier <= TierDiamond; tier++ {
+		if stake := requirements[tier]; stake == nil || stake.Int64() != int64(tier)*1000 {
+			t.Errorf("requirements[%v] = %v, want %d", tier, stake, int64(tier)*1000)
+		}
+	}
+
+	// The requirements are cached, and the caller's copy is its own
+	requirements[TierBronze].SetInt64(0)
+	again, err := c.GetStakeRequirements(context.Background())
+	if err != nil {
+		t.Fatalf("GetStakeRequirements: %v", err)
+	}
+	if calls != int(TierDiamond) {
+		t.Errorf("getTierRequirements called %d times, want once per tier", calls)
+	}
+	if again[TierBronze].Int64() != 1000 {
+		t.Errorf("cached Bronze requirement = %s after the caller modified its copy", again[TierBronze])
+	}
+}
+
+func TestAcceptQuoteAndWait(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	quoteID, serviceID, price := [32]byte{7}, [32]byte{1}, big.NewInt(2500)
+	backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "acceptQuote")
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		return []*types.Log{eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "ServiceRequest",
+			[]common.Hash{serviceID, common.BytesToHash(c.Address().Bytes())},
+			price,
+		)}
+	}
+
+	result, err := c.AcceptQuoteAndWait(context.Background(), quoteID)
+	if err != nil {
+		t.Fatalf("AcceptQuoteAndWait: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(sent) != 1 || result.TxHash != sent[0].Hash() {
+		t.Fatalf("TxHash = %s, want the acceptQuote transaction", result.TxHash.Hex())
+	}
+	if result.PaymentID != quoteID || result.Amount.Cmp(price) != 0 || result.Fee.Sign() != 0 {
+		t.Errorf("result = payment %x amount %s fee %s, want payment %x amount %s and no fee",
+			result.PaymentID, result.Amount, result.Fee, quoteID, price)
+	}
+
+	backend.receiptLogs = nil
+	if _, err := c.AcceptQuoteAndWait(context.Background(), quoteID); err == nil {
+		t.Error("AcceptQuoteAndWait succeeded without a ServiceRequest event")
+	}
+}
+
+func TestInsufficientGasFunds(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	// The wallet holds SYNX but no native currency
+	backend.balances[c.Address()] = new(big.Int)
+	backend.returns(testContracts.Token, tokenABI, "balanceOf", big.NewInt(1e18))
+
+	_, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(1), WithGasLimit(50_000))
+	if !errors.Is(err, ErrInsufficientGasFunds) {
+		t.Fatalf("Transfer: err = %v, want ErrInsufficientGasFunds", err)
+	}
+	if len(backend.sentTxs()) != 0 {
+		t.Error("transaction sent without funds for gas")
+	}
+
+	// The required balance is gas limit × price plus value
+	backend.balances[c.Address()] = big.NewInt(1000)
+	err = c.CheckGasBalance(context.Background(), 50_000, big.NewInt(2e9), big.NewInt(500))
+	if !errors.Is(err, ErrInsufficientGasFunds) {
+		t.Fatalf("CheckGasBalance: err = %v, want ErrInsufficientGasFunds", err)
+	}
+	if want := "short 99999999999500 wei"; !strings.Contains(err.Error(), want) {
+		t.Errorf("err = %v, want it to name the shortfall (%s)", err, want)
+	}
+	if err := c.CheckGasBalance(context.Background(), 500, big.NewInt(1), big.NewInt(500)); err != nil {
+		t.Errorf("CheckGasBalance with enough balance: %v", err)
+	}
+}
+
+func TestCloneWithKey(t *testing.T) {
+	backend := newMockBackend()
+	backend.autoMine = false
+	c := newTestClient(t, backend, Config{})
+
+	var clones []*Client
+	for _, n := range []int{1, 2} {
+		clone, err := c.CloneWithKey(common.Bytes2Hex(crypto.FromECDSA(testKey(n))))
+		if err != nil {
+			t.Fatalf("CloneWithKey: %v", err)
+		}
+		if clone.Address() != testAddress(n) {
+			t.Errorf("clone address = %s, want %s", clone.Address().Hex(), testAddress(n).Hex())
+		}
+		if clone.ChainID().Cmp(c.ChainID()) != 0 {
+			t.Errorf("clone chain ID = %s, want %s", clone.ChainID(), c.ChainID())
+		}
+		clones = append(clones, clone)
+	}
+	if clones[0].Address() == clones[1].Address() {
+		t.Fatal("clones share an address")
+	}
+
+	// Both clones send through the shared backend, each from its own nonce
+	for _, clone := range clones {
+		if _, err := clone.Transfer(context.Background(), testAddress(3), big.NewInt(1)); err != nil {
+			t.Fatalf("Transfer: %v", err)
+		}
+	}
+	for i, tx := range backend.sentTxs() {
+		from, _ := types.Sender(types.LatestSignerForChainID(backend.chainID), tx)
+		if from != clones[i].Address() || tx.Nonce() != 0 {
+			t.Errorf("transaction %d from %s with nonce %d, want %s with nonce 0", i, from.Hex(), tx.Nonce(), clones[i].Address().Hex())
+		}
+	}
+
+	if _, err := c.CloneWithKey("not a key"); err == nil {
+		t.Error("CloneWithKey accepted an invalid key")
+	}
+}
+
+// pendingTransfersBackend answers pending-state calls of balanceOf with the
+// confirmed balance less the token transfers waiting in the pool
+type pendingTransfersBackend struct {
+	*mockBackend
+}
+
+func (b pendingTransfersBackend) PendingCallContract(ctx context.Context, msg ethereum.CallMsg) ([]byte, error) {
+	out, err := b.CallContract(ctx, msg, nil)
+	if err != nil || !bytes.HasPrefix(msg.Data, tokenABI.Methods["balanceOf"].ID) {
+		return out, err
+	}
+	args, _ := tokenABI.Methods["balanceOf"].Inputs.Unpack(msg.Data[4:])
+	balance := new(big.Int).SetBytes(out)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, tx := range b.pool {
+		from, _ := types.Sender(types.LatestSignerForChainID(b.chainID), tx)
+		if from == args[0].(common.Address) && bytes.HasPrefix(tx.Data(), tokenABI.Methods["transfer"].ID) {
+			transfer, _ := tokenABI.Methods["transfer"].Inputs.Unpack(tx.Data()[4:])
+			balance.Sub(balance, transfer[1].(*big.Int))
+		}
+	}
+	return tokenABI.Methods["balanceOf"].Outputs.Pack(balance)
+}
+
+func TestGetPendingBalance(t *testing.T) {
+	backend := newMockBackend()
+	backend.autoMine = false
+	backend.returns(testContracts.Token, tokenABI, "balanceOf", big.NewInt(1000))
+	c, err := NewClientWithBackend(pendingTransfersBackend{backend}, Config{Contracts: testContracts, Signer: NewLocalSigner(testKey(0))})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+
+	if _, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(300)); err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	confirmed, err := c.GetBalance(context.Background(), c.Address())
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	pending, err := c.GetPendingBalance(context.Background(), c.Address())
+	if err != nil {
+		t.Fatalf("GetPendingBalance: %v", err)
+	}
+	if confirmed.Int64() != 1000 || pending.Int64() != 700 {
+		t.Errorf("balances = %s confirmed, %s pending, want 1000 and 700", confirmed, pending)
+	}
+}
+
+func TestPayMetadataLimit(t *testing.T) {
+	tests := []struct {
+		name      string
+		limit     int
+		size      int
+		wantError bool
+	}{
+		{"default limit", 0, DefaultMaxMetadataBytes, false},
+		{"above default limit", 0, DefaultMaxMetadataBytes + 1, true},
+		{"configured limit", 16, 16, false},
+		{"above configured limit", 16, 17, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{MaxMetadataBytes: tt.limit})
+			backend.returns(testContracts.PaymentRouter, paymentRouterABI, "pay", [32]byte{})
+
+			_, err := c.Pay(context.Background(), testAddress(1), big.NewInt(1000), make([]byte, tt.size))
+			if got := errors.Is(err, ErrMetadataTooLarge); got != tt.wantError {
+				t.Fatalf("Pay with %d bytes of metadata: err = %v", tt.size, err)
+			}
+			if tt.wantError && !strings.Contains(err.Error(), fmt.Sprintf("%d bytes", tt.size)) {
+				t.Errorf("err = %v, want it to name the size", err)
+			}
+			if sent := len(backend.sentTxs()) > 0; sent == tt.wantError {
+				t.Errorf("payment sent = %v", sent)
+			}
+		})
+	}
+
+	payload := []byte(`{"prompt":"a long request stored off-chain"}`)
+	if HashMetadata(payload) != crypto.Keccak256Hash(payload) {
+		t.Error("HashMetadata is not the keccak256 of the payload")
+	}
+}
+
+func TestGetNetworkInfo(t *testing.T) {
+	tests := []struct {
+		name    string
+		baseFee *big.Int
+	}{
+		{"EIP-1559 chain", big.NewInt(7e9)},
+		{"legacy chain", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			backend.headers[0].BaseFee = tt.baseFee
+			c := newTestClient(t, backend, Config{})
+
+			info, err := c.GetNetworkInfo(context.Background())
+			if err != nil {
+				t.Fatalf("GetNetworkInfo: %v", err)
+			}
+			if info.ChainID.Cmp(backend.chainID) != 0 || info.GasPrice.Cmp(backend.gasPrice) != 0 {
+				t.Errorf("chain ID %s gas price %s, want %s and %s", info.ChainID, info.GasPrice, backend.chainID, backend.gasPrice)
+			}
+			if tt.baseFee == nil {
+				if info.BaseFee != nil || info.SuggestedTip != nil || info.PendingBlockGasUsedRatio != 0 {
+					t.Errorf("legacy chain reported fee fields %+v", info)
+				}
+				return
+			}
+			if info.BaseFee.Cmp(tt.baseFee) != 0 {
+				t.Errorf("BaseFee = %s, want %s", info.BaseFee, tt.baseFee)
+			}
+			if info.SuggestedTip == nil || info.SuggestedTip.Cmp(backend.tip) != 0 {
+				t.Errorf("SuggestedTip = %v, want %s", info.SuggestedTip, backend.tip)
+			}
+			if info.PendingBlockGasUsedRatio != 0.5 {
+				t.Errorf("PendingBlockGasUsedRatio = %v, want 0.5", info.PendingBlockGasUsedRatio)
+			}
+		})
+	}
+}
+
+func TestRequestQuoteMinValidity(t *testing.T) {
+	tests := []struct {
+		name        string
+		minValidity time.Duration
+		wantErr     error
+	}{
+		{"no minimum", 0, nil},
+		{"quote outlives the minimum", time.Minute, nil},
+		{"quote expires too soon", 5 * time.Minute, ErrQuoteValidityTooShort},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			service := testService(1, testAddress(1), "inference", "llm", 1000)
+			withServices(backend, service)
+			quoteID := [32]byte{7}
+			backend.returns(testContracts.ServiceRegistry, serviceRegistryABI, "requestQuote", quoteID)
+			backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+				return []*types.Log{eventLog(testContracts.ServiceRegistry, serviceRegistryABI, "QuoteCreated",
+					[]common.Hash{quoteID, service.ServiceId, common.BytesToHash(c.Address().Bytes())},
+					big.NewInt(1000),
+				)}
+			}
+			// The quote is valid for two minutes after the block requesting it
+			backend.handle(testContracts.ServiceRegistry, serviceRegistryABI, "getQuote", func(common.Address, []interface{}) ([]interface{}, error) {
+				return []interface{}{quoteData{
+					QuoteId:         quoteID,
+					ServiceId:       service.ServiceId,
+					Requester:       c.Address(),
+					EstimatedAmount: big.NewInt(1000),
+					ValidUntil:      new(big.Int).SetUint64(backend.head().Time + 120),
+				}}, nil
+			})
+
+			got, err := c.RequestQuote(context.Background(), service.ServiceId, 1, []byte("spec"), tt.minValidity)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RequestQuote error = %v, want %v", err, tt.wantErr)
+			}
+			if got != quoteID {
+				t.Errorf("RequestQuote = %x, want %x", got, quoteID)
+			}
+		})
+	}
+}
+
+func TestAppPaymentID(t *testing.T) {
+	backend := newMockBackend()
+	appA := newTestClient(t, backend, Config{AppNamespace: []byte("app-a")})
+	appB := newTestClient(t, backend, Config{AppNamespace: []byte("app-b")})
+	plain := newTestClient(t, backend, Config{})
+	paymentID := ComputePaymentID(testAddress(0), testAddress(1), big.NewInt(1000), 1_700_000_000, 7)
+
+	if appA.AppPaymentID(paymentID) == appB.AppPaymentID(paymentID) {
+		t.Error("the same payment has one ID in two namespaces")
+	}
+	if again := newTestClient(t, backend, Config{AppNamespace: []byte("app-a")}); again.AppPaymentID(paymentID) != appA.AppPaymentID(paymentID) {
+		t.Error("the same namespace derives different IDs")
+	}
+	if got := plain.AppPaymentID(paymentID); got != paymentID {
+		t.Errorf("AppPaymentID without a namespace = %x, want the payment ID %x", got, paymentID)
+	}
+}
+
+func TestWaitForBalance(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	wallet := testAddress(1)
+	// A faucet adds 400 SYNX between polls
+	polls := 0
+	backend.handle(testContracts.Token, tokenABI, "balanceOf", func(common.Address, []interface{}) ([]interface{}, error) {
+		polls++
+		return []interface{}{big.NewInt(int64(polls-1) * 400)}, nil
+	})
+
+	balance, err := c.WaitForBalance(context.Background(), wallet, big.NewInt(1000), 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("WaitForBalance: %v", err)
+	}
+	if balance.Int64() != 1200 || polls != 4 {
+		t.Errorf("WaitForBalance = %s after %d polls, want 1200 after 4", balance, polls)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	balance, err = c.WaitForBalance(ctx, wallet, big.NewInt(1e18), 10*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) || balance == nil {
+		t.Errorf("WaitForBalance for an unreachable minimum = %v, %v, want the last balance and a timeout", balance, err)
+	}
+}
+
+func TestPaySubmitsToRouter(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	recipient := testAddress(1)
+	amount, fee := big.NewInt(5000), big.NewInt(5)
+	paymentID := [32]byte{0xab}
+
+	backend.returns(testContracts.PaymentRouter, paymentRouterABI, "pay", paymentID)
+	backend.receiptLogs = func(tx *types.Transaction, header *types.Header) []*types.Log {
+		executed := func(address common.Address, id [32]byte) *types.Log {
+			return eventLog(address, paymentRouterABI, "PaymentExecuted",
+				[]common.Hash{id, common.BytesToHash(c.Address().Bytes()), common.BytesToHash(recipient.Bytes())},
+				amount, fee, [32]byte{},
+			)
+		}
+		// The same event from another contract is not the router's
+		return []*types.Log{executed(testAddress(9), [32]byte{0xee}), executed(testContracts.PaymentRouter, paymentID)}
+	}
+
+	result, err := c.Pay(context.Background(), recipient, amount, []byte("order-17"))
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	sent := backend.sentTxs()
+	if len(sent) != 1 || result.TxHash != sent[0].Hash() {
+		t.Fatalf("TxHash = %s, want the submitted transaction", result.TxHash.Hex())
+	}
+	if to := sent[0].To(); to == nil || *to != testContracts.PaymentRouter {
+		t.Errorf("payment sent to %v, want the PaymentRouter", to)
+	}
+	args, err := paymentRouterABI.Methods["pay"].Inputs.Unpack(sent[0].Data()[4:])
+	if err != nil {
+		t.Fatalf("unpack pay: %v", err)
+	}
+	if args[0] != recipient || args[1].(*big.Int).Cmp(amount) != 0 || args[3] != "order-17" {
+		t.Errorf("pay(%v, %v, _, %v), want the payment's recipient, amount and metadata", args[0], args[1], args[3])
+	}
+	if result.PaymentID != paymentID || result.Amount.Cmp(amount) != 0 || result.Fee.Cmp(fee) != 0 {
+		t.Errorf("result = payment %x amount %s fee %s, want %x, %s and %s", result.PaymentID, result.Amount, result.Fee, paymentID, amount, fee)
+	}
+
+	backend.receiptLogs = nil
+	if _, err := c.Pay(context.Background(), recipient, amount, nil); err == nil {
+		t.Error("Pay succeeded without a PaymentExecuted event")
+	}
+}
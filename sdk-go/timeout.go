@@ -0,0 +1,48 @@
+package synapse
+
+import (
+	"context"
+	"time"
+)
+
+// timeoutCategory selects which configured timeout applies to an operation
+type timeoutCategory uint8
+
+const (
+	timeoutRead timeoutCategory = iota
+	timeoutWrite
+	timeoutWait
+)
+
+// timeoutFor returns the configured timeout for a category, falling back to
+// Config.DefaultTimeout
+func (c *Client) timeoutFor(category timeoutCategory) time.Duration {
+	var timeout time.Duration
+	switch category {
+	case timeoutRead:
+		timeout = c.config.ReadTimeout
+	case timeoutWrite:
+		timeout = c.config.WriteTimeout
+	case timeoutWait:
+		timeout = c.config.WaitTimeout
+	}
+
+	if timeout == 0 {
+		timeout = c.config.DefaultTimeout
+	}
+	return timeout
+}
+
+// withTimeout bounds ctx by the category's timeout. A deadline already set by
+// the caller always wins.
+func (c *Client) withTimeout(ctx context.Context, category timeoutCategory) (context.Context, context.CancelFunc) {
+	if _, ok := ctx.Deadline(); ok {
+		return ctx, func() {}
+	}
+
+	timeout := c.timeoutFor(category)
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,117 @@
+package synapse
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// deadlineBackend records the time left before the context deadline of the
+// last call of each method, or zero for a context without one
+type deadlineBackend struct {
+	*mockBackend
+
+	mu   sync.Mutex
+	left map[string]time.Duration
+}
+
+func (b *deadlineBackend) record(ctx context.Context, method string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.left[method] = 0
+	if deadline, ok := ctx.Deadline(); ok {
+		b.left[method] = time.Until(deadline)
+	}
+}
+
+func (b *deadlineBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	b.record(ctx, "CallContract")
+	return b.mockBackend.CallContract(ctx, msg, block)
+}
+
+func (b *deadlineBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	b.record(ctx, "SendTransaction")
+	return b.mockBackend.SendTransaction(ctx, tx)
+}
+
+func (b *deadlineBackend) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	b.record(ctx, "TransactionReceipt")
+	return b.mockBackend.TransactionReceipt(ctx, hash)
+}
+
+func TestDefaultTimeouts(t *testing.T) {
+	categories := Config{ReadTimeout: time.Hour, WriteTimeout: 2 * time.Hour, WaitTimeout: 3 * time.Hour, DefaultTimeout: 4 * time.Hour}
+
+	read := func(ctx context.Context, c *Client) error {
+		_, err := c.GetBalance(ctx, c.Address())
+		return err
+	}
+	write := func(ctx context.Context, c *Client) error {
+		_, err := c.Transfer(ctx, testAddress(1), big.NewInt(1))
+		return err
+	}
+	var mined common.Hash
+	wait := func(ctx context.Context, c *Client) error {
+		_, err := c.WaitForTransaction(ctx, mined)
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		config Config
+		op     func(context.Context, *Client) error
+		method string
+		ctx    time.Duration
+		want   time.Duration
+	}{
+		{"read", categories, read, "CallContract", 0, time.Hour},
+		{"write", categories, write, "SendTransaction", 0, 2 * time.Hour},
+		{"wait", categories, wait, "TransactionReceipt", 0, 3 * time.Hour},
+		{"read falls back to the default", Config{DefaultTimeout: 4 * time.Hour}, read, "CallContract", 0, 4 * time.Hour},
+		{"wait falls back to the default", Config{DefaultTimeout: 4 * time.Hour}, wait, "TransactionReceipt", 0, 4 * time.Hour},
+		{"caller deadline wins", categories, write, "SendTransaction", 5 * time.Hour, 5 * time.Hour},
+		{"no timeouts", Config{}, read, "CallContract", 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &deadlineBackend{mockBackend: newMockBackend(), left: make(map[string]time.Duration)}
+			backend.returns(testContracts.Token, tokenABI, "balanceOf", big.NewInt(1))
+			tt.config.Signer = NewLocalSigner(testKey(0))
+			tt.config.Contracts = testContracts
+			c, err := NewClientWithBackend(backend, tt.config)
+			if err != nil {
+				t.Fatalf("NewClientWithBackend: %v", err)
+			}
+			if mined, err = c.Transfer(context.Background(), testAddress(1), big.NewInt(1)); err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+
+			ctx := context.Background()
+			if tt.ctx > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.ctx)
+				defer cancel()
+			}
+			if err := tt.op(ctx, c); err != nil {
+				t.Fatalf("operation failed: %v", err)
+			}
+
+			backend.mu.Lock()
+			left, called := backend.left[tt.method]
+			backend.mu.Unlock()
+			if !called {
+				t.Fatalf("operation made no %s call", tt.method)
+			}
+			if left > tt.want || left < tt.want-time.Minute {
+				t.Errorf("%s deadline in %s, want %s", tt.method, left, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TokenInfo describes the SYNX token
+type TokenInfo struct {
+	Name        string
+	Symbol      string
+	Decimals    uint8
+	TotalSupply *big.Int
+	// CirculatingSupply is nil if the token does not expose circulatingSupply
+	CirculatingSupply *big.Int
+}
+
+// GetTokenInfo returns the token's metadata and supply. Name, symbol and
+// decimals are read once and cached.
+func (c *Client) GetTokenInfo(ctx context.Context) (*TokenInfo, error) {
+	metadata, err := c.getTokenMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := c.callContract(ctx, ContractToken, "totalSupply")
+	if err != nil {
+		return nil, err
+	}
+
+	info := *metadata
+	info.TotalSupply = out[0].(*big.Int)
+
+	// circulatingSupply is a SYNX extension to ERC-20, so a token without it
+	// reverts without data
+	out, err = c.callContract(ctx, ContractToken, "circulatingSupply")
+	switch {
+	case err == nil:
+		info.CirculatingSupply = out[0].(*big.Int)
+	case !isMissingMethod(err):
+		return nil, err
+	}
+
+	return &info, nil
+}
+
+// getTokenMetadata returns the cached immutable token fields, reading them on
+// first use. The lock is not held while reading, so concurrent first calls
+// may each read them.
+func (c *Client) getTokenMetadata(ctx context.Context) (*TokenInfo, error) {
+	c.cacheMu.Lock()
+	cached := c.tokenMetadata
+	c.cacheMu.Unlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	metadata := &TokenInfo{}
+
+	out, err := c.callContract(ctx, ContractToken, "name")
+	if err != nil {
+		return nil, err
+	}
+	metadata.Name = out[0].(string)
+
+	out, err = c.callContract(ctx, ContractToken, "symbol")
+	if err != nil {
+		return nil, err
+	}
+	metadata.Symbol = out[0].(string)
+
+	out, err = c.callContract(ctx, ContractToken, "decimals")
+	if err != nil {
+		return nil, err
+	}
+	metadata.Decimals = out[0].(uint8)
+
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if c.tokenMetadata == nil {
+		c.tokenMetadata = metadata
+	}
+
+	return c.tokenMetadata, nil
+}
+
+// GetTokenDecimals returns the token's decimals. The value is read once and
+// cached.
+func (c *Client) GetTokenDecimals(ctx context.Context) (uint8, error) {
+	metadata, err := c.getTokenMetadata(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return metadata.Decimals, nil
+}
+
+// ParseSYNXCtx parses a SYNX amount string to base units using the token's
+// on-chain decimals
+func (c *Client) ParseSYNXCtx(ctx context.Context, amount string) (*big.Int, error) {
+	decimals, err := c.GetTokenDecimals(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseUnits(amount, decimals)
+}
+
+// FormatSYNXCtx formats base units as a SYNX string using the token's
+// on-chain decimals
+func (c *Client) FormatSYNXCtx(ctx context.Context, amount *big.Int) (string, error) {
+	decimals, err := c.GetTokenDecimals(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	return formatUnits(amount, decimals), nil
+}
+
+// GetBalances returns holder's balance of each ERC-20 token, keyed by token,
+// with the balanceOf calls batched through Multicall3 when
+// Contracts.Multicall is configured. Tokens whose balance cannot be read,
+// such as addresses that are not ERC-20 contracts, are left out of the map
+// and reported together in the error.
+func (c *Client) GetBalances(ctx context.Context, tokens []common.Address, holder common.Address) (map[common.Address]*big.Int, error) {
+	tokenABI := c.contractABI(ContractToken)
+	data, err := tokenABI.Pack("balanceOf", holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack balanceOf: %w", err)
+	}
+
+	calls := make([]multicallCall, len(tokens))
+	for i, token := range tokens {
+		calls[i] = multicallCall{Target: token, AllowFailure: true, CallData: data}
+	}
+
+	results, err := c.multicall(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make(map[common.Address]*big.Int, len(tokens))
+	var errs []error
+	for i, result := range results {
+		if !result.Success {
+			errs = append(errs, fmt.Errorf("token %s: balanceOf failed", tokens[i].Hex()))
+			continue
+		}
+
+		out, err := tokenABI.Unpack("balanceOf", result.ReturnData)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("token %s: failed to unpack balanceOf: %w", tokens[i].Hex(), err))
+			continue
+		}
+		balances[tokens[i]] = out[0].(*big.Int)
+	}
+
+	return balances, errors.Join(errs...)
+}
@@ -0,0 +1,275 @@
+package synapse
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestGetTokenInfoCirculatingSupply(t *testing.T) {
+	connectionReset := errors.New("connection reset")
+
+	tests := []struct {
+		name    string
+		handler callHandler
+		want    *big.Int
+		wantErr error
+	}{
+		{"supported", func(common.Address, []interface{}) ([]interface{}, error) {
+			return []interface{}{big.NewInt(600)}, nil
+		}, big.NewInt(600), nil},
+		{"not implemented", nil, nil, nil},
+		{"custom revert", func(common.Address, []interface{}) ([]interface{}, error) {
+			return nil, revertWith(tokenABI, "AddressBlocked")
+		}, nil, &RevertError{}},
+		{"RPC failure", func(common.Address, []interface{}) ([]interface{}, error) {
+			return nil, connectionReset
+		}, nil, connectionReset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			backend.returns(testContracts.Token, tokenABI, "name", "Synapse")
+			backend.returns(testContracts.Token, tokenABI, "symbol", "SYNX")
+			backend.returns(testContracts.Token, tokenABI, "decimals", uint8(18))
+			backend.returns(testContracts.Token, tokenABI, "totalSupply", big.NewInt(1000))
+			if tt.handler != nil {
+				backend.handle(testContracts.Token, tokenABI, "circulatingSupply", tt.handler)
+			}
+
+			info, err := c.GetTokenInfo(context.Background())
+			var revert *RevertError
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("GetTokenInfo: %v", err)
+			case errors.As(tt.wantErr, &revert):
+				if !errors.As(err, &revert) {
+					t.Fatalf("error = %v, want a RevertError", err)
+				}
+				return
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			if (info.CirculatingSupply == nil) != (tt.want == nil) || (tt.want != nil && info.CirculatingSupply.Cmp(tt.want) != 0) {
+				t.Errorf("CirculatingSupply = %v, want %v", info.CirculatingSupply, tt.want)
+			}
+			if info.Symbol != "SYNX" || info.TotalSupply.Int64() != 1000 {
+				t.Errorf("GetTokenInfo = %+v", info)
+			}
+		})
+	}
+}
+
+func TestParseAndFormatUnits(t *testing.T) {
+	large, _ := new(big.Int).SetString("123456789123456789123456789", 10)
+
+	tests := []struct {
+		amount   string
+		decimals uint8
+		want     *big.Int
+		// formatted is the formatting of want, if it differs from amount
+		formatted string
+	}{
+		{"123456789.123456789123456789", 18, large, ""},
+		{"1.5", 18, big.NewInt(15e17), ""},
+		{"-0.25", 18, big.NewInt(-25e16), ""},
+		{"100", 18, new(big.Int).Mul(big.NewInt(100), big.NewInt(1e18)), ""},
+		{"0.000000000000000001", 18, big.NewInt(1), ""},
+		{".5", 6, big.NewInt(500000), "0.5"},
+		{"1.500000", 6, big.NewInt(1500000), "1.5"},
+		{"42", 0, big.NewInt(42), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			got, err := parseUnits(tt.amount, tt.decimals)
+			if err != nil {
+				t.Fatalf("parseUnits: %v", err)
+			}
+			if got.Cmp(tt.want) != 0 {
+				t.Errorf("parseUnits = %s, want %s", got, tt.want)
+			}
+
+			want := tt.amount
+			if tt.formatted != "" {
+				want = tt.formatted
+			}
+			if formatted := formatUnits(got, tt.decimals); formatted != want {
+				t.Errorf("formatUnits = %s, want %s", formatted, want)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"", ".", "-", "1e18", "0x10", "1,5", " 1", "1.2.3", "--1", "+1", "0.0000001"} {
+		if _, err := parseUnits(invalid, 6); err == nil {
+			t.Errorf("parseUnits(%q, 6) succeeded", invalid)
+		}
+	}
+}
+
+func TestSYNXCtxUsesTokenDecimals(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	backend.returns(testContracts.Token, tokenABI, "name", "USD Coin")
+	backend.returns(testContracts.Token, tokenABI, "symbol", "USDC")
+	backend.returns(testContracts.Token, tokenABI, "decimals", uint8(6))
+	ctx := context.Background()
+
+	amount, err := c.ParseSYNXCtx(ctx, "1234.567891")
+	if err != nil {
+		t.Fatalf("ParseSYNXCtx: %v", err)
+	}
+	if amount.Int64() != 1234567891 {
+		t.Errorf("ParseSYNXCtx = %s, want 1234567891", amount)
+	}
+	if _, err := c.ParseSYNXCtx(ctx, "0.0000001"); err == nil {
+		t.Error("ParseSYNXCtx accepted more digits than the token's 6 decimals")
+	}
+
+	formatted, err := c.FormatSYNXCtx(ctx, big.NewInt(1500001))
+	if err != nil {
+		t.Fatalf("FormatSYNXCtx: %v", err)
+	}
+	if formatted != "1.500001" {
+		t.Errorf("FormatSYNXCtx = %s, want 1.500001", formatted)
+	}
+}
+
+// withApprovals makes the token mock report the allowances set by the
+// approve transactions sent so far
+func withApprovals(backend *mockBackend) {
+	backend.returns(testContracts.Token, tokenABI, "approve", true)
+	backend.handle(testContracts.Token, tokenABI, "allowance", func(_ common.Address, args []interface{}) ([]interface{}, error) {
+		allowance := new(big.Int)
+		approve := tokenABI.Methods["approve"]
+		for _, tx := range backend.sentTxs() {
+			from, _ := types.Sender(types.LatestSignerForChainID(backend.chainID), tx)
+			if from != args[0].(common.Address) || !bytes.HasPrefix(tx.Data(), approve.ID) {
+				continue
+			}
+			approval, err := approve.Inputs.Unpack(tx.Data()[4:])
+			if err != nil {
+				return nil, err
+			}
+			if approval[0].(common.Address) == args[1].(common.Address) {
+				allowance = approval[1].(*big.Int)
+			}
+		}
+		return []interface{}{allowance}, nil
+	})
+}
+
+func TestApproveMany(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	withApprovals(backend)
+	approvals := []SpenderApproval{
+		{testContracts.PaymentRouter, big.NewInt(1000)},
+		{testContracts.PaymentChannel, big.NewInt(250)},
+		{testAddress(1), big.NewInt(1)},
+	}
+
+	hashes, err := c.ApproveMany(context.Background(), approvals)
+	if err != nil {
+		t.Fatalf("ApproveMany: %v", err)
+	}
+	if len(hashes) != len(approvals) {
+		t.Fatalf("ApproveMany returned %d hashes, want %d", len(hashes), len(approvals))
+	}
+	for _, approval := range approvals {
+		allowance, err := c.GetAllowance(context.Background(), c.Address(), approval.Spender)
+		if err != nil {
+			t.Fatalf("GetAllowance: %v", err)
+		}
+		if allowance.Cmp(approval.Amount) != 0 {
+			t.Errorf("allowance of %s = %s, want %s", approval.Spender.Hex(), allowance, approval.Amount)
+		}
+	}
+	if allowance, _ := c.GetAllowance(context.Background(), c.Address(), testContracts.Reputation); allowance.Sign() != 0 {
+		t.Errorf("unlisted spender has allowance %s", allowance)
+	}
+}
+
+func TestApproveAllContinuesPastFailure(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{})
+	withApprovals(backend)
+	approve := tokenABI.Methods["approve"]
+	backend.estimateGas = func(msg ethereum.CallMsg) (uint64, error) {
+		if bytes.HasPrefix(msg.Data, approve.ID) {
+			args, _ := approve.Inputs.Unpack(msg.Data[4:])
+			if args[0].(common.Address) == testContracts.Reputation {
+				return 0, errors.New("execution reverted")
+			}
+		}
+		return 60000, nil
+	}
+
+	results, err := c.ApproveAll(context.Background())
+	if err == nil || !strings.Contains(err.Error(), testContracts.Reputation.Hex()) {
+		t.Fatalf("ApproveAll error = %v, want the Reputation approval's failure", err)
+	}
+
+	want := []common.Address{testContracts.PaymentRouter, testContracts.Reputation, testContracts.ServiceRegistry, testContracts.PaymentChannel}
+	if len(results) != len(want) {
+		t.Fatalf("ApproveAll returned %d results, want %d", len(results), len(want))
+	}
+	for i, result := range results {
+		if result.Contract != want[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.Contract.Hex(), want[i].Hex())
+		}
+		failed := result.Contract == testContracts.Reputation
+		if (result.Err != nil) != failed || (result.Hash == common.Hash{}) != failed {
+			t.Errorf("result for %s = hash %s err %v", result.Contract.Hex(), result.Hash.Hex(), result.Err)
+		}
+	}
+	if n := len(backend.sentTxs()); n != 3 {
+		t.Errorf("sent %d approvals, want 3", n)
+	}
+}
+
+func TestGetBalances(t *testing.T) {
+	backend := &callCountingBackend{mockBackend: newMockBackend(), calls: make(map[common.Address]int)}
+	contracts := testContracts
+	contracts.Multicall = testAddress(9)
+	c, err := NewClientWithBackend(backend, Config{Contracts: contracts})
+	if err != nil {
+		t.Fatalf("NewClientWithBackend: %v", err)
+	}
+	aggregates := withMulticall(backend.mockBackend, contracts.Multicall)
+	other, notAToken := testAddress(7), testAddress(8)
+	holder := testAddress(1)
+	backend.returns(testContracts.Token, tokenABI, "balanceOf", big.NewInt(1000))
+	backend.returns(other, tokenABI, "balanceOf", big.NewInt(25))
+
+	balances, err := c.GetBalances(context.Background(), []common.Address{testContracts.Token, other}, holder)
+	if err != nil {
+		t.Fatalf("GetBalances: %v", err)
+	}
+	if len(balances) != 2 || balances[testContracts.Token].Int64() != 1000 || balances[other].Int64() != 25 {
+		t.Errorf("balances = %v, want 1000 SYNX and 25 of the other token", balances)
+	}
+	if *aggregates != 1 || len(backend.calls) != 1 {
+		t.Errorf("made %d aggregate3 calls and direct calls to %d contracts, want one aggregate3 only", *aggregates, len(backend.calls))
+	}
+
+	// An address without balanceOf is reported without losing the others
+	balances, err = c.GetBalances(context.Background(), []common.Address{testContracts.Token, notAToken}, holder)
+	if err == nil || !strings.Contains(err.Error(), notAToken.Hex()) {
+		t.Errorf("GetBalances error = %v, want one naming %s", err, notAToken.Hex())
+	}
+	if _, ok := balances[notAToken]; ok || balances[testContracts.Token].Int64() != 1000 {
+		t.Errorf("balances = %v, want only the SYNX balance", balances)
+	}
+}
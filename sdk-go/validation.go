@@ -0,0 +1,112 @@
+package synapse
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ValidationError reports every invalid parameter of a call at once. The
+// individual problems wrap sentinels such as ErrZeroAmount, so errors.Is
+// matches any of them.
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		messages[i] = problem.Error()
+	}
+	return "invalid parameters: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the individual problems
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// validator collects the problems found in a call's parameters
+type validator struct {
+	problems []error
+}
+
+// check records err if it is non-nil
+func (v *validator) check(err error) {
+	if err != nil {
+		v.problems = append(v.problems, err)
+	}
+}
+
+// err returns a *ValidationError holding the recorded problems, or nil
+func (v *validator) err() error {
+	if len(v.problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Problems: v.problems}
+}
+
+// requireRecipient returns ErrInvalidRecipient for the zero address or the
+// sending account, both of which the contracts reject
+func requireRecipient(name string, recipient, sender common.Address) error {
+	if err := requireAddress(name, recipient); err != nil {
+		return err
+	}
+	if recipient == sender {
+		return fmt.Errorf("%w: %s is the sender %s", ErrInvalidRecipient, name, sender.Hex())
+	}
+	return nil
+}
+
+// requireAddress returns ErrInvalidRecipient for the zero address
+func requireAddress(name string, address common.Address) error {
+	if address == (common.Address{}) {
+		return fmt.Errorf("%w: %s is the zero address", ErrInvalidRecipient, name)
+	}
+	return nil
+}
+
+// requireOptionalAmount is requireAmount for amounts where nil or zero is
+// allowed
+func requireOptionalAmount(name string, amount *big.Int) error {
+	if amount != nil && amount.Sign() < 0 {
+		return fmt.Errorf("%w: %s is %v", ErrZeroAmount, name, amount)
+	}
+	return nil
+}
+
+// requireAllowance returns ErrZeroAmount if an allowance is nil or
+// negative. Zero is allowed and revokes.
+func requireAllowance(name string, amount *big.Int) error {
+	if amount == nil || amount.Sign() < 0 {
+		return fmt.Errorf("%w: %s is %v", ErrZeroAmount, name, amount)
+	}
+	return nil
+}
+
+// validateEndpoint returns ErrInvalidEndpoint unless endpoint is an absolute
+// https or wss URL, or http or ws with Config.AllowInsecureEndpoints
+func (c *Client) validateEndpoint(endpoint string) error {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %v", ErrInvalidEndpoint, endpoint, err)
+	}
+	if parsed.Host == "" {
+		return fmt.Errorf("%w: %q has no scheme or host", ErrInvalidEndpoint, endpoint)
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "https", "wss":
+		return nil
+	case "http", "ws":
+		if c.config.AllowInsecureEndpoints {
+			return nil
+		}
+		return fmt.Errorf("%w: %q is not encrypted; set AllowInsecureEndpoints to allow it", ErrInvalidEndpoint, endpoint)
+	default:
+		return fmt.Errorf("%w: %q has unsupported scheme %q", ErrInvalidEndpoint, endpoint, parsed.Scheme)
+	}
+}
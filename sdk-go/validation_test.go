@@ -0,0 +1,100 @@
+package synapse
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestZeroAmounts(t *testing.T) {
+	ctx := context.Background()
+	recipient := testAddress(1)
+	zero := new(big.Int)
+
+	tests := []struct {
+		name string
+		call func(c *Client) error
+		// allowed zeros pass validation and are submitted
+		allowed bool
+	}{
+		{"Transfer", func(c *Client) error { _, err := c.Transfer(ctx, recipient, zero); return err }, false},
+		{"Pay", func(c *Client) error { _, err := c.Pay(ctx, recipient, zero, nil); return err }, false},
+		{"Pay nil", func(c *Client) error { _, err := c.Pay(ctx, recipient, nil, nil); return err }, false},
+		{"BatchPay", func(c *Client) error {
+			_, err := c.BatchPay(ctx, []BatchPayment{{recipient, big.NewInt(1)}, {testAddress(2), zero}})
+			return err
+		}, false},
+		{"CreateEscrow", func(c *Client) error {
+			_, err := c.CreateEscrow(ctx, recipient, testAddress(2), zero, 1_800_000_000)
+			return err
+		}, false},
+		{"CreateStream", func(c *Client) error {
+			_, err := c.CreateStream(ctx, recipient, zero, 1_800_000_000, 1_800_086_400)
+			return err
+		}, false},
+		{"RegisterAgent", func(c *Client) error {
+			_, err := c.RegisterAgent(ctx, RegisterAgentParams{MetadataURI: "ipfs://agent", Stake: zero})
+			return err
+		}, false},
+		{"IncreaseStake", func(c *Client) error { _, err := c.IncreaseStake(ctx, zero); return err }, false},
+		{"RegisterService", func(c *Client) error {
+			_, err := c.RegisterService(ctx, RegisterServiceParams{Name: "llm", Category: "inference", Endpoint: "https://llm.example", BasePrice: zero})
+			return err
+		}, false},
+		{"OpenChannel both deposits", func(c *Client) error { _, err := c.OpenChannel(ctx, recipient, zero, nil); return err }, false},
+		{"OpenChannel one-sided", func(c *Client) error { _, err := c.OpenChannel(ctx, recipient, big.NewInt(100), zero); return err }, true},
+		{"Approve revokes", func(c *Client) error { _, _, err := c.Approve(ctx, recipient, zero); return err }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newMockBackend()
+			c := newTestClient(t, backend, Config{})
+			withApprovals(backend)
+
+			err := tt.call(c)
+			sent := len(backend.sentTxs())
+			if tt.allowed {
+				if errors.Is(err, ErrZeroAmount) || sent == 0 {
+					t.Errorf("err = %v with %d transactions sent, want the zero accepted", err, sent)
+				}
+				return
+			}
+			if !errors.Is(err, ErrZeroAmount) {
+				t.Errorf("err = %v, want ErrZeroAmount", err)
+			}
+			if sent != 0 {
+				t.Errorf("sent %d transactions for a zero amount", sent)
+			}
+		})
+	}
+}
+
+func TestValidationErrorReportsEveryProblem(t *testing.T) {
+	backend := newMockBackend()
+	c := newTestClient(t, backend, Config{MaxMetadataBytes: 4})
+
+	_, err := c.Pay(context.Background(), common.Address{}, nil, []byte("too long"))
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("Pay error = %v, want a *ValidationError", err)
+	}
+	if len(validation.Problems) != 3 {
+		t.Errorf("reported %d problems, want 3: %v", len(validation.Problems), err)
+	}
+	for _, want := range []error{ErrInvalidRecipient, ErrZeroAmount, ErrMetadataTooLarge} {
+		if !errors.Is(err, want) {
+			t.Errorf("Pay error = %v, want it to wrap %v", err, want)
+		}
+	}
+	if n := len(backend.sentTxs()); n != 0 {
+		t.Errorf("sent %d transactions for invalid parameters", n)
+	}
+
+	// A valid call reports no ValidationError
+	if _, err := c.Pay(context.Background(), testAddress(1), big.NewInt(1), nil); errors.As(err, &validation) {
+		t.Errorf("Pay with valid parameters: err = %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package synapse
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Web3Signer signs through the eth1 JSON-RPC API of Consensys Web3Signer:
+// transactions with eth_signTransaction, and personal messages, which include
+// channel states, profiles, ownership proofs and meta-transactions, with
+// eth_sign.
+//
+// The JSON-RPC API has no method for raw digests, so SignHash, and with it
+// SignQuoteAcceptance's EIP-712 signature, returns accounts.ErrNotSupported.
+type Web3Signer struct {
+	client  *rpc.Client
+	address common.Address
+}
+
+// NewWeb3Signer connects to the signer at url and signs for address, which
+// must be one of the signer's accounts
+func NewWeb3Signer(ctx context.Context, url string, address common.Address) (*Web3Signer, error) {
+	client, err := rpc.DialContext(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to signer: %w", err)
+	}
+
+	var addresses []common.Address
+	if err := client.CallContext(ctx, &addresses, "eth_accounts"); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to list signer accounts: %w", err)
+	}
+	for _, a := range addresses {
+		if a == address {
+			return &Web3Signer{client: client, address: address}, nil
+		}
+	}
+
+	client.Close()
+	return nil, fmt.Errorf("signer has no account %s", address.Hex())
+}
+
+// Address implements Signer
+func (s *Web3Signer) Address() common.Address {
+	return s.address
+}
+
+// SignHash implements Signer. Remote signers do not sign raw digests, so it
+// always fails.
+func (s *Web3Signer) SignHash(ctx context.Context, hash []byte) ([]byte, error) {
+	return nil, fmt.Errorf("remote signers only sign transactions and personal messages: %w", accounts.ErrNotSupported)
+}
+
+// SignMessage implements MessageSigner with eth_sign, which adds the EIP-191
+// prefix to message
+func (s *Web3Signer) SignMessage(ctx context.Context, message []byte) ([]byte, error) {
+	var signature hexutil.Bytes
+	if err := s.client.CallContext(ctx, &signature, "eth_sign", s.address, hexutil.Bytes(message)); err != nil {
+		return nil, fmt.Errorf("eth_sign failed: %w", err)
+	}
+	if len(signature) != crypto.SignatureLength {
+		return nil, fmt.Errorf("eth_sign returned a %d-byte signature", len(signature))
+	}
+
+	// eth_sign returns the 27/28 recovery ID; Signer uses 0/1
+	if signature[crypto.RecoveryIDOffset] >= 27 {
+		signature[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(accounts.TextHash(message), signature)
+	if err != nil || crypto.PubkeyToAddress(*pub) != s.address {
+		return nil, fmt.Errorf("eth_sign signature does not recover to %s", s.address.Hex())
+	}
+
+	return signature, nil
+}
+
+// SignTx implements TxSigner with eth_signTransaction
+func (s *Web3Signer) SignTx(ctx context.Context, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	args := map[string]interface{}{
+		"from":    s.address,
+		"to":      tx.To(),
+		"gas":     hexutil.Uint64(tx.Gas()),
+		"value":   (*hexutil.Big)(tx.Value()),
+		"data":    hexutil.Bytes(tx.Data()),
+		"nonce":   hexutil.Uint64(tx.Nonce()),
+		"chainId": (*hexutil.Big)(chainID),
+	}
+	switch tx.Type() {
+	case types.LegacyTxType, types.AccessListTxType:
+		args["gasPrice"] = (*hexutil.Big)(tx.GasPrice())
+	case types.DynamicFeeTxType:
+		args["maxFeePerGas"] = (*hexutil.Big)(tx.GasFeeCap())
+		args["maxPriorityFeePerGas"] = (*hexutil.Big)(tx.GasTipCap())
+	default:
+		return nil, fmt.Errorf("unsupported transaction type %d", tx.Type())
+	}
+	if len(tx.AccessList()) > 0 {
+		args["accessList"] = tx.AccessList()
+	}
+
+	var raw hexutil.Bytes
+	if err := s.client.CallContext(ctx, &raw, "eth_signTransaction", args); err != nil {
+		return nil, fmt.Errorf("eth_signTransaction failed: %w", err)
+	}
+
+	signed := new(types.Transaction)
+	if err := signed.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("failed to decode signed transaction: %w", err)
+	}
+	txHasher := types.LatestSignerForChainID(chainID)
+	if txHasher.Hash(signed) != txHasher.Hash(tx) {
+		return nil, fmt.Errorf("signer changed the transaction")
+	}
+	if from, err := types.Sender(txHasher, signed); err != nil || from != s.address {
+		return nil, fmt.Errorf("signed transaction is not from %s", s.address.Hex())
+	}
+
+	return signed, nil
+}
+
+// Close disconnects from the signer
+func (s *Web3Signer) Close() {
+	s.client.Close()
+}
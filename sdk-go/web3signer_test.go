@@ -0,0 +1,134 @@
+package synapse
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// fakeWeb3Signer serves the eth namespace of Web3Signer for one key
+type fakeWeb3Signer struct {
+	key *ecdsa.PrivateKey
+}
+
+func (s *fakeWeb3Signer) Accounts() []common.Address {
+	return []common.Address{crypto.PubkeyToAddress(s.key.PublicKey)}
+}
+
+func (s *fakeWeb3Signer) Sign(address common.Address, data hexutil.Bytes) (hexutil.Bytes, error) {
+	signature, err := crypto.Sign(accounts.TextHash(data), s.key)
+	if err != nil {
+		return nil, err
+	}
+	signature[crypto.RecoveryIDOffset] += 27
+	return signature, nil
+}
+
+type fakeSignTxArgs struct {
+	To                   *common.Address `json:"to"`
+	Gas                  hexutil.Uint64  `json:"gas"`
+	Value                *hexutil.Big    `json:"value"`
+	Data                 hexutil.Bytes   `json:"data"`
+	Nonce                hexutil.Uint64  `json:"nonce"`
+	ChainID              *hexutil.Big    `json:"chainId"`
+	MaxFeePerGas         *hexutil.Big    `json:"maxFeePerGas"`
+	MaxPriorityFeePerGas *hexutil.Big    `json:"maxPriorityFeePerGas"`
+}
+
+func (s *fakeWeb3Signer) SignTransaction(args fakeSignTxArgs) (hexutil.Bytes, error) {
+	tx := types.NewTx(&types.DynamicFeeTx{
+		ChainID:   args.ChainID.ToInt(),
+		Nonce:     uint64(args.Nonce),
+		GasTipCap: args.MaxPriorityFeePerGas.ToInt(),
+		GasFeeCap: args.MaxFeePerGas.ToInt(),
+		Gas:       uint64(args.Gas),
+		To:        args.To,
+		Value:     args.Value.ToInt(),
+		Data:      args.Data,
+	})
+	signed, err := types.SignTx(tx, types.LatestSignerForChainID(args.ChainID.ToInt()), s.key)
+	if err != nil {
+		return nil, err
+	}
+	return signed.MarshalBinary()
+}
+
+func newTestWeb3Signer(t *testing.T, key *ecdsa.PrivateKey) *Web3Signer {
+	t.Helper()
+
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &fakeWeb3Signer{key: key}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	t.Cleanup(httpServer.Close)
+
+	signer, err := NewWeb3Signer(context.Background(), httpServer.URL, crypto.PubkeyToAddress(key.PublicKey))
+	if err != nil {
+		t.Fatalf("NewWeb3Signer: %v", err)
+	}
+	t.Cleanup(signer.Close)
+	return signer
+}
+
+func TestWeb3SignerSignsChannelStates(t *testing.T) {
+	signer := newTestWeb3Signer(t, testKey(3))
+	c := newTestClient(t, newMockBackend(), Config{Signer: signer})
+	channelID := [32]byte{1}
+
+	signature, err := c.SignChannelState(channelID, big.NewInt(600), big.NewInt(400), 5)
+	if err != nil {
+		t.Fatalf("SignChannelState: %v", err)
+	}
+	got, err := recoverSigner(c.channelStateHash(channelID, big.NewInt(600), big.NewInt(400), 5), signature)
+	if err != nil || got != signer.Address() {
+		t.Errorf("channel state signed by %s, want %s", got.Hex(), signer.Address().Hex())
+	}
+
+	if _, err := signer.SignHash(context.Background(), make([]byte, 32)); !errors.Is(err, accounts.ErrNotSupported) {
+		t.Errorf("SignHash error = %v, want accounts.ErrNotSupported", err)
+	}
+}
+
+func TestWeb3SignerSignsTransactions(t *testing.T) {
+	backend := newMockBackend()
+	signer := newTestWeb3Signer(t, testKey(3))
+	c := newTestClient(t, backend, Config{Signer: signer})
+
+	hash, err := c.Transfer(context.Background(), testAddress(1), big.NewInt(500))
+	if err != nil {
+		t.Fatalf("Transfer: %v", err)
+	}
+
+	sent := backend.sentTxs()
+	if len(sent) != 1 || sent[0].Hash() != hash {
+		t.Fatalf("sent %d transactions, want the transfer %s", len(sent), hash.Hex())
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(c.ChainID()), sent[0])
+	if err != nil || from != signer.Address() {
+		t.Errorf("transfer sent from %s, want %s", from.Hex(), signer.Address().Hex())
+	}
+}
+
+func TestWeb3SignerRejectsUnknownAccount(t *testing.T) {
+	server := rpc.NewServer()
+	if err := server.RegisterName("eth", &fakeWeb3Signer{key: testKey(3)}); err != nil {
+		t.Fatal(err)
+	}
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	if _, err := NewWeb3Signer(context.Background(), httpServer.URL, testAddress(4)); err == nil {
+		t.Fatal("NewWeb3Signer accepted an account the signer does not hold")
+	}
+}